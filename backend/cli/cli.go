@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Command is a command of ISAAC, run instead of the server like "isaac export txs -channel loopchain_default".
+type Command struct {
+	Name  string
+	Usage string
+	Run   func(args []string) error
+}
+
+var commands []Command
+
+// Output of usage and errors.
+var stderr io.Writer = os.Stderr
+
+func register(command Command) {
+	commands = append(commands, command)
+}
+
+// Run runs the command in args, and returns the exit code.
+func Run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage()
+		return 0
+	}
+
+	for _, command := range commands {
+		if command.Name != args[0] {
+			continue
+		}
+
+		if err := command.Run(args[1:]); err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintf(stderr, "%s: %s\n", command.Name, err)
+			}
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "Unknown command %q.\n", args[0])
+	printUsage()
+	return 2
+}
+
+func printUsage() {
+	fmt.Fprintln(stderr, "Usage: isaac [command] [arguments]")
+	fmt.Fprintln(stderr, "Runs the server without command.")
+	fmt.Fprintln(stderr, "\nCommands:")
+	for _, command := range commands {
+		fmt.Fprintf(stderr, "  %-10s %s\n", command.Name, command.Usage)
+	}
+}
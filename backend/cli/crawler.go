@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"motherbear/backend/db"
+	"motherbear/backend/polarbear"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func init() {
+	register(Command{
+		Name:  "crawler",
+		Usage: "Pause or resume crawling, and run or cancel the crawl jobs. Run 'isaac crawler -h' for the arguments.",
+		Run:   runCrawler,
+	})
+}
+
+// runCrawler runs the control of crawler in args[0] with the other args.
+func runCrawler(args []string) error {
+	flags := flag.NewFlagSet("crawler", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: isaac crawler [pause|resume|recrawl|rebuild|jobs|cancel] [arguments]")
+		fmt.Fprintln(stderr, "  pause, resume  Pause or resume crawling in the channel of the server.")
+		fmt.Fprintln(stderr, "  recrawl        Delete the blocks between the heights and crawl them again.")
+		fmt.Fprintln(stderr, "  rebuild        Purge the blocks not pruned and crawl them up to the last block height.")
+		fmt.Fprintln(stderr, "                 Recrawl and rebuild run in this process. Pause the channel first if the server runs.")
+		fmt.Fprintln(stderr, "  jobs           Show the crawl jobs run by the server or the command.")
+		fmt.Fprintln(stderr, "  cancel         Cancel the running crawl job.")
+		flags.PrintDefaults()
+	}
+
+	channelID := flags.String("channel", "", "Channel name or PK of channel. Required except jobs and cancel.")
+	fromHeight := flags.Int64("fromHeight", 0, "The lowest block height to recrawl.")
+	toHeight := flags.Int64("toHeight", 0, "The highest block height to recrawl.")
+	jobID := flags.Uint("job", 0, "ID of the crawl job to cancel.")
+	limit := flags.Int("limit", 20, "The count of the latest crawl jobs to show.")
+
+	if len(args) == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	action := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	channelName := ""
+	if *channelID != "" {
+		var err error
+		if channelName, err = db.ConvertPKCHtoChannelName(*channelID); err != nil {
+			return err
+		}
+	}
+	if channelName == "" && action != "jobs" && action != "cancel" {
+		flags.Usage()
+		return errors.New("channel is required")
+	}
+
+	switch action {
+	case "pause":
+		if err := polarbear.PauseCrawling(channelName); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Crawling is paused in %s.\n", channelName)
+	case "resume":
+		if err := polarbear.ResumeCrawling(channelName); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Crawling is resumed in %s.\n", channelName)
+	case "recrawl":
+		return runCrawlJob(channelName, polarbear.CrawlJobRecrawl, *fromHeight, *toHeight)
+	case "rebuild":
+		return runCrawlJob(channelName, polarbear.CrawlJobRebuild, 0, 0)
+	case "jobs":
+		var jobs []polarbear.CrawlJob
+		if _, err := polarbear.QueryCrawlJobs(channelName, *limit, 0, &jobs); err != nil {
+			return err
+		}
+		for i := range jobs {
+			printCrawlJob(&jobs[i])
+		}
+	case "cancel":
+		var job polarbear.CrawlJob
+		if err := polarbear.CancelCrawlJob(*jobID, &job); err != nil {
+			return err
+		}
+		fmt.Fprintf(stderr, "Crawl job %d is canceled. It stops after the blocks in progress are crawled.\n", job.ID)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	return nil
+}
+
+// runCrawlJob runs the crawl job in this process with the progress. The interrupt cancels the job.
+// The server skips the channel once the job is created, but the lock of crawling is not shared between processes.
+// The blocks being crawled by the server when the job begins may be stored while the job deletes them,
+// so pause crawling in the channel first, or run the job through the API of the server.
+func runCrawlJob(channelName string, kind string, fromHeight int64, toHeight int64) error {
+	var job polarbear.CrawlJob
+	if err := polarbear.CreateCrawlJob(channelName, kind, fromHeight, toHeight, &job); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Crawl job %d is started. Interrupt to cancel it.\n", job.ID)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer close(interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			var canceled polarbear.CrawlJob
+			_ = polarbear.CancelCrawlJob(job.ID, &canceled)
+		}
+	}()
+
+	err := polarbear.RunCrawlJob(&job, func(job *polarbear.CrawlJob) {
+		fmt.Fprintf(stderr, "Crawled %d of %d blocks from %d to %d. Failed %d.\n",
+			job.CountOfDone, job.CountOfBlocks, job.BeginHeight, job.EndHeight, job.CountOfFailed)
+	})
+	printCrawlJob(&job)
+	if err != nil {
+		return err
+	}
+	if job.CountOfFailed != 0 {
+		fmt.Fprintf(stderr, "%d blocks failed to crawl. They are crawled again by backfill of the server.\n",
+			job.CountOfFailed)
+	}
+	return nil
+}
+
+func printCrawlJob(job *polarbear.CrawlJob) {
+	finishedAt := ""
+	if job.FinishedAt != nil {
+		finishedAt = job.FinishedAt.Format(time.RFC3339)
+	}
+	fmt.Fprintf(stderr, "%d %s %s %d-%d %s %d/%d failed:%d %s %s %s\n",
+		job.ID, job.Channel, job.Kind, job.BeginHeight, job.EndHeight, job.Status, job.CountOfDone,
+		job.CountOfBlocks, job.CountOfFailed, job.CreatedAt.Format(time.RFC3339), finishedAt, job.LastError)
+}
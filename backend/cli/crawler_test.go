@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/polarbear"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCrawler(t *testing.T) {
+	output := setup(t)
+	defer func() { stderr = os.Stderr }()
+
+	assert.Equal(t, Run([]string{"crawler", "pause", "-channel", "channel1"}), 0)
+	assert.Equal(t, strings.Contains(output.String(), "Crawling is paused in channel1."), true)
+	paused, _ := polarbear.IsCrawlingPaused("channel1")
+	assert.Equal(t, paused, true)
+
+	assert.Equal(t, Run([]string{"crawler", "resume", "-channel", "channel1"}), 0)
+	paused, _ = polarbear.IsCrawlingPaused("channel1")
+	assert.Equal(t, paused, false)
+
+	// No node serves the blocks in test. They are left to backfill.
+	output.Reset()
+	assert.Equal(t, Run([]string{"crawler", "recrawl", "-channel", "channel1", "-fromHeight", "4", "-toHeight", "5"}), 0)
+	assert.Equal(t, strings.Contains(output.String(), "Crawled 2 of 2 blocks from 4 to 5. Failed 2."), true)
+	assert.Equal(t, polarbear.GetCurrentBlockHeightInDB("channel1"), int64(3))
+
+	output.Reset()
+	assert.Equal(t, Run([]string{"crawler", "jobs"}), 0)
+	assert.Equal(t, strings.Contains(output.String(), "channel1 Recrawl 4-5 Done 2/2 failed:2"), true)
+
+	// No job of the ID.
+	assert.Equal(t, Run([]string{"crawler", "cancel", "-job", "100"}), 1)
+
+	// Channel and range are required.
+	assert.Equal(t, Run([]string{"crawler", "pause"}), 1)
+	assert.Equal(t, Run([]string{"crawler", "recrawl", "-channel", "channel1"}), 1)
+	assert.Equal(t, Run([]string{"crawler", "unknown", "-channel", "channel1"}), 1)
+}
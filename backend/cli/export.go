@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/handlers/export"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/polarbear"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(Command{
+		Name:  "export",
+		Usage: "Export blocks, txs or symptoms as CSV or NDJSON. Run 'isaac export -h' for the arguments.",
+		Run:   runExport,
+	})
+}
+
+// runExport exports the data in args[0] with the search in the other args.
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: isaac export [blocks|txs|symptoms] [arguments]")
+		flags.PrintDefaults()
+	}
+
+	channelID := flags.String("channel", "", "Channel name or PK of channel. Required for blocks and txs.")
+	format := flags.String("format", constants.ExportFormatNDJSON, "Format to export, csv or ndjson.")
+	out := flags.String("out", "", "File to export. Standard output if empty.")
+	fromHeight := flags.Int64("fromHeight", 0, "The lowest block height to export.")
+	toHeight := flags.Int64("toHeight", 0, "The highest block height to export.")
+	from := flags.String("from", "", "The first time to export in RFC3339.")
+	to := flags.String("to", "", "The last time to export in RFC3339.")
+	status := flags.String("status", "", "Status of Txs, Success, Failure or Pending.")
+	blockHeight := flags.Int64("blockHeight", -1, "Block height of Txs.")
+	fromAddress := flags.String("fromAddress", "", "Sender of Txs.")
+	toAddress := flags.String("toAddress", "", "Receiver of Txs.")
+	data := flags.String("data", "", "Phrase in the data of Txs.")
+
+	if len(args) == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	kind := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	fromTime, toTime, err := parseTimeRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	channelName := ""
+	if *channelID != "" {
+		if channelName, err = db.ConvertPKCHtoChannelName(*channelID); err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	buffer := bufio.NewWriter(w)
+
+	var count int64
+	switch kind {
+	case "blocks":
+		if channelName == "" {
+			return errors.New("channel is required")
+		}
+		search := polarbear.BlockSearch{FromHeight: *fromHeight, ToHeight: *toHeight, From: fromTime, To: toTime}
+		count, err = export.ExportBlocks(buffer, *format, channelName, search)
+	case "txs":
+		if channelName == "" {
+			return errors.New("channel is required")
+		}
+		search := polarbear.TxSearch{
+			BlockHeight: *blockHeight,
+			From:        fromTime,
+			To:          toTime,
+			FromAddress: *fromAddress,
+			ToAddress:   *toAddress,
+			Data:        *data,
+			FromHeight:  *fromHeight,
+			ToHeight:    *toHeight,
+		}
+		if *status != "" {
+			search.Status = strings.Title(strings.ToLower(*status))
+			if search.Status != polarbear.TxStatusSuccess && search.Status != polarbear.TxStatusFailure &&
+				search.Status != polarbear.TxStatusPending {
+				return isaacerror.SysErrInvalidTransactionStatus
+			}
+		}
+		count, err = export.ExportTxs(buffer, *format, channelName, search)
+	case "symptoms":
+		var channelPKs []string
+		if channelPKs, err = getChannelPKs(channelName); err != nil {
+			return err
+		}
+		count, err = export.ExportSymptoms(buffer, *format, channelPKs, fromTime, toTime)
+	default:
+		flags.Usage()
+		return fmt.Errorf("unknown data %q", kind)
+	}
+	if err != nil {
+		return err
+	}
+	if err := buffer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stderr, "Exported %d %s.\n", count, kind)
+	return nil
+}
+
+// parseTimeRange parses the times in RFC3339. Empty is zero time.
+func parseTimeRange(from string, to string) (time.Time, time.Time, error) {
+	var fromTime, toTime time.Time
+	var err error
+	if from != "" {
+		if fromTime, err = time.Parse(time.RFC3339, from); err != nil {
+			return fromTime, toTime, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+	}
+	if to != "" {
+		if toTime, err = time.Parse(time.RFC3339, to); err != nil {
+			return fromTime, toTime, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+	}
+	return fromTime, toTime, nil
+}
+
+// getChannelPKs returns PK of the channel, or PKs of every channel if the channel is empty.
+func getChannelPKs(channelName string) ([]string, error) {
+	if channelName != "" {
+		channelPK, exists := db.GetChannelNameToPKMap()[channelName]
+		if !exists {
+			return nil, isaacerror.SysErrNoChannelInDB
+		}
+		return []string{channelPK}, nil
+	}
+
+	var channelPKs []string
+	for _, channel := range db.GetConfigurationChannelTable() {
+		channelPKs = append(channelPKs, channel.CHANNEL_PK)
+	}
+	return channelPKs, nil
+}
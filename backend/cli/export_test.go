@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"gopkg.in/go-playground/assert.v1"
+	"io/ioutil"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T) *bytes.Buffer {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	for h := 1; h <= 5; h++ {
+		block := polarbear.Block{
+			Channel:     "channel1",
+			BlockHeight: int64(h),
+			BlockHash:   "0x" + strconv.Itoa(h),
+			Timestamp:   time.Now(),
+			Txs: []polarbear.Tx{{
+				TxHash:      "0x" + strconv.Itoa(h),
+				Status:      "Success",
+				Channel:     "channel1",
+				BlockHeight: int64(h),
+				Timestamp:   time.Now(),
+				From:        "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+				To:          "cx54d95fee187faaea03cee908f50623c8381179d0",
+			}},
+		}
+		if err := polarbear.Database().Save(&block).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := &bytes.Buffer{}
+	stderr = output
+	return output
+}
+
+func TestRun(t *testing.T) {
+	output := setup(t)
+	defer func() { stderr = os.Stderr }()
+
+	assert.Equal(t, Run([]string{"help"}), 0)
+	assert.Equal(t, strings.Contains(output.String(), "export"), true)
+
+	assert.Equal(t, Run([]string{"unknown"}), 2)
+	assert.Equal(t, Run([]string{"export", "unknown", "-channel", "channel1"}), 1)
+}
+
+func TestExport(t *testing.T) {
+	output := setup(t)
+	defer func() { stderr = os.Stderr }()
+
+	file, err := ioutil.TempFile("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	// Txs as CSV.
+	assert.Equal(t, Run([]string{"export", "txs", "-channel", "channel1", "-format", "csv", "-fromHeight", "2",
+		"-status", "success", "-out", file.Name()}), 0)
+	assert.Equal(t, strings.Contains(output.String(), "Exported 4 txs."), true)
+
+	data, _ := ioutil.ReadFile(file.Name())
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 5)
+	assert.Equal(t, records[1][0], "0x2")
+
+	// Blocks as NDJSON.
+	assert.Equal(t, Run([]string{"export", "blocks", "-channel", "channel1", "-toHeight", "3", "-out", file.Name()}), 0)
+	data, _ = ioutil.ReadFile(file.Name())
+	assert.Equal(t, len(strings.Split(strings.TrimSpace(string(data)), "\n")), 3)
+
+	// Pending Txs, and wrong status.
+	assert.Equal(t, Run([]string{"export", "txs", "-channel", "channel1", "-status", "pending", "-out", file.Name()}), 0)
+	assert.Equal(t, Run([]string{"export", "txs", "-channel", "channel1", "-status", "done", "-out", file.Name()}), 1)
+
+	// Channel is required.
+	assert.Equal(t, Run([]string{"export", "blocks"}), 1)
+
+	// Wrong format.
+	assert.Equal(t, Run([]string{"export", "blocks", "-channel", "channel1", "-format", "xml", "-out", file.Name()}), 1)
+}
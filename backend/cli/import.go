@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"motherbear/backend/db"
+	"motherbear/backend/polarbear"
+	"os"
+)
+
+func init() {
+	register(Command{
+		Name:  "import",
+		Usage: "Import blocks from the dump of JSON-RPC responses. Run 'isaac import -h' for the arguments.",
+		Run:   runImport,
+	})
+}
+
+// runImport stores the blocks in the dump files in channel.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: isaac import -channel [channel] -blocks [file] [arguments]")
+		flags.PrintDefaults()
+	}
+
+	channelID := flags.String("channel", "", "Channel name or PK of channel. Required.")
+	blocksPath := flags.String("blocks", "", "File of icx_getBlockByHeight responses, one block per line. Required.")
+	txResultsPath := flags.String("txResults", "",
+		"File of icx_getTransactionResult responses, one result per line. Every Tx is success if empty.")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *channelID == "" || *blocksPath == "" {
+		flags.Usage()
+		return errors.New("channel and blocks are required")
+	}
+
+	channelName, err := db.ConvertPKCHtoChannelName(*channelID)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := os.Open(*blocksPath)
+	if err != nil {
+		return err
+	}
+	defer blocks.Close()
+
+	var txResults io.Reader
+	if *txResultsPath != "" {
+		file, err := os.Open(*txResultsPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		txResults = file
+	}
+
+	result, err := polarbear.ImportBlocks(channelName, blocks, txResults)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(stderr, "Line %d, block %d: %s\n", e.Line, e.BlockHeight, e.Error)
+	}
+	fmt.Fprintf(stderr, "Imported %d blocks. Duplicated %d, failed %d.\n",
+		result.Imported, result.Duplicated, result.Failed)
+	if result.Failed != 0 {
+		return fmt.Errorf("%d blocks failed to import", result.Failed)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"gopkg.in/go-playground/assert.v1"
+	"io/ioutil"
+	"motherbear/backend/polarbear"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	output := setup(t)
+	defer func() { stderr = os.Stderr }()
+
+	file, err := ioutil.TempFile("", "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	// The blocks on the top of the test data, and the block at the same height with the other hash.
+	for h := 5; h <= 7; h++ {
+		fmt.Fprintf(file, `{"version":"0.3","height":%d,"hash":"0x%064d","prevHash":"","timestamp":1560000000000000,`+
+			`"transactions":[],"leader":"hx01"}`+"\n", h, h)
+	}
+	file.Close()
+
+	assert.Equal(t, Run([]string{"import", "-channel", "channel1", "-blocks", file.Name()}), 1)
+	assert.Equal(t, strings.Contains(output.String(), "Imported 2 blocks. Duplicated 0, failed 1."), true)
+	assert.Equal(t, strings.Contains(output.String(), "Line 1, block 5"), true)
+	assert.Equal(t, polarbear.GetCurrentBlockHeightInDB("channel1"), int64(7))
+
+	// Blocks are required.
+	assert.Equal(t, Run([]string{"import", "-channel", "channel1"}), 1)
+}
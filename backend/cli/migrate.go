@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"motherbear/backend/db"
+	"motherbear/backend/migration"
+	"motherbear/backend/polarbear"
+)
+
+// MigrateCommandName is the name of the command to migrate DB. The server opens DB without the migrations for it.
+const MigrateCommandName = "migrate"
+
+func init() {
+	register(Command{
+		Name:  MigrateCommandName,
+		Usage: "Apply the schema migrations of ISAAC and crawling DB. Run 'isaac migrate -h' for the arguments.",
+		Run:   runMigrate,
+	})
+}
+
+// migrationTarget is DB to migrate.
+type migrationTarget struct {
+	name    string
+	version func() (int, error)
+	migrate func(dryRun bool) ([]migration.Migration, error)
+}
+
+var migrationTargets = []migrationTarget{
+	{"isaac", db.SchemaVersion, db.Migrate},
+	{"crawl", polarbear.SchemaVersion, polarbear.Migrate},
+}
+
+// runMigrate applies the pending migrations of DB in args, or shows them in dry run.
+func runMigrate(args []string) error {
+	flags := flag.NewFlagSet(MigrateCommandName, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: isaac migrate [arguments]")
+		flags.PrintDefaults()
+	}
+
+	target := flags.String("db", "all", "DB to migrate, all, isaac or crawl.")
+	dryRun := flags.Bool("dryRun", false, "Show the pending migrations without applying them.")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	migrated := false
+	for _, t := range migrationTargets {
+		if *target != "all" && *target != t.name {
+			continue
+		}
+		migrated = true
+
+		version, err := t.version()
+		if err != nil {
+			return err
+		}
+
+		migrations, err := t.migrate(*dryRun)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "Applied %d migrations to %s DB before the failure.\n", len(migrations), t.name)
+		case len(migrations) == 0:
+			fmt.Fprintf(stderr, "%s DB is up to date at version %d.\n", t.name, version)
+		case *dryRun:
+			fmt.Fprintf(stderr, "%s DB at version %d has %d migrations pending. Nothing applied in dry run.\n",
+				t.name, version, len(migrations))
+		default:
+			fmt.Fprintf(stderr, "Migrated %s DB from version %d to %d.\n",
+				t.name, version, migrations[len(migrations)-1].Version)
+		}
+		for _, m := range migrations {
+			fmt.Fprintf(stderr, "  %d: %s\n", m.Version, m.Description)
+		}
+		if err != nil {
+			return fmt.Errorf("%s DB: %s", t.name, err)
+		}
+	}
+
+	if !migrated {
+		flags.Usage()
+		return fmt.Errorf("unknown DB %q", *target)
+	}
+	return nil
+}
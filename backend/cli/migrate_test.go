@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"fmt"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	output := setup(t)
+	defer func() { stderr = os.Stderr }()
+
+	// Crawling DB opened without the migrations.
+	polarbear.OpenDB(constants.DBTypeSqlite3, ":memory:")
+	defer polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Every migration is pending in the empty DB.
+	pending, _ := polarbear.Migrate(true)
+	latest := pending[len(pending)-1].Version
+
+	assert.Equal(t, Run([]string{"migrate", "-db", "crawl", "-dryRun"}), 0)
+	assert.Equal(t, strings.Contains(output.String(),
+		fmt.Sprintf("crawl DB at version 0 has %d migrations pending.", len(pending))), true)
+	assert.Equal(t, strings.Contains(output.String(), "  1: Create the tables"), true)
+	assert.Equal(t, polarbear.Database().HasTable(&polarbear.Block{}), false)
+
+	output.Reset()
+	assert.Equal(t, Run([]string{"migrate", "-db", "crawl"}), 0)
+	assert.Equal(t, strings.Contains(output.String(),
+		fmt.Sprintf("Migrated crawl DB from version 0 to %d.", latest)), true)
+	assert.Equal(t, polarbear.Database().HasTable(&polarbear.Block{}), true)
+	version, err := polarbear.SchemaVersion()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version, latest)
+
+	output.Reset()
+	assert.Equal(t, Run([]string{"migrate", "-db", "crawl"}), 0)
+	assert.Equal(t, strings.Contains(output.String(),
+		fmt.Sprintf("crawl DB is up to date at version %d.", latest)), true)
+
+	assert.Equal(t, Run([]string{"migrate", "-db", "unknown"}), 1)
+}
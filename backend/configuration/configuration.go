@@ -1,12 +1,19 @@
 package configuration
 
 import (
+	"database/sql"
 	"io/ioutil"
 	"motherbear/backend/constants"
+	"motherbear/backend/isaacerror"
+	"net"
+	"net/url"
+	"strconv"
 	"sync"
+	"time"
 
 	"motherbear/backend/logger"
 
+	"github.com/go-sql-driver/mysql"
 	"gopkg.in/yaml.v2"
 )
 
@@ -28,8 +35,14 @@ type Nodes struct {
 
 // Channels configurations.
 type Channels struct {
-	Name  string   `yaml:"name"`
-	Nodes []string `yaml:",flow"`
+	Name        string   `yaml:"name"`
+	Nodes       []string `yaml:",flow"`
+	Concurrency int      `yaml:"concurrency,omitempty"` // Count of workers to crawl blocks.
+
+	// Crawling of the channel. The zero values are the defaults.
+	CrawlDisabled    bool  `yaml:"crawlDisabled,omitempty"`    // Blocks in the channel are not crawled.
+	StartHeight      int64 `yaml:"startHeight,omitempty"`      // The lowest block height to crawl. 0 is from the first block.
+	CrawlingInterval int   `yaml:"crawlingInterval,omitempty"` // Interval in seconds to poll. 0 is the interval of blockchain.
 }
 
 // Prometheus configurations.
@@ -45,7 +58,23 @@ type Prometheus struct {
 // Blockchain configurations
 type Blockchain struct {
 	CrawlingInterval int        `yaml:"crawlingInterval"`
+	BackfillInterval int        `yaml:"backfillInterval"`
+	RequestPerSecond int        `yaml:"requestPerSecond"`         // Limit of requests per second to a node.
+	Streaming        bool       `yaml:"streaming,omitempty"`      // Subscribe new blocks through WebSocket of goloop node.
+	CrawlerMetrics   bool       `yaml:"crawlerMetrics,omitempty"` // Serve the metrics of crawler for prometheus.
 	DB               []DBConfig `yaml:"db"`
+
+	Retention              []Retention `yaml:"retention,omitempty"`
+	SymptomRetentionInDays int         `yaml:"symptomRetentionInDays,omitempty"` // 0 for no limit.
+	PruningInterval        int         `yaml:"pruningInterval,omitempty"`
+	ReconcileInterval      int         `yaml:"reconcileInterval,omitempty"` // Interval to query the result of pending Txs.
+}
+
+// Retention configurations of the blocks in channel. Channel "" is the default for the other channels.
+type Retention struct {
+	Channel      string `yaml:"channel"`
+	MaxAgeInDays int    `yaml:"maxAgeInDays,omitempty"` // Keep the blocks in the days. 0 for no limit.
+	MaxBlocks    int64  `yaml:"maxBlocks,omitempty"`    // Keep the most recent blocks. 0 for no limit.
 }
 
 // Etc configurations
@@ -69,6 +98,97 @@ type DBConfig struct {
 	Pass     string `yaml:"pass"`
 	Database string `yaml:"database"`
 	DBPath   string `yaml:"path"`
+	Host     string `yaml:"host,omitempty"`    // For mysql and postgres. The default port of the DB type if no port.
+	Port     int    `yaml:"port,omitempty"`    // For mysql and postgres.
+	SSLMode  string `yaml:"sslmode,omitempty"` // For postgres. disable, require, verify-ca or verify-full.
+	Schema   string `yaml:"schema,omitempty"`  // For postgres. Created if not exist.
+}
+
+// Validate checks the DB type is supported and its driver is linked in the binary.
+func (c DBConfig) Validate() error {
+	switch c.DBType {
+	case constants.DBTypeSqlite3, constants.DBTypeMysql, constants.DBTypePostgres:
+	default:
+		logger.Errorf("%s is not supported DB type.", c.DBType)
+		return isaacerror.SysErrNotSupportedDBType
+	}
+
+	for _, driver := range sql.Drivers() {
+		if driver == c.DBType {
+			return nil
+		}
+	}
+	logger.Errorf("The driver of %s is not linked in the binary.", c.DBType)
+	return isaacerror.SysErrDBDriverNotLinked
+}
+
+// DataSourceName returns the data source name to open the DB of the type.
+// The database of mysql is "tcp(host:port)/name" if no host, or the name of database.
+func (c DBConfig) DataSourceName() (string, error) {
+	switch c.DBType {
+	case constants.DBTypeSqlite3:
+		return c.DBPath, nil
+	case constants.DBTypeMysql:
+		return c.mysqlDataSourceName()
+	case constants.DBTypePostgres:
+		return c.postgresDataSourceName(), nil
+	}
+
+	return "", isaacerror.SysErrNotSupportedDBType
+}
+
+func (c DBConfig) mysqlDataSourceName() (string, error) {
+	config := mysql.NewConfig()
+	if c.Host != "" {
+		port := c.Port
+		if port == 0 {
+			port = 3306
+		}
+		config.Net = "tcp"
+		config.Addr = net.JoinHostPort(c.Host, strconv.Itoa(port))
+		config.DBName = c.Database
+	} else {
+		parsed, err := mysql.ParseDSN("@" + c.Database)
+		if err != nil {
+			return "", err
+		}
+		config = parsed
+	}
+
+	config.User = c.Id
+	config.Passwd = c.Pass
+	config.ParseTime = true
+	config.Loc = time.Local
+	config.Params = map[string]string{"charset": "utf8mb4,utf8"}
+	return config.FormatDSN(), nil
+}
+
+func (c DBConfig) postgresDataSourceName() string {
+	host := c.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := c.Port
+	if port == 0 {
+		port = 5432
+	}
+
+	query := url.Values{}
+	if c.SSLMode != "" {
+		query.Set("sslmode", c.SSLMode)
+	}
+	if c.Schema != "" {
+		query.Set("search_path", c.Schema)
+	}
+
+	dataSource := url.URL{
+		Scheme:   constants.DBTypePostgres,
+		User:     url.UserPassword(c.Id, c.Pass),
+		Host:     net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:     "/" + c.Database,
+		RawQuery: query.Encode(),
+	}
+	return dataSource.String()
 }
 
 var instance *Configuration
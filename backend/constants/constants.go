@@ -16,9 +16,12 @@ const HTTPHeaderContentType = "Content-Type"
 const HTTPHeaderContentRange = "Content-Range"
 const HTTPHeaderXTotalCount = "X-Total-Count"
 const HTTPHeaderContentLength = "Content-Length"
+const HTTPHeaderContentDisposition = "Content-Disposition"
 
 // HTTP Header content
 const HTTPContentTypeApplicationJson = "application/json"
+const HTTPContentTypeTextCSV = "text/csv"
+const HTTPContentTypeNDJSON = "application/x-ndjson"
 const HTTPAuthorizationJWTType = "Bearer "
 
 // HTTP request data key.
@@ -26,6 +29,9 @@ const RequestResourceID = "id"
 const RequestParamChannel = "channel"
 const RequestParamBlockID = "blockid"
 const RequestParamTxHash = "txhash"
+const RequestParamAddress = "address"
+const RequestParamBlockHeight = "blockheight"
+const RequestParamJobID = "jobid"
 const RequestQueryLimit = "limit"
 const RequestQueryOffset = "offset"
 const RequestQueryFrom = "from"
@@ -36,6 +42,17 @@ const RequestQueryBlockHeight = "blockHeight"
 const RequestQueryFromAddress = "fromAddress"
 const RequestQueryToAddress = "toAddress"
 const RequestQueryData = "data"
+const RequestQueryContract = "contract"
+const RequestQuerySignature = "signature"
+const RequestQueryIndexed = "indexed"
+const RequestQueryFromHeight = "fromHeight"
+const RequestQueryToHeight = "toHeight"
+const RequestQueryDirection = "direction"
+const RequestQueryAddress = "address"
+const RequestQueryInterval = "interval"
+const RequestQueryFormat = "format"
+const RequestQueryKind = "kind"
+const RequestQuerySearch = "q"
 
 // Gin context data key.
 const ContextKeyPermissionChannelList = "permissionChannelList"
@@ -93,6 +110,74 @@ const TxAPIBaseURL = "/txs"
 const TxGETListAPIURL = TxAPIBaseURL
 const TxGETAPIURL = TxAPIBaseURL + "/:txhash"
 
+// Event API URL
+const EventAPIBaseURL = "/events"
+const EventGETListAPIURL = EventAPIBaseURL
+
+// Address API URL
+const AddressAPIBaseURL = "/addresses"
+const AddressGETAPIURL = AddressAPIBaseURL + "/:" + RequestParamAddress
+
+// Contract API URL
+const ContractAPIBaseURL = "/contracts"
+const ContractGETListAPIURL = ContractAPIBaseURL
+const ContractGETAPIURL = ContractAPIBaseURL + "/:" + RequestParamAddress
+
+// Stats API URL
+const StatsAPIBaseURL = "/stats"
+const StatsVolumeGETAPIURL = StatsAPIBaseURL + "/volume"
+
+// Token API URL
+const TokenAPIBaseURL = "/tokens"
+const TokenGETListAPIURL = TokenAPIBaseURL
+const TokenHoldersGETAPIURL = TokenAPIBaseURL + "/:" + RequestParamAddress + "/holders"
+const TokenTransfersGETAPIURL = TokenAPIBaseURL + "/:" + RequestParamAddress + "/transfers"
+
+// Quarantine API URL
+const QuarantineAPIBaseURL = "/quarantine"
+const QuarantineGETListAPIURL = QuarantineAPIBaseURL
+const QuarantineGETAPIURL = QuarantineAPIBaseURL + "/:" + RequestParamBlockHeight
+const QuarantineRetryPOSTAPIURL = QuarantineAPIBaseURL + "/:" + RequestParamBlockHeight + "/retry"
+
+// Retention API URL
+const RetentionAPIBaseURL = "/retention"
+const RetentionGETAPIURL = RetentionAPIBaseURL
+
+// Search API URL
+const SearchAPIBaseURL = "/search"
+const SearchGETAPIURL = SearchAPIBaseURL
+
+// Export API URL
+const ExportAPIBaseURL = "/export"
+const ExportBlocksGETAPIURL = ExportAPIBaseURL + "/blocks"
+const ExportTxsGETAPIURL = ExportAPIBaseURL + "/txs"
+const ExportSymptomsGETAPIURL = ExportAPIBaseURL + "/symptoms"
+
+// Export format
+const ExportFormatCSV = "csv"
+const ExportFormatNDJSON = "ndjson"
+
+// Import API URL
+const ImportAPIBaseURL = "/import"
+const ImportPOSTAPIURL = ImportAPIBaseURL
+
+// Import form file
+const ImportFormFileBlocks = "blocks"
+const ImportFormFileTxResults = "txResults"
+
+// Crawler API URL
+const CrawlerAPIBaseURL = "/crawler"
+const CrawlerStatusGETAPIURL = CrawlerAPIBaseURL + "/status"
+const CrawlerPausePOSTAPIURL = CrawlerAPIBaseURL + "/pause"
+const CrawlerResumePOSTAPIURL = CrawlerAPIBaseURL + "/resume"
+const CrawlerJobsGETListAPIURL = CrawlerAPIBaseURL + "/jobs"
+const CrawlerJobsPOSTAPIURL = CrawlerAPIBaseURL + "/jobs"
+const CrawlerJobGETAPIURL = CrawlerAPIBaseURL + "/jobs/:" + RequestParamJobID
+const CrawlerJobCancelPOSTAPIURL = CrawlerAPIBaseURL + "/jobs/:" + RequestParamJobID + "/cancel"
+
+// Metrics of crawler for prometheus, served outside of the API.
+const CrawlerMetricsURL = "/metrics"
+
 // Resources API URL
 const ResourcesAPIBaseURL = "/resources"
 const ResourcesGETAPIURL = ResourcesAPIBaseURL + "/:id"
@@ -113,6 +198,7 @@ const DBFolderName = "data"
 // DB.
 const DBTypeMysql = "mysql"
 const DBTypeSqlite3 = "sqlite3"
+const DBTypePostgres = "postgres"
 const DBUserTypeCodeAdmin = "USER_ADMIN"
 const DBUserTypeCodeCommon = "USER_COMMON"
 const DBUserTypeCodeThirdParty = "THIRD_PARTY"
@@ -122,10 +208,17 @@ const DBDefaultUnsyncBlockToleranceTime = 360
 const DBDefaultSlowResponseTime = 5
 const DBDefaultUnsyncBlockDifference = 100
 
+// Block crawling.
+const DefaultBackfillIntervalInSec = 60
+const DefaultReconcileIntervalInSec = 10
+const DefaultRequestPerSecondToNode = 20
+const DefaultPruningIntervalInSec = 3600
+
 // Logger
 const LoggerServerUser = "Isaac Server"
 const SlowResponse = "Slow response"
 const UnsyncBlock = "Unsync block"
+const ChainReorg = "Chain reorg"
 
 // Auth.
 const InitTokenExpirationTimeInMin = 1
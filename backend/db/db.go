@@ -5,6 +5,7 @@ import (
 	"motherbear/backend/constants"
 	"motherbear/backend/isaacerror"
 	"motherbear/backend/logger"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,6 +18,7 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 
 	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -55,6 +57,12 @@ type CONFIGURATION_CHANNEL_TB struct {
 	CHANNEL_NAME string `gorm:"type:varchar(40)"`
 	CHANNEL_PK   string `gorm:"type:varchar(40);primary_key;not null"`
 	CHANNEL_ID   string `gorm:"type:varchar(40)"` // It is the channel ID of goloop. Can be use only on the goloop.
+
+	// Crawl configuration of the channel. The channels before it are crawled with the defaults.
+	CRAWL_DISABLED     bool  `gorm:"default:false"`
+	CRAWL_START_HEIGHT int64 `gorm:"default:0"` // The lowest block height to crawl. 0 is from the first block.
+	CRAWL_INTERVAL     int   `gorm:"default:0"` // Interval in seconds to poll. 0 is the interval of blockchain.
+	CRAWL_CONCURRENCY  int   `gorm:"default:0"` // Count of workers to crawl blocks. 0 is by the count of CPUs.
 }
 
 type CONFIGURATION_MASTER_TB struct {
@@ -157,16 +165,29 @@ func NewTransaction() (tx *Transaction) {
 	return transaction
 }
 
-// InitDB create instance of DB.
-func InitDB(dbType string, dbOption ...string) *gorm.DB {
+// InitDB create instance of DB, and applies the migrations of the tables.
+// The data source is from DataSourceName of DBConfig.
+func InitDB(dbType string, dataSource string) *gorm.DB {
+	OpenDB(dbType, dataSource)
+
+	// Create and migrate tables for ISAAC
+	if _, err := Migrate(false); err != nil {
+		panic(err)
+	}
+	return instance
+}
+
+// OpenDB create instance of DB without the migrations.
+func OpenDB(dbType string, dataSource string) *gorm.DB {
 	var err error
 	switch dbType {
-	case constants.DBTypeSqlite3: //sqlite3
-		instance, err = gorm.Open(dbType, dbOption[0])
-	case constants.DBTypeMysql: //mysql
-		instance, err = gorm.Open(dbType, dbOption[0]+
-			":"+dbOption[1]+"@"+dbOption[2]+
-			"?charset=utf8&parseTime=True&loc=Local")
+	case constants.DBTypeSqlite3, constants.DBTypeMysql:
+		instance, err = gorm.Open(dbType, dataSource)
+	case constants.DBTypePostgres:
+		instance, err = gorm.Open(dbType, dataSource)
+		if err == nil {
+			err = CreateSchemaIfNotExist(instance, dataSource)
+		}
 	}
 
 	if err != nil {
@@ -176,11 +197,24 @@ func InitDB(dbType string, dbOption ...string) *gorm.DB {
 		panic("Failed to create the handle")
 	}
 
-	// Create tables for ISAAC
-	InitCreateTable()
 	return instance
 }
 
+// CreateSchemaIfNotExist creates the schema in search_path of the postgres data source if not exist.
+// The tables are created in the schema.
+func CreateSchemaIfNotExist(database *gorm.DB, dataSource string) error {
+	parsed, err := url.Parse(dataSource)
+	if err != nil {
+		return err
+	}
+
+	schema := parsed.Query().Get("search_path")
+	if schema == "" {
+		return nil
+	}
+	return database.Exec("CREATE SCHEMA IF NOT EXISTS " + database.Dialect().Quote(schema)).Error
+}
+
 // CloseDBInstance delete instance of DB.
 func CloseDBInstance() {
 	err := DBgorm().Close()
@@ -57,6 +57,54 @@ func TestInitCreateTable(t *testing.T) {
 	}
 }
 
+// Every supported DB type is linked in the binary without build tags.
+func TestValidateDBConfig(t *testing.T) {
+	for _, dbType := range []string{constants.DBTypeSqlite3, constants.DBTypeMysql, constants.DBTypePostgres} {
+		assert.Equal(t, configuration.DBConfig{DBType: dbType}.Validate(), nil)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dbpath := ":memory:"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	version, err := SchemaVersion()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version, len(migrator.Migrations))
+
+	pending, err := Migrate(true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 0)
+}
+
+// Test the crawl configuration of channels in the file is kept in the channels before it.
+func TestMigrateChannelCrawl(t *testing.T) {
+	dbpath := ":memory:"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	// The channel table and schema version before the crawl configuration.
+	DBgorm().DropTable(&CONFIGURATION_CHANNEL_TB{})
+	DBgorm().Exec("CREATE TABLE configuration_channel_tbs (channel_name varchar(40), " +
+		"channel_pk varchar(40) NOT NULL, channel_id varchar(40), PRIMARY KEY (channel_pk))")
+	DBgorm().Exec("INSERT INTO configuration_channel_tbs (channel_name, channel_pk) VALUES (?, ?)",
+		"loopchain_default", "PKCH_0000000000000001")
+	DBgorm().Exec("DELETE FROM " + migrator.Table + " WHERE version > 1")
+
+	configuration.Conf().Channel[0].Concurrency = 4
+	defer func() { configuration.Conf().Channel[0].Concurrency = 0 }()
+
+	pending, err := Migrate(false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pending[0].Version, 2)
+
+	channelTB := GetConfigurationChannelInfoByName("loopchain_default")
+	assert.Equal(t, channelTB.CRAWL_DISABLED, false)
+	assert.Equal(t, channelTB.CRAWL_START_HEIGHT, int64(0))
+	assert.Equal(t, channelTB.CRAWL_CONCURRENCY, 4)
+}
+
 func Setup(path string) *gorm.DB {
 
 	// Initialize yaml settings
@@ -67,7 +115,21 @@ func Setup(path string) *gorm.DB {
 	// Read the configuration file.
 	configuration.InitConfigData("config/configuration.yaml")
 
-	database := InitDB("sqlite3", path)
+	// The DB of ISAAC_TEST_DB_TYPE and ISAAC_TEST_DB_SOURCE is used instead of the sqlite3 DB if set.
+	var database *gorm.DB
+	if dbType := os.Getenv("ISAAC_TEST_DB_TYPE"); dbType != "" {
+		database = OpenDB(dbType, os.Getenv("ISAAC_TEST_DB_SOURCE"))
+		database.DropTableIfExists(&USER_INFO_TB{}, &CHANNEL_USER_MAPPING_TB{}, &PERMISSION_USER_MAPPING_TB{},
+			&PERMISSION_INFO_TB{}, &ROLE_GROUP_TB{}, &CHANNEL_GROUP_MAPPING_TB{}, &PERMISSION_GROUP_MAPPING_TB{},
+			&CONFIGURATION_CHANNEL_TB{}, &CONFIGURATION_MASTER_TB{}, &CONFIGURATION_NODE_TB{},
+			&NODE_CHANNEL_MAPPING_TB{}, &CONFIGURATION_DATA_ALERT_TB{}, &CONFIGURATION_DATA_VISIBILITY_TB{},
+			migrator.Table)
+		if _, err := Migrate(false); err != nil {
+			panic(err)
+		}
+	} else {
+		database = InitDB("sqlite3", path)
+	}
 	userInfoTB := &USER_INFO_TB{
 		PK:                               "PKID_0000000000000001",
 		USER_ID:                          "test1",
@@ -862,6 +924,32 @@ func TestUpdateConfigurationChannelInfoTB(t *testing.T) {
 	assert.Equal(t, configuration_channel_ch1_tb.CHANNEL_NAME, "ch4")
 }
 
+func TestUpdateConfigurationChannelCrawlTB(t *testing.T) {
+	dbpath := ":memory:"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	channelTB := GetConfigurationChannelTable()
+	assert.Equal(t, channelTB[0].CRAWL_DISABLED, false)
+
+	err := UpdateConfigurationChannelCrawl(channelTB[0].CHANNEL_PK, true, 100, 5, 4)
+	assert.Equal(t, err, nil)
+
+	updatedTB := GetConfigurationChannelInfo(channelTB[0].CHANNEL_PK)
+	assert.Equal(t, updatedTB.CRAWL_DISABLED, true)
+	assert.Equal(t, updatedTB.CRAWL_START_HEIGHT, int64(100))
+	assert.Equal(t, updatedTB.CRAWL_INTERVAL, 5)
+	assert.Equal(t, updatedTB.CRAWL_CONCURRENCY, 4)
+
+	// The zero values are updated.
+	err = UpdateConfigurationChannelCrawl(channelTB[0].CHANNEL_PK, false, 0, 0, 0)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, GetConfigurationChannelInfo(channelTB[0].CHANNEL_PK).CRAWL_START_HEIGHT, int64(0))
+	assert.Equal(t, GetConfigurationChannelInfo(channelTB[0].CHANNEL_PK).CRAWL_DISABLED, false)
+
+	assert.NotEqual(t, UpdateConfigurationChannelCrawl("PKCH_none", false, 0, 0, 0), nil)
+}
+
 func TestUpdateUser(t *testing.T) {
 	dbpath := ":memory:"
 	Setup(dbpath)
@@ -0,0 +1,59 @@
+package db
+
+import (
+	. "motherbear/backend/configuration"
+	"motherbear/backend/migration"
+
+	"github.com/jinzhu/gorm"
+)
+
+// migrator has the migrations of ISAAC DB. Append the new migration with the next version.
+var migrator = migration.Migrator{
+	Table: "isaac_schema_migrations",
+	Migrations: []migration.Migration{
+		{
+			Version:     1,
+			Description: "Create the tables of ISAAC with the users, channels and nodes in the configuration.",
+			Up: func(*gorm.DB) error {
+				InitCreateTable()
+				return nil
+			},
+		},
+		{
+			Version:     2,
+			Description: "Add the crawl configuration to the channels, with the crawl configuration of channels in the file.",
+			Up: func(database *gorm.DB) error {
+				for _, field := range []string{"CRAWL_DISABLED", "CRAWL_START_HEIGHT", "CRAWL_INTERVAL",
+					"CRAWL_CONCURRENCY"} {
+					if err := migration.AddColumnIfNotExist(database, &CONFIGURATION_CHANNEL_TB{}, field); err != nil {
+						return err
+					}
+				}
+
+				// Keep the concurrency of channels configured in the file before.
+				for _, ch := range Conf().Channel {
+					if err := database.Model(&CONFIGURATION_CHANNEL_TB{}).Where("CHANNEL_NAME = ?", ch.Name).Updates(
+						map[string]interface{}{
+							"CRAWL_DISABLED":     ch.CrawlDisabled,
+							"CRAWL_START_HEIGHT": ch.StartHeight,
+							"CRAWL_INTERVAL":     ch.CrawlingInterval,
+							"CRAWL_CONCURRENCY":  ch.Concurrency,
+						}).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// Migrate applies the pending migrations to ISAAC DB, and returns them. Nothing is applied in dry run.
+func Migrate(dryRun bool) ([]migration.Migration, error) {
+	return migrator.Up(DBgorm(), dryRun)
+}
+
+// SchemaVersion returns the schema version of ISAAC DB.
+func SchemaVersion() (int, error) {
+	return migrator.Version(DBgorm())
+}
@@ -30,6 +30,35 @@ func UpdateConfigurationChannelInfo(pk string, channelName string) error {
 	return nil
 }
 
+// UpdateConfigurationChannelCrawl update the crawl configuration of channel.
+func UpdateConfigurationChannelCrawl(pk string, disabled bool, startHeight int64, interval int, concurrency int) error {
+	tx := NewTransaction()
+	defer tx.Close()
+
+	configurationChannelTB := &CONFIGURATION_CHANNEL_TB{}
+	if err := tx.db.Where("CHANNEL_PK = ?", pk).First(&configurationChannelTB).Error; err != nil {
+		Logger().Error("UpdateConfigurationChannelCrawl, CONFIGURATION_CHANNEL_TB Where failed!")
+		Logger().Errorf("%v+", err)
+		tx.Fail()
+		return err
+	}
+
+	// Update with map for the zero values.
+	if err := tx.db.Model(&configurationChannelTB).Updates(map[string]interface{}{
+		"CRAWL_DISABLED":     disabled,
+		"CRAWL_START_HEIGHT": startHeight,
+		"CRAWL_INTERVAL":     interval,
+		"CRAWL_CONCURRENCY":  concurrency,
+	}).Error; err != nil {
+		Logger().Error("UpdateConfigurationChannelCrawl, CONFIGURATION_CHANNEL_TB Update failed!")
+		Logger().Errorf("%v+", err)
+		tx.Fail()
+		return err
+	}
+
+	return nil
+}
+
 // UpdateChannelPermissionNode update channel permission nodes and update it other related tables.
 func UpdateChannelPermissionNode(channelPKID string, nodePKID ...string) error {
 	tx := NewTransaction()
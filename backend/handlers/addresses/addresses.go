@@ -0,0 +1,129 @@
+package addresses
+
+import (
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/handlers/txs"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AddressResponse struct {
+	Address           string           `json:"address" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	FirstSeen         string           `json:"firstSeen" example:"2006-01-02T15:04:05Z07:00"`
+	LastSeen          string           `json:"lastSeen" example:"2006-01-02T15:04:05Z07:00"`
+	SentCount         int64            `json:"sentCount" example:"10" `
+	ReceivedCount     int64            `json:"receivedCount" example:"5" `
+	CounterpartyCount int64            `json:"counterpartyCount" example:"3" `
+	Txs               []txs.TxResponse `json:"txs"`
+	Total             int              `json:"total" example:"15" format:"int32"`
+}
+
+// GetHandler godoc
+// @Tags Addresses
+// @Summary GET handler of addresses
+// @Description Get the activity summary and the transaction history of the address.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param address path string true "Address"
+// @Param limit query integer true "Identify the number of transactions returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return transactions from a result set."
+// @Param direction query string false "'direction' is 'sent' or 'received'. Both directions if it is empty."
+// @Success 200 {object} addresses.AddressResponse "Result for the address"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /addresses/{address} [get]
+func GetHandler(c *gin.Context) {
+	// Check the parameters.
+	offset, limit, err := utility.GetOffsetListFromRequest(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	address := c.Param(constants.RequestParamAddress)
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" || address == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	direction := c.Query(constants.RequestQueryDirection)
+	if direction != "" && direction != polarbear.AddressDirectionSent && direction != polarbear.AddressDirectionReceived {
+		internalError := isaacerror.SysErrInvalidParameter.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorInvalidParameter, internalError)
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryAddress, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Address requested, %s, limit:%d, offset:%d in %s", address, limit, offset, channelName)
+
+	// Query the summary and the Txs of address.
+	var summary polarbear.AddressSummary
+	if err := polarbear.QueryAddressSummaryInChannel(channelName, address, &summary); err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryAddress, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var txsOfAddress []polarbear.Tx
+	count, err := polarbear.QueryTxsOfAddressInChannel(channelName, address, direction, limit, offset, &txsOfAddress)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryAddress, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp AddressResponse
+	convertPbAddressSummaryToAddressResponse(&summary, &resp)
+	resp.Txs = make([]txs.TxResponse, len(txsOfAddress))
+	resp.Total = int(count)
+
+	for i := 0; i < len(txsOfAddress); i++ {
+		txs.ConvertPbTxToTxResponse(&txsOfAddress[i], &resp.Txs[i])
+	}
+
+	// Put total information.
+	bytes := "bytes 0-" + strconv.Itoa(resp.Total) + "/" + strconv.Itoa(resp.Total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(resp.Total))
+
+	// Return body.
+	c.JSON(http.StatusOK, resp)
+}
+
+func convertPbAddressSummaryToAddressResponse(summary *polarbear.AddressSummary, out *AddressResponse) {
+	out.Address = summary.Address
+	out.SentCount = summary.SentCount
+	out.ReceivedCount = summary.ReceivedCount
+	out.CounterpartyCount = summary.CounterpartyCount
+
+	// The address which has no Tx has never been seen.
+	if !summary.FirstSeen.IsZero() {
+		out.FirstSeen = summary.FirstSeen.Format(time.RFC3339)
+	}
+	if !summary.LastSeen.IsZero() {
+		out.LastSeen = summary.LastSeen.Format(time.RFC3339)
+	}
+}
@@ -0,0 +1,155 @@
+package addresses
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const addressA = "hx5d91dee6102ead2aca60256cf33ebf9aab102c82"
+const addressB = "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b"
+const addressC = "cx54d95fee187faaea03cee908f50623c8381179d0"
+
+// generateTestTxsInDB generates Txs from A to B in odd blocks, from C to A in even blocks and from A to C in the last block.
+func generateTestTxsInDB(channelName string, height int) error {
+	baseTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for h := 1; h <= height+1; h++ {
+		from, to := addressA, addressB
+		if h == height+1 {
+			from, to = addressA, addressC
+		} else if h%2 == 0 {
+			from, to = addressC, addressA
+		}
+
+		block := polarbear.Block{
+			Channel:     channelName,
+			BlockHeight: int64(h),
+			BlockHash:   "0x" + strconv.FormatInt(int64(h*1000), 16),
+			Timestamp:   baseTime.Add(time.Duration(h) * time.Second),
+			Txs: []polarbear.Tx{
+				{
+					TxHash:      "0x" + strconv.FormatInt(int64(h), 16),
+					Channel:     channelName,
+					Status:      "Success",
+					BlockHeight: int64(h),
+					From:        from,
+					To:          to,
+					Timestamp:   baseTime.Add(time.Duration(h) * time.Second),
+				},
+			},
+		}
+		if err := polarbear.Database().Save(&block).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestTxsInDB("channel1", 10)
+}
+
+func requestAddress(router *gin.Engine, address string, query map[string]string) (*httptest.ResponseRecorder, AddressResponse) {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.AddressAPIBaseURL+"/"+address,
+		nil)
+	q := request.URL.Query()
+	for k, v := range query {
+		q.Add(k, v)
+	}
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var resp AddressResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	return w, resp
+}
+
+func TestGetHandler(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.AddressGETAPIURL, GetHandler)
+
+	w, resp := requestAddress(router, addressA, map[string]string{"limit": "4", "offset": "0", "channel": "channel1"})
+
+	// Test the response.
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Address, addressA)
+	assert.Equal(t, resp.SentCount, int64(6))
+	assert.Equal(t, resp.ReceivedCount, int64(5))
+	assert.Equal(t, resp.CounterpartyCount, int64(2))
+	assert.Equal(t, resp.FirstSeen, "2019-01-01T00:00:01Z")
+	assert.Equal(t, resp.LastSeen, "2019-01-01T00:00:11Z")
+	assert.Equal(t, resp.Total, 11)
+	assert.Equal(t, len(resp.Txs), 4)
+	assert.Equal(t, strconv.Itoa(resp.Total), w.Header().Get("X-Total-Count"))
+	assert.Equal(t, resp.Txs[0].BlockHeight, int64(11))
+	assert.Equal(t, resp.Txs[1].BlockHeight, int64(10))
+
+	// Only received Txs.
+	w, resp = requestAddress(router, addressA,
+		map[string]string{"limit": "10", "offset": "0", "channel": "channel1", "direction": "received"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Total, 5)
+	for _, tx := range resp.Txs {
+		assert.Equal(t, tx.To, addressA)
+	}
+
+	// Only sent Txs, with offset.
+	w, resp = requestAddress(router, addressA,
+		map[string]string{"limit": "10", "offset": "4", "channel": "channel1", "direction": "sent"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Total, 6)
+	assert.Equal(t, len(resp.Txs), 2)
+	assert.Equal(t, resp.Txs[1].BlockHeight, int64(1))
+
+	// The counterparty of B is only A.
+	w, resp = requestAddress(router, addressB, map[string]string{"limit": "10", "offset": "0", "channel": "channel1"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.SentCount, int64(0))
+	assert.Equal(t, resp.ReceivedCount, int64(5))
+	assert.Equal(t, resp.CounterpartyCount, int64(1))
+}
+
+// Test the address which has no Tx.
+func TestGetHandlerUnknownAddress(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.AddressGETAPIURL, GetHandler)
+
+	w, resp := requestAddress(router, "hx0000000000000000000000000000000000000000",
+		map[string]string{"limit": "10", "offset": "0", "channel": "channel1"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Total, 0)
+	assert.Equal(t, resp.FirstSeen, "")
+	assert.Equal(t, resp.CounterpartyCount, int64(0))
+}
+
+// Test to return error with the invalid direction.
+func TestGetHandlerInvalidParameter(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.AddressGETAPIURL, GetHandler)
+
+	w, _ := requestAddress(router, addressA,
+		map[string]string{"limit": "10", "offset": "0", "channel": "channel1", "direction": "both"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
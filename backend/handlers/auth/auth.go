@@ -52,6 +52,14 @@ var userCommonAuthorizedAPIList = map[string][]string{
 	constants.NodesAPIBaseURL:    {constants.HTTPMethodGET},
 	constants.BlockAPIBaseURL:    {constants.HTTPMethodGET},
 	constants.TxAPIBaseURL:       {constants.HTTPMethodGET},
+	constants.EventAPIBaseURL:    {constants.HTTPMethodGET},
+	constants.AddressAPIBaseURL:  {constants.HTTPMethodGET},
+	constants.ContractAPIBaseURL: {constants.HTTPMethodGET},
+	constants.StatsAPIBaseURL:    {constants.HTTPMethodGET},
+	constants.TokenAPIBaseURL:    {constants.HTTPMethodGET},
+	constants.RetentionAPIBaseURL: {constants.HTTPMethodGET},
+	constants.ExportAPIBaseURL:    {constants.HTTPMethodGET},
+	constants.SearchAPIBaseURL:    {constants.HTTPMethodGET},
 	constants.SymptomAPIBaseURL:  {constants.HTTPMethodGET},
 	constants.PrometheusGETAPIURL:  {constants.HTTPMethodGET},
 }
@@ -61,6 +69,14 @@ var thirdPartyAllowableAPIList = []string{
 	constants.NodesAPIBaseURL,
 	constants.BlockAPIBaseURL,
 	constants.TxAPIBaseURL,
+	constants.EventAPIBaseURL,
+	constants.AddressAPIBaseURL,
+	constants.ContractAPIBaseURL,
+	constants.StatsAPIBaseURL,
+	constants.TokenAPIBaseURL,
+	constants.RetentionAPIBaseURL,
+	constants.ExportAPIBaseURL,
+	constants.SearchAPIBaseURL,
 }
 
 var userTypeList = map[string]map[string][]string{
@@ -72,7 +88,7 @@ var APIListWithoutAuthorization = []string{
 	constants.APIVersionURL + constants.AuthLoginAPIURL,
 	constants.APIVersionURL + constants.ResourcesAPIBaseURL + "/" + constants.ResourcesIDLoginLogoImage}
 
-var channelPermissionAPIList = []string{constants.ChannelsAPIBaseURL, constants.NodesAPIBaseURL, constants.BlockAPIBaseURL, constants.TxAPIBaseURL, constants.SymptomAPIBaseURL}
+var channelPermissionAPIList = []string{constants.ChannelsAPIBaseURL, constants.NodesAPIBaseURL, constants.BlockAPIBaseURL, constants.TxAPIBaseURL, constants.EventAPIBaseURL, constants.AddressAPIBaseURL, constants.ContractAPIBaseURL, constants.StatsAPIBaseURL, constants.TokenAPIBaseURL, constants.RetentionAPIBaseURL, constants.SymptomAPIBaseURL, constants.ExportAPIBaseURL, constants.SearchAPIBaseURL}
 
 var jwtSecret []byte
 var once sync.Once
@@ -502,8 +518,10 @@ func checkAPIPermission(c *gin.Context, payload JWTpayload) error {
 			if channelID == "" {
 				return isaacerror.SysErrUsedUnauthorizedAPI
 			}
-		case constants.BlockAPIBaseURL, constants.TxAPIBaseURL: // blocks API or txs API.
-			// 1. Blocks, txs Get-List and Get API can be used only when select channel.
+		case constants.BlockAPIBaseURL, constants.TxAPIBaseURL, constants.EventAPIBaseURL,
+			constants.AddressAPIBaseURL, constants.ContractAPIBaseURL, constants.StatsAPIBaseURL,
+			constants.TokenAPIBaseURL, constants.RetentionAPIBaseURL: // blocks, txs, events, addresses, contracts, stats, tokens or retention API.
+			// 1. Blocks, txs, events, addresses, contracts, stats, tokens Get-List and Get API can be used only when select channel.
 			channelID := c.Query(constants.RequestParamChannel)
 			if channelID == "" {
 				return isaacerror.SysErrUsedUnauthorizedAPI
@@ -513,6 +531,18 @@ func checkAPIPermission(c *gin.Context, payload JWTpayload) error {
 			if !utility.IsExistValueInList(constants.DBUserPermissionMonitoringLog, payload.PERMISSION) {
 				return isaacerror.SysErrUsedUnauthorizedAPI
 			}
+		case constants.ExportAPIBaseURL: // export API.
+			// 1. Export API can be used only when select channel.
+			channelID := c.Query(constants.RequestParamChannel)
+			if channelID == "" {
+				return isaacerror.SysErrUsedUnauthorizedAPI
+			}
+
+			// 2. Symptoms can be exported only with the permission of peer symptom API.
+			if strings.HasSuffix(c.Request.URL.Path, constants.ExportSymptomsGETAPIURL) &&
+				!utility.IsExistValueInList(constants.DBUserPermissionMonitoringLog, payload.PERMISSION) {
+				return isaacerror.SysErrUsedUnauthorizedAPI
+			}
 		}
 	}
 
@@ -556,7 +586,9 @@ func checkChannelPermission(c *gin.Context, payload JWTpayload) error {
 				return isaacerror.SysErrFailToGetThatUnauthorizedChannel
 			}
 		}
-	case constants.BlockAPIBaseURL, constants.TxAPIBaseURL: // blocks API, txs API.
+	case constants.BlockAPIBaseURL, constants.TxAPIBaseURL, constants.EventAPIBaseURL,
+		constants.AddressAPIBaseURL, constants.ContractAPIBaseURL, constants.StatsAPIBaseURL,
+		constants.TokenAPIBaseURL, constants.RetentionAPIBaseURL: // blocks, txs, events, addresses, contracts, stats, tokens, retention API.
 		// Get channel ID in query
 		channelID := c.Query(constants.RequestParamChannel)
 		if channelID != "" {
@@ -564,8 +596,16 @@ func checkChannelPermission(c *gin.Context, payload JWTpayload) error {
 				return isaacerror.SysErrFailToGetThatUnauthorizedChannel
 			}
 		}
-	case constants.SymptomAPIBaseURL: // symptom API.
+	case constants.SymptomAPIBaseURL, constants.SearchAPIBaseURL: // symptom, search API.
 		c.Set(constants.ContextKeyPermissionChannelList, permissionChannelList)
+	case constants.ExportAPIBaseURL: // export API.
+		// Symptoms of every channel with permission are exported without channel.
+		channelID := c.Query(constants.RequestParamChannel)
+		if channelID == "" {
+			c.Set(constants.ContextKeyPermissionChannelList, permissionChannelList)
+		} else if !utility.IsExistValueInList(channelID, permissionChannelList) {
+			return isaacerror.SysErrFailToGetThatUnauthorizedChannel
+		}
 	}
 
 	return nil
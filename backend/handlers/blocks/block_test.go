@@ -2,10 +2,13 @@ package blocks
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"motherbear/backend/constants"
 	"motherbear/backend/polarbear"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -122,3 +125,54 @@ func TestGetListHandler(t *testing.T) {
 	assert.Equal(t, blockHash, resultBody2.BlockHash)
 	assert.Equal(t, resultList.Data[2].ConfirmedTx[0].Data, resultBody2.ConfirmedTx[0].Data)
 }
+
+func TestGetListHandlerInSeparateStore(t *testing.T) {
+	// Blocks are stored in the other DB than the one of polarbear.
+	dir, err := ioutil.TempDir("", "block_store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	storeDB := polarbear.InitDB(constants.DBTypeSqlite3, filepath.Join(dir, "store.db"))
+	defer storeDB.Close()
+
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+	polarbear.SetStore(polarbear.NewGormStore(storeDB))
+	_ = generateTestBlockdataInDB("channel1", 30)
+
+	router := gin.Default()
+	router.GET(constants.BlockGETListAPIURL, GetHandlerList)
+	router.GET(constants.BlockGETAPIURL, GetHandler)
+
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, constants.BlockGETListAPIURL, nil)
+	q := request.URL.Query()
+	q.Add("limit", "10")
+	q.Add("offset", "5")
+	q.Add("channel", "channel1")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var resultList BlockResponseList
+	_ = json.Unmarshal(w.Body.Bytes(), &resultList)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 30)
+	assert.Equal(t, len(resultList.Data), 10)
+	assert.Equal(t, resultList.Data[0].BlockHeight, int64(25))
+	assert.Equal(t, len(resultList.Data[0].ConfirmedTx), 3)
+
+	// Nothing in the DB.
+	var count int
+	polarbear.Database().Model(&polarbear.Block{}).Count(&count)
+	assert.Equal(t, count, 0)
+
+	// Query by block height.
+	w = httptest.NewRecorder()
+	request, _ = http.NewRequest(constants.HTTPMethodGET, constants.BlockAPIBaseURL+"/7?channel=channel1", nil)
+	router.ServeHTTP(w, request)
+
+	var resultBody BlockResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resultBody)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, resultBody.BlockHeight, int64(7))
+}
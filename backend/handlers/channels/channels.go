@@ -16,8 +16,9 @@ import (
 
 type Request struct {
 	Data struct {
-		Name  string   `json:"name" example:"channel1"`
-		Nodes []string `json:"nodes" example:"node1,node2"`
+		Name  string     `json:"name" example:"channel1"`
+		Nodes []string   `json:"nodes" example:"node1,node2"`
+		Crawl *CrawlData `json:"crawl,omitempty"` // Keep the crawl configuration if not set.
 	} `json:"data"`
 }
 type Response struct {
@@ -39,6 +40,16 @@ type ChannelData struct {
 	ResponseTimeInSec *float64   `json:"responseTimeInSec,omitempty" example:"0.001" format:"float64"`
 	Total             int        `json:"total" example:"1" format:"int32"`
 	Nodes             []NodeData `json:"nodes"`
+	Crawl             CrawlData  `json:"crawl"`
+}
+
+// CrawlData is the crawl configuration of channel. The zero values are the defaults.
+// Enabled is kept as it is stored if not set in request.
+type CrawlData struct {
+	Enabled     *bool `json:"enabled,omitempty" example:"true"`
+	StartHeight int64 `json:"startHeight" example:"0" format:"int64"`
+	Interval    int   `json:"interval" example:"0" format:"int32"`
+	Concurrency int   `json:"concurrency" example:"0" format:"int32"`
 }
 
 type NodeData struct {
@@ -181,7 +192,7 @@ func PostHandler(c *gin.Context) {
 	}
 
 	// Check parameter.
-	if !utility.IsAlphanumericString(data.Data.Name) {
+	if !utility.IsAlphanumericString(data.Data.Name) || !isValidCrawlData(data.Data.Crawl) {
 		// Invalid Parameter.
 		internalError := isaacerror.SysErrInvalidParameter.Error()
 		logger.Error(internalError)
@@ -221,6 +232,18 @@ func PostHandler(c *gin.Context) {
 		return
 	}
 
+	// Set crawl configuration of channel.
+	if data.Data.Crawl != nil {
+		if err := updateCrawlData(id, data.Data.Crawl); err != nil {
+			// Failed insert channel.
+			internalError := isaacerror.SysErrFailToInsertChannel.Error()
+			logger.Error(internalError)
+			message := isaacerror.GetAPIError(isaacerror.ErrorFailToInsertChannel, internalError)
+			c.JSON(http.StatusInternalServerError, message)
+			return
+		}
+	}
+
 	// Update node configuration.
 	changeChannelConfig()
 
@@ -234,6 +257,7 @@ func PostHandler(c *gin.Context) {
 	var response Response
 	response.Data.ID = id
 	response.Data.Name = data.Data.Name
+	response.Data.Crawl = getCrawlData(db.GetConfigurationChannelInfo(id))
 	response.Data.Nodes = make([]NodeData, len(mappingTB))
 	for i, value := range mappingTB {
 		response.Data.Nodes[i].ID = value.NODE_PK
@@ -284,7 +308,7 @@ func PutHandler(c *gin.Context) {
 	}
 
 	// Check parameter.
-	if !utility.IsAlphanumericString(data.Data.Name) {
+	if !utility.IsAlphanumericString(data.Data.Name) || !isValidCrawlData(data.Data.Crawl) {
 		// Invalid Parameter.
 		internalError := isaacerror.SysErrInvalidParameter.Error()
 		logger.Error(internalError)
@@ -341,6 +365,9 @@ func PutHandler(c *gin.Context) {
 	} else {
 		err = db.UpdateConfigurationChannelInfoWithMapping(id, data.Data.Name, data.Data.Nodes...)
 	}
+	if err == nil && data.Data.Crawl != nil {
+		err = updateCrawlData(id, data.Data.Crawl)
+	}
 	if err != nil {
 		// Failed update node.
 		internalError := isaacerror.SysErrFailToUpdateChannel.Error()
@@ -363,6 +390,7 @@ func PutHandler(c *gin.Context) {
 	var response Response
 	response.Data.ID = id
 	response.Data.Name = data.Data.Name
+	response.Data.Crawl = getCrawlData(db.GetConfigurationChannelInfo(id))
 	response.Data.Nodes = make([]NodeData, len(mappingTB))
 	for i, value := range mappingTB {
 		response.Data.Nodes[i].ID = value.NODE_PK
@@ -426,6 +454,7 @@ func DeleteHandler(c *gin.Context) {
 	var response Response
 	response.Data.ID = id
 	response.Data.Name = channelTB.CHANNEL_NAME
+	response.Data.Crawl = getCrawlData(channelTB)
 	response.Data.Nodes = make([]NodeData, len(mappingTB))
 	for i, value := range mappingTB {
 		response.Data.Nodes[i].ID = value.NODE_PK
@@ -455,6 +484,10 @@ func changeChannelConfig() {
 	for i, value := range channelTB {
 		conf.Channel[i].Name = value.CHANNEL_NAME
 		conf.Channel[i].Nodes = make([]string, 0)
+		conf.Channel[i].CrawlDisabled = value.CRAWL_DISABLED
+		conf.Channel[i].StartHeight = value.CRAWL_START_HEIGHT
+		conf.Channel[i].CrawlingInterval = value.CRAWL_INTERVAL
+		conf.Channel[i].Concurrency = value.CRAWL_CONCURRENCY
 
 		for _, mappingValue := range mappingTB {
 			if value.CHANNEL_PK == mappingValue.CHANNEL_PK {
@@ -467,6 +500,31 @@ func changeChannelConfig() {
 	configuration.ChangeConfigFile(configuration.GetFilePath(), conf)
 }
 
+// isValidCrawlData checks the crawl configuration in request. Nil is valid to keep the configuration.
+func isValidCrawlData(crawl *CrawlData) bool {
+	return crawl == nil || (crawl.StartHeight >= 0 && crawl.Interval >= 0 && crawl.Concurrency >= 0)
+}
+
+func updateCrawlData(channelPK string, crawl *CrawlData) error {
+	disabled := db.GetConfigurationChannelInfo(channelPK).CRAWL_DISABLED
+	if crawl.Enabled != nil {
+		disabled = !*crawl.Enabled
+	}
+
+	return db.UpdateConfigurationChannelCrawl(channelPK, disabled, crawl.StartHeight, crawl.Interval,
+		crawl.Concurrency)
+}
+
+func getCrawlData(channelTB *db.CONFIGURATION_CHANNEL_TB) CrawlData {
+	enabled := !channelTB.CRAWL_DISABLED
+	return CrawlData{
+		Enabled:     &enabled,
+		StartHeight: channelTB.CRAWL_START_HEIGHT,
+		Interval:    channelTB.CRAWL_INTERVAL,
+		Concurrency: channelTB.CRAWL_CONCURRENCY,
+	}
+}
+
 func isExistNodesInDB(nodeList []string) bool {
 	nodeTB := db.GetConfigurationNodeTable()
 	nodeListInDB := make([]string, len(nodeTB))
@@ -505,6 +563,7 @@ func getResponseDataByChannelPK(channelPK string) (*Response, error) {
 	responseData.Data.ID = channelPK
 	responseData.Data.Name = channelTB.CHANNEL_NAME
 	responseData.Data.GoloopChannelID = channelTB.CHANNEL_ID
+	responseData.Data.Crawl = getCrawlData(channelTB)
 	responseData.Data.Total = len(mappingTB)
 	responseData.Data.Status = &loopchainChannelData.Status
 	responseData.Data.Nodes = make([]NodeData, len(mappingTB))
@@ -371,6 +371,76 @@ func TestPutHandler2(t *testing.T) {
 	assert.Equal(t, isaacerror.ErrorDuplicatedChannelName, err.Errors[0].UserMessage)
 }
 
+// Test to update the crawl configuration of channel.
+func TestPutHandler3(t *testing.T) {
+	Setup()
+	defer Teardown()
+
+	whereTB := db.GetConfigurationChannelInfoByName("channel1")
+	nodes := db.GetChannelPermissionNodes(whereTB.CHANNEL_PK)
+
+	var requestData Request
+	requestData.Data.Name = whereTB.CHANNEL_NAME
+	requestData.Data.Nodes = make([]string, len(nodes))
+	for i, value := range nodes {
+		requestData.Data.Nodes[i] = value.NODE_PK
+	}
+	enabled, disabled := true, false
+	requestData.Data.Crawl = &CrawlData{Enabled: &disabled, StartHeight: 1000, Interval: 5, Concurrency: 4}
+
+	router := gin.Default()
+	router.PUT(constants.ChannelsPutAPIURL, PutHandler)
+	put := func(requestData Request, out interface{}) int {
+		requestJSON, _ := json.Marshal(requestData)
+		w := httptest.NewRecorder()
+		request, _ := http.NewRequest(constants.HTTPMethodPUT, constants.ChannelsAPIBaseURL+"/"+whereTB.CHANNEL_PK,
+			bytes.NewBuffer(requestJSON))
+		router.ServeHTTP(w, request)
+		_ = json.Unmarshal(w.Body.Bytes(), out)
+		return w.Code
+	}
+
+	var result Response
+	assert.Equal(t, put(requestData, &result), 200)
+	assert.Equal(t, result.Data.Crawl, *requestData.Data.Crawl)
+
+	// Check database and configuration.
+	channelTB := db.GetConfigurationChannelInfo(whereTB.CHANNEL_PK)
+	assert.Equal(t, channelTB.CRAWL_DISABLED, true)
+	assert.Equal(t, channelTB.CRAWL_START_HEIGHT, int64(1000))
+
+	conf := configuration.InitConfigData(confFilePath)
+	assert.Equal(t, conf.Channel[0].CrawlDisabled, true)
+	assert.Equal(t, conf.Channel[0].StartHeight, int64(1000))
+	assert.Equal(t, conf.Channel[0].CrawlingInterval, 5)
+	assert.Equal(t, conf.Channel[0].Concurrency, 4)
+
+	// The crawl configuration is kept if not in request.
+	crawl := requestData.Data.Crawl
+	requestData.Data.Crawl = nil
+	assert.Equal(t, put(requestData, &result), 200)
+	assert.Equal(t, result.Data.Crawl, *crawl)
+
+	// Crawling stays disabled if enabled is not in the crawl configuration of request.
+	requestData.Data.Crawl = &CrawlData{Interval: 10}
+	assert.Equal(t, put(requestData, &result), 200)
+	assert.Equal(t, *result.Data.Crawl.Enabled, false)
+	assert.Equal(t, result.Data.Crawl.StartHeight, int64(0))
+	assert.Equal(t, result.Data.Crawl.Interval, 10)
+	channelTB = db.GetConfigurationChannelInfo(whereTB.CHANNEL_PK)
+	assert.Equal(t, channelTB.CRAWL_DISABLED, true)
+
+	requestData.Data.Crawl = &CrawlData{Enabled: &enabled}
+	assert.Equal(t, put(requestData, &result), 200)
+	assert.Equal(t, *result.Data.Crawl.Enabled, true)
+
+	// Invalid crawl configuration.
+	var err isaacerror.APIError
+	requestData.Data.Crawl = &CrawlData{Enabled: &enabled, StartHeight: -1}
+	assert.Equal(t, put(requestData, &err), 400)
+	assert.Equal(t, isaacerror.ErrorInvalidParameter, err.Errors[0].UserMessage)
+}
+
 func TestDeleteHandler(t *testing.T) {
 	Setup()
 	defer Teardown()
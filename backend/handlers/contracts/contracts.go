@@ -0,0 +1,212 @@
+package contracts
+
+import (
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ContractResponseList struct {
+	Data  []ContractResponse `json:"data"`
+	Total int                `json:"total" example:"1" format:"int32"`
+}
+
+type ContractResponse struct {
+	Address          string                    `json:"address" example:"cx54d95fee187faaea03cee908f50623c8381179d0" `
+	Deployer         string                    `json:"deployer" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	DeployTxHash     string                    `json:"deployTxHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+	DeployHeight     int64                     `json:"deployHeight" example:"124" `
+	DeployTimestamp  string                    `json:"deployTimeStamp" example:"2006-01-02T15:04:05Z07:00"`
+	ContentType      string                    `json:"contentType" example:"application/zip" `
+	LastUpdateTxHash string                    `json:"lastUpdateTxHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+	LastUpdateHeight int64                     `json:"lastUpdateHeight" example:"125" `
+	CallCount        int64                     `json:"callCount" example:"10" `
+	History          []ContractHistoryResponse `json:"history,omitempty"`
+}
+
+type ContractHistoryResponse struct {
+	TxHash      string `json:"txHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+	BlockHeight int64  `json:"blockHeight" example:"124" `
+	Timestamp   string `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+	Action      string `json:"action" example:"deploy" `
+	From        string `json:"from" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	ContentType string `json:"contentType" example:"application/zip" `
+	RefTxHash   string `json:"refTxHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+}
+
+// GetHandlerList godoc
+// @Tags Contracts
+// @Summary GET handler of contracts
+// @Description Get many contracts deployed in the channel.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Success 200 {object} contracts.ContractResponseList "Result for many contract resources"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /contracts [get]
+func GetHandlerList(c *gin.Context) {
+	// Check the parameters.
+	offset, limit, err := utility.GetOffsetListFromRequest(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryContractList, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Contracts requested, limit:%d, offset:%d in %s", limit, offset, channelName)
+
+	// Query contract list with the call volume.
+	var contractList []polarbear.Contract
+	count, err := polarbear.QueryContractsInChannel(channelName, limit, offset, &contractList)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryContractList, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	addresses := make([]string, 0, len(contractList))
+	for _, contract := range contractList {
+		addresses = append(addresses, contract.Address)
+	}
+	callCounts, err := polarbear.QueryCallCountsOfContracts(channelName, addresses)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryContractList, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp ContractResponseList
+	resp.Data = make([]ContractResponse, len(contractList))
+	resp.Total = int(count)
+
+	for i := 0; i < len(contractList); i++ {
+		convertPbContractToContractResponse(&contractList[i], &resp.Data[i])
+		resp.Data[i].CallCount = callCounts[contractList[i].Address]
+	}
+
+	// Put total information.
+	bytes := "bytes 0-" + strconv.Itoa(resp.Total) + "/" + strconv.Itoa(resp.Total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(resp.Total))
+
+	// Return body.
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHandler godoc
+// @Tags Contracts
+// @Summary GET handler of contracts
+// @Description Get the one contract resource with its deploy, update and audit history by address.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param address path string true "Contract address"
+// @Success 200 {object} contracts.ContractResponse "Result for get the one contract resource"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /contracts/{address} [get]
+func GetHandler(c *gin.Context) {
+
+	// Check the parameters.
+	address := c.Param(constants.RequestParamAddress)
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	var err error
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryContract, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Contract requested, %s in %s", address, channelName)
+
+	var contract polarbear.Contract
+	var history []polarbear.ContractHistory
+	if err := polarbear.QueryContractInChannelByAddress(channelName, address, &contract, &history); err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryContract, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	callCounts, err := polarbear.QueryCallCountsOfContracts(channelName, []string{address})
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryContract, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp ContractResponse
+	convertPbContractToContractResponse(&contract, &resp)
+	resp.CallCount = callCounts[address]
+	resp.History = make([]ContractHistoryResponse, len(history))
+	for i := 0; i < len(history); i++ {
+		convertPbContractHistoryToContractHistoryResponse(&history[i], &resp.History[i])
+	}
+
+	// Return body.
+	c.JSON(http.StatusOK, resp)
+}
+
+func convertPbContractToContractResponse(contract *polarbear.Contract, out *ContractResponse) {
+	out.Address = contract.Address
+	out.Deployer = contract.Deployer
+	out.DeployTxHash = contract.DeployTxHash
+	out.DeployHeight = contract.DeployHeight
+	out.ContentType = contract.ContentType
+	out.LastUpdateTxHash = contract.LastUpdateTxHash
+	out.LastUpdateHeight = contract.LastUpdateHeight
+
+	// The contract deployed before the crawled blocks has no deploy information.
+	if !contract.DeployTimestamp.IsZero() {
+		out.DeployTimestamp = contract.DeployTimestamp.Format(time.RFC3339)
+	}
+}
+
+func convertPbContractHistoryToContractHistoryResponse(history *polarbear.ContractHistory, out *ContractHistoryResponse) {
+	out.TxHash = history.TxHash
+	out.BlockHeight = history.BlockHeight
+	out.Timestamp = history.Timestamp.Format(time.RFC3339)
+	out.Action = history.Action
+	out.From = history.From
+	out.ContentType = history.ContentType
+	out.RefTxHash = history.RefTxHash
+}
@@ -0,0 +1,135 @@
+package contracts
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const contractAddress = "cx54d95fee187faaea03cee908f50623c8381179d0"
+
+func generateTestContractsInDB(channelName string, count int) error {
+	for h := 1; h <= count; h++ {
+		address := contractAddress
+		if h > 1 {
+			address = "cx" + strconv.FormatInt(int64(h), 16)
+		}
+		contract := polarbear.Contract{
+			Channel:         channelName,
+			Address:         address,
+			Deployer:        "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+			DeployTxHash:    "0x" + strconv.FormatInt(int64(h), 16),
+			DeployHeight:    int64(h),
+			DeployTimestamp: time.Now(),
+			ContentType:     "application/zip",
+		}
+		if err := polarbear.Database().Save(&contract).Error; err != nil {
+			return err
+		}
+		history := polarbear.ContractHistory{
+			Channel:     channelName,
+			Address:     address,
+			TxHash:      contract.DeployTxHash,
+			BlockHeight: int64(h),
+			Timestamp:   time.Now(),
+			Action:      polarbear.ContractActionDeploy,
+			From:        contract.Deployer,
+			ContentType: contract.ContentType,
+		}
+		if err := polarbear.Database().Save(&history).Error; err != nil {
+			return err
+		}
+	}
+
+	// Calls to the first contract.
+	for i := 0; i < 3; i++ {
+		tx := polarbear.Tx{
+			TxHash:      "0xc" + strconv.Itoa(i),
+			Channel:     channelName,
+			Status:      "Success",
+			BlockHeight: int64(count + 1),
+			To:          contractAddress,
+			DataType:    "call",
+		}
+		if err := polarbear.Database().Save(&tx).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestContractsInDB("channel1", 15)
+}
+
+func TestGetHandlerList(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.ContractGETListAPIURL, GetHandlerList)
+	w := httptest.NewRecorder()
+
+	// Request the URL.
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.ContractGETListAPIURL,
+		nil)
+	q := request.URL.Query()
+	q.Add("limit", "10")
+	q.Add("offset", "5")
+	q.Add("channel", "channel1")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var resultList ContractResponseList
+	_ = json.Unmarshal(w.Body.Bytes(), &resultList)
+
+	// Test the response. The first contract is the last one by the latest deployment.
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 15)
+	assert.Equal(t, len(resultList.Data), 10)
+	assert.Equal(t, strconv.Itoa(resultList.Total), w.Header().Get("X-Total-Count"))
+	assert.Equal(t, resultList.Data[0].DeployHeight, int64(10))
+	assert.Equal(t, resultList.Data[9].Address, contractAddress)
+	assert.Equal(t, resultList.Data[9].CallCount, int64(3))
+	assert.Equal(t, resultList.Data[0].CallCount, int64(0))
+}
+
+func TestGetHandler(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.ContractGETAPIURL, GetHandler)
+	w := httptest.NewRecorder()
+
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.ContractAPIBaseURL+"/"+contractAddress,
+		nil)
+	q := request.URL.Query()
+	q.Add("channel", "channel1")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var result ContractResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &result)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, result.Address, contractAddress)
+	assert.Equal(t, result.DeployHeight, int64(1))
+	assert.Equal(t, result.CallCount, int64(3))
+	assert.Equal(t, len(result.History), 1)
+	assert.Equal(t, result.History[0].Action, polarbear.ContractActionDeploy)
+}
@@ -0,0 +1,513 @@
+package crawler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CrawlerStatusResponseList struct {
+	Data []CrawlerStatusResponse `json:"data"`
+}
+
+type CrawlerStatusResponse struct {
+	Channel         string  `json:"channel" example:"channel1"`
+	DBHeight        int64   `json:"dbHeight" example:"1020"`
+	ChainHeight     int64   `json:"chainHeight" example:"1024"`
+	LagInBlocks     int64   `json:"lagInBlocks" example:"4"`
+	LagInSeconds    int64   `json:"lagInSeconds" example:"8"`
+	BlocksPerSecond float64 `json:"blocksPerSecond" example:"0.5"`
+	Crawling        bool    `json:"crawling" example:"true"`
+	Streaming       bool    `json:"streaming" example:"false"`
+	Paused          bool    `json:"paused" example:"false"`
+	PendingTxs      int64   `json:"pendingTxs" example:"0"`
+	LastSuccess     string  `json:"lastSuccess" example:"2006-01-02T15:04:05Z07:00"`
+	LastError       string  `json:"lastError" example:"Fail to get the last block height from nodes. "`
+	LastErrorTime   string  `json:"lastErrorTime" example:"2006-01-02T15:04:05Z07:00"`
+	CountOfError    int64   `json:"countOfError" example:"1"`
+	NodeIP          string  `json:"nodeIP" example:"http://127.0.0.1:9000"`
+}
+
+type CrawlJobResponseList struct {
+	Data  []CrawlJobResponse `json:"data"`
+	Total int                `json:"total" example:"1" format:"int32"`
+}
+
+type CrawlJobResponse struct {
+	ID            uint   `json:"id" example:"1"`
+	Channel       string `json:"channel" example:"channel1"`
+	Kind          string `json:"kind" example:"Recrawl"`
+	BeginHeight   int64  `json:"beginHeight" example:"1000"`
+	EndHeight     int64  `json:"endHeight" example:"1999"`
+	Status        string `json:"status" example:"Running"`
+	CountOfBlocks int64  `json:"countOfBlocks" example:"1000"`
+	CountOfDone   int64  `json:"countOfDone" example:"300"`
+	CountOfFailed int64  `json:"countOfFailed" example:"0"`
+	LastError     string `json:"lastError" example:""`
+	StartedAt     string `json:"startedAt" example:"2006-01-02T15:04:05Z07:00"`
+	FinishedAt    string `json:"finishedAt" example:"2006-01-02T15:04:05Z07:00"`
+}
+
+// GetStatusHandler godoc
+// @Tags Crawler
+// @Summary GET handler of crawler status
+// @Description Get the status of crawling in the channel, or in all channels if no channel is queried.
+// @Description chainHeight is -1 if the crawler has not seen the chain yet. lastSuccess, lastError and lastErrorTime are empty if there is none since the server started.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  false "Channel to query. Can be channel name or PK of channel."
+// @Success 200 {object} crawler.CrawlerStatusResponseList "Result for crawler status"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/status [get]
+func GetStatusHandler(c *gin.Context) {
+	var channelNames []string
+	if channelName := c.Query(constants.RequestQueryChannel); channelName != "" {
+		// If user queried channel as PK, then get the real name of channel.
+		channelName, err := db.ConvertPKCHtoChannelName(channelName)
+		if err != nil {
+			message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryCrawlerStatus, err.Error())
+			c.JSON(http.StatusBadRequest, message)
+			return
+		}
+		channelNames = append(channelNames, channelName)
+	} else {
+		for _, channel := range configuration.Conf().Channel {
+			channelNames = append(channelNames, channel.Name)
+		}
+	}
+	logger.Infof("Crawler status requested in %v", channelNames)
+
+	var resp CrawlerStatusResponseList
+	resp.Data = make([]CrawlerStatusResponse, len(channelNames))
+	for i, channelName := range channelNames {
+		var status polarbear.CrawlerStatus
+		if err := polarbear.QueryCrawlerStatus(channelName, &status); err != nil {
+			internalError := err.Error()
+			logger.Error(internalError)
+			message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryCrawlerStatus, internalError)
+			c.JSON(http.StatusInternalServerError, message)
+			return
+		}
+		convertPbCrawlerStatusToResponse(&status, &resp.Data[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PostHandlerPause godoc
+// @Tags Crawler
+// @Summary POST handler to pause crawling
+// @Description Pause crawling in the channel. Polling, block stream and backfill skip the channel until it is resumed.
+// @Description The crawling in progress is not stopped, and the crawl jobs are run while it is paused.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to pause. Can be channel name or PK of channel."
+// @Success 200 {object} crawler.CrawlerStatusResponse "The crawler status of the channel"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/pause [post]
+func PostHandlerPause(c *gin.Context) {
+	controlCrawling(c, polarbear.PauseCrawling)
+}
+
+// PostHandlerResume godoc
+// @Tags Crawler
+// @Summary POST handler to resume crawling
+// @Description Resume crawling in the channel paused.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to resume. Can be channel name or PK of channel."
+// @Success 200 {object} crawler.CrawlerStatusResponse "The crawler status of the channel"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/resume [post]
+func PostHandlerResume(c *gin.Context) {
+	controlCrawling(c, polarbear.ResumeCrawling)
+}
+
+// controlCrawling pauses or resumes crawling in the channel of request, and responds the crawler status.
+func controlCrawling(c *gin.Context, control func(channelName string) error) {
+	channelName, ok := getChannelName(c, isaacerror.ErrorFailToControlCrawler)
+	if !ok {
+		return
+	}
+	logger.Infof("Control of crawler requested, %s in %s", c.Request.URL.Path, channelName)
+
+	var status polarbear.CrawlerStatus
+	err := control(channelName)
+	if err == nil {
+		err = polarbear.QueryCrawlerStatus(channelName, &status)
+	}
+	if err != nil {
+		writeError(c, err, isaacerror.ErrorFailToControlCrawler)
+		return
+	}
+
+	var resp CrawlerStatusResponse
+	convertPbCrawlerStatusToResponse(&status, &resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHandlerJobList godoc
+// @Tags Crawler
+// @Summary GET handler of crawl jobs
+// @Description Get many crawl jobs ordered by the latest, in the channel or in all channels if no channel is queried.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  false "Channel to query. Can be channel name or PK of channel."
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Success 200 {object} crawler.CrawlJobResponseList "Result for many crawl jobs"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/jobs [get]
+func GetHandlerJobList(c *gin.Context) {
+	offset, limit, err := utility.GetOffsetListFromRequest(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName != "" {
+		// If user queried channel as PK, then get the real name of channel.
+		if channelName, err = db.ConvertPKCHtoChannelName(channelName); err != nil {
+			message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryCrawlJobList, err.Error())
+			c.JSON(http.StatusBadRequest, message)
+			return
+		}
+	}
+	logger.Infof("Crawl jobs requested, limit:%d, offset:%d in %s", limit, offset, channelName)
+
+	var jobs []polarbear.CrawlJob
+	count, err := polarbear.QueryCrawlJobs(channelName, limit, offset, &jobs)
+	if err != nil {
+		writeError(c, err, isaacerror.ErrorFailToQueryCrawlJobList)
+		return
+	}
+
+	var resp CrawlJobResponseList
+	resp.Data = make([]CrawlJobResponse, len(jobs))
+	resp.Total = int(count)
+
+	for i := 0; i < len(jobs); i++ {
+		convertPbCrawlJobToResponse(&jobs[i], &resp.Data[i])
+	}
+
+	// Put total information.
+	bytes := "bytes 0-" + strconv.Itoa(resp.Total) + "/" + strconv.Itoa(resp.Total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(resp.Total))
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHandlerJob godoc
+// @Tags Crawler
+// @Summary GET handler of a crawl job
+// @Description Get the crawl job with the progress.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param jobid path integer true "ID of crawl job"
+// @Success 200 {object} crawler.CrawlJobResponse "Result for a crawl job"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/jobs/{jobid} [get]
+func GetHandlerJob(c *gin.Context) {
+	id, ok := getJobID(c, isaacerror.ErrorFailToQueryCrawlJob)
+	if !ok {
+		return
+	}
+
+	var job polarbear.CrawlJob
+	if err := polarbear.QueryCrawlJob(id, &job); err != nil {
+		writeError(c, err, isaacerror.ErrorFailToQueryCrawlJob)
+		return
+	}
+
+	var resp CrawlJobResponse
+	convertPbCrawlJobToResponse(&job, &resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PostHandlerJob godoc
+// @Tags Crawler
+// @Summary POST handler to start a crawl job
+// @Description Start the job to crawl the blocks again in the channel. Recrawl deletes the blocks between fromHeight and toHeight
+// @Description and crawls them again. Rebuild purges the blocks not pruned in the channel and crawls them up to the last block height of the chain.
+// @Description The channel is not crawled by polling, block stream and backfill while the job is running. A job at once in a channel.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to crawl. Can be channel name or PK of channel."
+// @Param kind query string  true "Kind of job. Recrawl or Rebuild."
+// @Param fromHeight query integer  false "The lowest block height to recrawl."
+// @Param toHeight query integer  false "The highest block height to recrawl."
+// @Success 200 {object} crawler.CrawlJobResponse "The crawl job started"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 409 {object} isaacerror.APIError "Another job is running in the channel."
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/jobs [post]
+func PostHandlerJob(c *gin.Context) {
+	channelName, ok := getChannelName(c, isaacerror.ErrorFailToStartCrawlJob)
+	if !ok {
+		return
+	}
+
+	kind := c.Query(constants.RequestQueryKind)
+	var fromHeight, toHeight int64
+	if kind == polarbear.CrawlJobRecrawl {
+		var errFrom, errTo error
+		fromHeight, errFrom = strconv.ParseInt(c.Query(constants.RequestQueryFromHeight), 10, 64)
+		toHeight, errTo = strconv.ParseInt(c.Query(constants.RequestQueryToHeight), 10, 64)
+		if errFrom != nil || errTo != nil {
+			writeError(c, isaacerror.SysErrInvalidCrawlJob, isaacerror.ErrorFailToStartCrawlJob)
+			return
+		}
+	}
+	logger.Infof("Crawl job requested, %s from %d to %d in %s", kind, fromHeight, toHeight, channelName)
+
+	var job polarbear.CrawlJob
+	if err := polarbear.CreateCrawlJob(channelName, kind, fromHeight, toHeight, &job); err != nil {
+		writeError(c, err, isaacerror.ErrorFailToStartCrawlJob)
+		return
+	}
+
+	var resp CrawlJobResponse
+	convertPbCrawlJobToResponse(&job, &resp)
+
+	// The job runs in background. Its progress is queried by GetHandlerJob.
+	go func() {
+		_ = polarbear.RunCrawlJob(&job, nil)
+	}()
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PostHandlerCancelJob godoc
+// @Tags Crawler
+// @Summary POST handler to cancel a crawl job
+// @Description Cancel the running crawl job. It stops after the blocks in progress are crawled, even if it is run by the command.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param jobid path integer true "ID of crawl job"
+// @Success 200 {object} crawler.CrawlJobResponse "The crawl job canceled"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 409 {object} isaacerror.APIError "The job is not running."
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /crawler/jobs/{jobid}/cancel [post]
+func PostHandlerCancelJob(c *gin.Context) {
+	id, ok := getJobID(c, isaacerror.ErrorFailToCancelCrawlJob)
+	if !ok {
+		return
+	}
+	logger.Infof("Cancel of crawl job %d requested", id)
+
+	var job polarbear.CrawlJob
+	if err := polarbear.CancelCrawlJob(id, &job); err != nil {
+		writeError(c, err, isaacerror.ErrorFailToCancelCrawlJob)
+		return
+	}
+	_ = polarbear.QueryCrawlJob(id, &job)
+
+	var resp CrawlJobResponse
+	convertPbCrawlJobToResponse(&job, &resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// getChannelName checks the channel in request. The response is written if it fails.
+func getChannelName(c *gin.Context, apiError string) (string, bool) {
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return "", false
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err := db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(apiError, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return "", false
+	}
+
+	return channelName, true
+}
+
+// getJobID checks the ID of crawl job in request. The response is written if it fails.
+func getJobID(c *gin.Context, apiError string) (uint, bool) {
+	jobID := c.Param(constants.RequestParamJobID)
+	id, err := strconv.ParseUint(jobID, 10, 32)
+	if err != nil {
+		errMsg := fmt.Sprintf("%s is not right job ID. ", jobID)
+		logger.Error(errMsg)
+		message := isaacerror.GetAPIError(apiError, errMsg)
+		c.JSON(http.StatusBadRequest, message)
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// writeError writes the error response. The errors of the job requested are the errors of parameter or conflict.
+func writeError(c *gin.Context, err error, apiError string) {
+	internalError := err.Error()
+	logger.Error(internalError)
+	message := isaacerror.GetAPIError(apiError, internalError)
+	switch err {
+	case isaacerror.SysErrInvalidCrawlJob, isaacerror.SysErrNoCrawlJob:
+		c.JSON(http.StatusBadRequest, message)
+	case isaacerror.SysErrCrawlJobRunning, isaacerror.SysErrCrawlJobNotRunning:
+		c.JSON(http.StatusConflict, message)
+	default:
+		c.JSON(http.StatusInternalServerError, message)
+	}
+}
+
+// GetMetricsHandler writes the crawler status of all channels in the text format of prometheus.
+// The channel whose status fails to be queried is left out.
+func GetMetricsHandler(c *gin.Context) {
+	var statuses []polarbear.CrawlerStatus
+	for _, channel := range configuration.Conf().Channel {
+		var status polarbear.CrawlerStatus
+		if err := polarbear.QueryCrawlerStatus(channel.Name, &status); err != nil {
+			logger.Error(err.Error())
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	c.String(http.StatusOK, formatMetrics(statuses))
+}
+
+// crawlerMetric is a metric of crawler in prometheus.
+type crawlerMetric struct {
+	name   string
+	help   string
+	kind   string
+	sample func(status *polarbear.CrawlerStatus) float64
+}
+
+var crawlerMetrics = []crawlerMetric{
+	{"isaac_crawler_db_height", "Highest block height in DB.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return float64(s.DBHeight) }},
+	{"isaac_crawler_chain_height", "Last block height of the chain seen by the crawler.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return float64(s.ChainHeight) }},
+	{"isaac_crawler_lag_blocks", "Blocks of the chain not in DB yet.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return float64(s.LagInBlocks) }},
+	{"isaac_crawler_lag_seconds", "Seconds of the chain not in DB yet.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return float64(s.LagInSeconds) }},
+	{"isaac_crawler_blocks_per_second", "Blocks stored per second in the last minute.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return s.BlocksPerSecond }},
+	{"isaac_crawler_crawling", "1 if the crawler is crawling the channel.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return boolToFloat(s.Crawling) }},
+	{"isaac_crawler_paused", "1 if crawling in the channel is paused.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return boolToFloat(s.Paused) }},
+	{"isaac_crawler_pending_txs", "Txs whose result is not fetched yet.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 { return float64(s.PendingTxs) }},
+	{"isaac_crawler_last_success_timestamp_seconds", "Unix time of the last success of the crawler.", "gauge",
+		func(s *polarbear.CrawlerStatus) float64 {
+			if s.LastSuccess.IsZero() {
+				return 0
+			}
+			return float64(s.LastSuccess.Unix())
+		}},
+	{"isaac_crawler_errors_total", "Errors of the crawler since the server started.", "counter",
+		func(s *polarbear.CrawlerStatus) float64 { return float64(s.CountOfError) }},
+}
+
+// formatMetrics formats the statuses in the text format of prometheus.
+func formatMetrics(statuses []polarbear.CrawlerStatus) string {
+	var b strings.Builder
+	for _, metric := range crawlerMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", metric.name, metric.kind)
+		for i := range statuses {
+			fmt.Fprintf(&b, "%s{channel=\"%s\"} %v\n",
+				metric.name, escapeLabelValue(statuses[i].Channel), metric.sample(&statuses[i]))
+		}
+	}
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes the label value in the text format of prometheus.
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func convertPbCrawlerStatusToResponse(status *polarbear.CrawlerStatus, out *CrawlerStatusResponse) {
+	out.Channel = status.Channel
+	out.DBHeight = status.DBHeight
+	out.ChainHeight = status.ChainHeight
+	out.LagInBlocks = status.LagInBlocks
+	out.LagInSeconds = status.LagInSeconds
+	out.BlocksPerSecond = status.BlocksPerSecond
+	out.Crawling = status.Crawling
+	out.Streaming = status.Streaming
+	out.Paused = status.Paused
+	out.PendingTxs = status.PendingTxs
+	out.LastError = status.LastError
+	out.CountOfError = status.CountOfError
+	out.NodeIP = status.NodeIP
+
+	if !status.LastSuccess.IsZero() {
+		out.LastSuccess = status.LastSuccess.Format(time.RFC3339)
+	}
+	if !status.LastErrorTime.IsZero() {
+		out.LastErrorTime = status.LastErrorTime.Format(time.RFC3339)
+	}
+}
+
+func convertPbCrawlJobToResponse(job *polarbear.CrawlJob, out *CrawlJobResponse) {
+	out.ID = job.ID
+	out.Channel = job.Channel
+	out.Kind = job.Kind
+	out.BeginHeight = job.BeginHeight
+	out.EndHeight = job.EndHeight
+	out.Status = job.Status
+	out.CountOfBlocks = job.CountOfBlocks
+	out.CountOfDone = job.CountOfDone
+	out.CountOfFailed = job.CountOfFailed
+	out.LastError = job.LastError
+	out.StartedAt = job.CreatedAt.Format(time.RFC3339)
+
+	if job.FinishedAt != nil {
+		out.FinishedAt = job.FinishedAt.Format(time.RFC3339)
+	}
+}
@@ -0,0 +1,189 @@
+package crawler
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	for h := 1; h <= 3; h++ {
+		block := polarbear.Block{
+			Channel:     "channel1",
+			BlockHeight: int64(h),
+			BlockHash:   "0x1234",
+			Timestamp:   time.Now(),
+		}
+		_ = polarbear.Database().Save(&block).Error
+	}
+
+	configuration.Conf().Channel = []configuration.Channels{{Name: "channel1"}, {Name: "channel2"}}
+}
+
+func request(router *gin.Engine, url string, channelName string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, url, nil)
+	q := request.URL.Query()
+	if channelName != "" {
+		q.Add("channel", channelName)
+	}
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+	return w
+}
+
+func get(router *gin.Engine, url string, out interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, url, nil)
+	router.ServeHTTP(w, request)
+
+	_ = json.Unmarshal(w.Body.Bytes(), out)
+	return w
+}
+
+func post(router *gin.Engine, url string, out interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodPOST, url, nil)
+	router.ServeHTTP(w, request)
+
+	_ = json.Unmarshal(w.Body.Bytes(), out)
+	return w
+}
+
+func TestGetStatusHandler(t *testing.T) {
+	setup()
+	defer func() { configuration.Conf().Channel = nil }()
+
+	router := gin.Default()
+	router.GET(constants.CrawlerStatusGETAPIURL, GetStatusHandler)
+
+	var result CrawlerStatusResponseList
+	w := request(router, constants.CrawlerStatusGETAPIURL, "channel1")
+	_ = json.Unmarshal(w.Body.Bytes(), &result)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, len(result.Data), 1)
+	assert.Equal(t, result.Data[0].Channel, "channel1")
+	assert.Equal(t, result.Data[0].DBHeight, int64(3))
+	assert.Equal(t, result.Data[0].ChainHeight, int64(-1))
+	assert.Equal(t, result.Data[0].LastSuccess, "")
+
+	// All channels in configuration.
+	result = CrawlerStatusResponseList{}
+	w = request(router, constants.CrawlerStatusGETAPIURL, "")
+	_ = json.Unmarshal(w.Body.Bytes(), &result)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, len(result.Data), 2)
+	assert.Equal(t, result.Data[1].Channel, "channel2")
+	assert.Equal(t, result.Data[1].DBHeight, int64(0))
+}
+
+func TestGetMetricsHandler(t *testing.T) {
+	setup()
+	defer func() { configuration.Conf().Channel = nil }()
+
+	router := gin.Default()
+	router.GET(constants.CrawlerMetricsURL, GetMetricsHandler)
+
+	w := request(router, constants.CrawlerMetricsURL, "")
+	body := w.Body.String()
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, strings.Contains(body, "# TYPE isaac_crawler_db_height gauge\n"), true)
+	assert.Equal(t, strings.Contains(body, "isaac_crawler_db_height{channel=\"channel1\"} 3\n"), true)
+	assert.Equal(t, strings.Contains(body, "isaac_crawler_chain_height{channel=\"channel2\"} -1\n"), true)
+	assert.Equal(t, strings.Contains(body, "# TYPE isaac_crawler_errors_total counter\n"), true)
+}
+
+func TestPostHandlerPause(t *testing.T) {
+	setup()
+	defer func() { configuration.Conf().Channel = nil }()
+
+	router := gin.Default()
+	router.POST(constants.CrawlerPausePOSTAPIURL, PostHandlerPause)
+	router.POST(constants.CrawlerResumePOSTAPIURL, PostHandlerResume)
+
+	var result CrawlerStatusResponse
+	w := post(router, constants.CrawlerPausePOSTAPIURL+"?channel=channel1", &result)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, result.Channel, "channel1")
+	assert.Equal(t, result.Paused, true)
+
+	w = post(router, constants.CrawlerResumePOSTAPIURL+"?channel=channel1", &result)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, result.Paused, false)
+
+	// No channel.
+	w = post(router, constants.CrawlerPausePOSTAPIURL, &result)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestCrawlJobHandlers(t *testing.T) {
+	setup()
+	defer func() { configuration.Conf().Channel = nil }()
+
+	router := gin.Default()
+	router.GET(constants.CrawlerJobsGETListAPIURL, GetHandlerJobList)
+	router.POST(constants.CrawlerJobsPOSTAPIURL, PostHandlerJob)
+	router.GET(constants.CrawlerJobGETAPIURL, GetHandlerJob)
+	router.POST(constants.CrawlerJobCancelPOSTAPIURL, PostHandlerCancelJob)
+
+	// Invalid kind and range.
+	var job CrawlJobResponse
+	w := post(router, constants.CrawlerJobsPOSTAPIURL+"?channel=channel1&kind=Unknown", &job)
+	assert.Equal(t, 400, w.Code)
+	w = post(router, constants.CrawlerJobsPOSTAPIURL+"?channel=channel1&kind=Recrawl&fromHeight=3&toHeight=1", &job)
+	assert.Equal(t, 400, w.Code)
+
+	w = post(router, constants.CrawlerJobsPOSTAPIURL+"?channel=channel1&kind=Recrawl&fromHeight=1&toHeight=3", &job)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, job.Kind, "Recrawl")
+	assert.Equal(t, job.Status, "Running")
+	assert.Equal(t, job.CountOfBlocks, int64(3))
+
+	// The job runs in background. No node serves the blocks in test.
+	url := strings.Replace(constants.CrawlerJobGETAPIURL, ":"+constants.RequestParamJobID, strconv.Itoa(int(job.ID)), 1)
+	for i := 0; i < 100 && (job.Status == "Running" || job.Status == "Canceling"); i++ {
+		time.Sleep(50 * time.Millisecond)
+		w = get(router, url, &job)
+		assert.Equal(t, 200, w.Code)
+	}
+	assert.Equal(t, job.Status, "Done")
+	assert.Equal(t, job.CountOfDone, int64(3))
+	assert.Equal(t, job.CountOfFailed, int64(3))
+	assert.NotEqual(t, job.FinishedAt, "")
+
+	// The job finished is not canceled.
+	w = post(router, url+"/cancel", &job)
+	assert.Equal(t, 409, w.Code)
+
+	var jobs CrawlJobResponseList
+	w = get(router, constants.CrawlerJobsGETListAPIURL+"?channel=channel1&limit=10&offset=0", &jobs)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, jobs.Total, 1)
+	assert.Equal(t, jobs.Data[0].ID, job.ID)
+
+	// No job of the ID.
+	w = get(router, strings.Replace(url, strconv.Itoa(int(job.ID)), "100", 1), &job)
+	assert.Equal(t, 400, w.Code)
+	w = get(router, strings.Replace(url, strconv.Itoa(int(job.ID)), "x", 1), &job)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	assert.Equal(t, escapeLabelValue(`a"b\c`+"\n"), `a\"b\\c\n`)
+}
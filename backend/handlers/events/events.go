@@ -0,0 +1,187 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type EventResponseList struct {
+	Data  []EventResponse `json:"data"`
+	Total int             `json:"total" example:"1" format:"int32"`
+}
+
+type EventResponse struct {
+	TxHash       string   `json:"txHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+	BlockHeight  int64    `json:"blockHeight" example:"124" `
+	Timestamp    string   `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+	LogIndex     int      `json:"logIndex" example:"0" `
+	ScoreAddress string   `json:"scoreAddress" example:"cx54d95fee187faaea03cee908f50623c8381179d0" `
+	Signature    string   `json:"signature" example:"Transfer(Address,Address,int,bytes)" `
+	Indexed      []string `json:"indexed" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	Data         []string `json:"data" example:"0x1" `
+}
+
+// GetHandlerList godoc
+// @Tags Events
+// @Summary GET handler of event logs
+// @Description Get many event logs of transactions.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Param contract query string false "'contract' is SCORE address which emitted the event. Be use to search."
+// @Param signature query string false "'signature' is event signature. ex) Transfer(Address,Address,int,bytes)"
+// @Param indexed query string false "'indexed' be used to search the event which has the value in indexed arguments."
+// @Param fromHeight query integer false "'fromHeight' be used to search block height. Greater than or equal to."
+// @Param toHeight query integer false "'toHeight' be used to search block height. Less than or equal to."
+// @Param from query string false "'from' be used to search timestamp. Greater than or equal to."
+// @Param to query string false "'to' be used to search timestamp. Less than or equal to."
+// @Success 200 {object} events.EventResponseList "Result for many event resources"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /events [get]
+func GetHandlerList(c *gin.Context) {
+	// Check the parameters.
+	offset, limit, err := utility.GetOffsetListFromRequest(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// Get search items in http query.
+	eventSearch, err := getEventSearchItems(c)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorInvalidParameter, internalError)
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryEventList, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Events requested, limit:%d, offset:%d in %s", limit, offset, channelName)
+
+	// Query event list.
+	var eventLogs []polarbear.EventLog
+	count, err := polarbear.QueryEventLogsInChannel(channelName, limit, offset, eventSearch, &eventLogs)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryEventList, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp EventResponseList
+	resp.Data = make([]EventResponse, len(eventLogs))
+	resp.Total = int(count)
+
+	for i := 0; i < len(eventLogs); i++ {
+		convertPbEventLogToEventResponse(&eventLogs[i], &resp.Data[i])
+	}
+
+	// Put total information.
+	bytes := "bytes 0-" + strconv.Itoa(resp.Total) + "/" + strconv.Itoa(resp.Total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(resp.Total))
+
+	// Return body.
+	c.JSON(http.StatusOK, resp)
+}
+
+func getEventSearchItems(c *gin.Context) (polarbear.EventLogSearch, error) {
+	eventSearch := polarbear.EventLogSearch{
+		FromHeight: -1,
+		ToHeight:   -1,
+	}
+
+	eventSearch.ScoreAddress = c.Query(constants.RequestQueryContract)
+	eventSearch.Signature = c.Query(constants.RequestQuerySignature)
+	eventSearch.Indexed = c.Query(constants.RequestQueryIndexed)
+
+	if fromHeight, exist := c.GetQuery(constants.RequestQueryFromHeight); exist {
+		height, err := strconv.ParseInt(fromHeight, 10, 64)
+		if err != nil {
+			return eventSearch, isaacerror.SysErrFailToParseStringToInt
+		}
+		eventSearch.FromHeight = height
+	}
+
+	if toHeight, exist := c.GetQuery(constants.RequestQueryToHeight); exist {
+		height, err := strconv.ParseInt(toHeight, 10, 64)
+		if err != nil {
+			return eventSearch, isaacerror.SysErrFailToParseStringToInt
+		}
+		eventSearch.ToHeight = height
+	}
+
+	if from, exist := c.GetQuery(constants.RequestQueryFrom); exist {
+		fromTimer, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return eventSearch, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+		eventSearch.From = fromTimer
+	}
+
+	if to, exist := c.GetQuery(constants.RequestQueryTo); exist {
+		toTimer, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return eventSearch, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+		eventSearch.To = toTimer
+	}
+
+	return eventSearch, nil
+}
+
+func convertPbEventLogToEventResponse(eventLog *polarbear.EventLog, out *EventResponse) {
+	out.TxHash = eventLog.TxHash
+	out.BlockHeight = eventLog.BlockHeight
+	out.Timestamp = eventLog.Timestamp.Format(time.RFC3339)
+	out.LogIndex = eventLog.LogIndex
+	out.ScoreAddress = eventLog.ScoreAddress
+	out.Signature = eventLog.Signature
+
+	out.Indexed = []string{}
+	_ = json.Unmarshal([]byte(eventLog.Indexed), &out.Indexed)
+
+	// Data values can be not string, so convert them into string.
+	var data []interface{}
+	_ = json.Unmarshal([]byte(eventLog.Data), &data)
+	out.Data = make([]string, 0, len(data))
+	for _, d := range data {
+		switch v := d.(type) {
+		case string:
+			out.Data = append(out.Data, v)
+		case nil:
+			out.Data = append(out.Data, "")
+		default:
+			b, _ := json.Marshal(v)
+			out.Data = append(out.Data, string(b))
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func generateTestEventLogsInDB(channelName string, height int) error {
+	for h := 1; h <= height; h++ {
+		txHash := "0x" + strconv.FormatInt(int64(h), 16)
+		block := polarbear.Block{
+			Channel:     channelName,
+			BlockHeight: int64(h),
+			BlockHash:   "0x" + strconv.FormatInt(int64(h*1000), 16),
+			Timestamp:   time.Now(),
+			Txs: []polarbear.Tx{
+				{
+					TxHash:      txHash,
+					Channel:     channelName,
+					Status:      "Success",
+					BlockHeight: int64(h),
+					Timestamp:   time.Now(),
+					Result: polarbear.TxResult{
+						TxHash:      txHash,
+						Channel:     channelName,
+						BlockHeight: int64(h),
+						EventLogs: []polarbear.EventLog{
+							{
+								TxHash:       txHash,
+								Channel:      channelName,
+								BlockHeight:  int64(h),
+								Timestamp:    time.Now(),
+								ScoreAddress: "cx54d95fee187faaea03cee908f50623c8381179d0",
+								Signature:    "Transfer(Address,Address,int,bytes)",
+								Indexed1:     "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+								Indexed2:     "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b",
+								Indexed3:     "0x1",
+								Indexed:      `["hx5d91dee6102ead2aca60256cf33ebf9aab102c82","hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b","0x1"]`,
+								Data:         `["0x"]`,
+							},
+						},
+					},
+				},
+			},
+		}
+		if err := polarbear.Database().Save(&block).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestEventLogsInDB("channel1", 30)
+}
+
+func TestGetHandlerList(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.EventGETListAPIURL, GetHandlerList)
+	w := httptest.NewRecorder()
+
+	// Request the URL.
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.EventGETListAPIURL,
+		nil)
+	q := request.URL.Query()
+	q.Add("limit", "10")
+	q.Add("offset", "0")
+	q.Add("channel", "channel1")
+	q.Add("contract", "cx54d95fee187faaea03cee908f50623c8381179d0")
+	q.Add("signature", "Transfer(Address,Address,int,bytes)")
+	q.Add("indexed", "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b")
+	q.Add("fromHeight", "11")
+	q.Add("toHeight", "25")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var resultList EventResponseList
+	_ = json.Unmarshal(w.Body.Bytes(), &resultList)
+
+	// Test the response.
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 15)
+	assert.Equal(t, len(resultList.Data), 10)
+	assert.Equal(t, strconv.Itoa(resultList.Total), w.Header().Get("X-Total-Count"))
+	assert.Equal(t, resultList.Data[0].BlockHeight, int64(25))
+	assert.Equal(t, resultList.Data[0].Indexed[1], "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b")
+	assert.Equal(t, resultList.Data[0].Data, []string{"0x"})
+
+	// No event with the other indexed value.
+	w2 := httptest.NewRecorder()
+	q.Set("indexed", "hx0000000000000000000000000000000000000000")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w2, request)
+
+	resultList = EventResponseList{}
+	_ = json.Unmarshal(w2.Body.Bytes(), &resultList)
+	assert.Equal(t, 200, w2.Code)
+	assert.Equal(t, resultList.Total, 0)
+}
+
+// Test to return error with the invalid search items.
+func TestGetHandlerListInvalidParameter(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.EventGETListAPIURL, GetHandlerList)
+	w := httptest.NewRecorder()
+
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.EventGETListAPIURL,
+		nil)
+	q := request.URL.Query()
+	q.Add("limit", "10")
+	q.Add("offset", "0")
+	q.Add("channel", "channel1")
+	q.Add("fromHeight", "abc")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,449 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"io"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/handlers/txs"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The count of records to write before flushing them to client.
+const flushRecordCount = 1000
+
+// BlockRecord is a block in export. Txs are exported separately.
+type BlockRecord struct {
+	Channel       string `json:"channel" example:"loopchain_default"`
+	BlockHeight   int64  `json:"blockHeight" example:"124"`
+	BlockHash     string `json:"blockHash" example:"0x586e5b26c51a8d07c6071a510ce7a26bf681342faa443180615c525a41934516"`
+	PrevBlockHash string `json:"prevBlockHash" example:"0x1c7e8a0b33bb2d8e8b4dd4e3a2e1d1f7d0a0b4b51f3ed1bb6eb8ef8ef2bba5f0"`
+	PeerID        string `json:"peerID" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82"`
+	Signature     string `json:"signature"`
+	Timestamp     string `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+}
+
+// SymptomRecord is a peer symptom in export.
+type SymptomRecord struct {
+	Channel   string `json:"channel" example:"loopchain_default"`
+	Msg       string `json:"msg" example:"[node4]response time slowly [25.106827] sec"`
+	Symptom   string `json:"symptom" example:"Slow response"`
+	Timestamp string `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+}
+
+var blockCSVHeader = []string{"channel", "blockHeight", "blockHash", "prevBlockHash", "peerID", "signature", "timeStamp"}
+
+var txCSVHeader = []string{"txHash", "status", "timeStamp", "from", "to", "blockHeight", "value", "data",
+	"stepUsed", "stepPrice", "fee", "scoreAddress", "failureCode", "failureMessage", "logsBloom"}
+
+var symptomCSVHeader = []string{"channel", "msg", "symptom", "timeStamp"}
+
+// recordWriter writes the records in CSV or NDJSON. The CSV header is written before the first record.
+type recordWriter struct {
+	header  []string
+	csv     *csv.Writer
+	json    *json.Encoder
+	count   int64
+	begun   bool
+	flusher http.Flusher // nil if not HTTP response.
+}
+
+func newRecordWriter(w io.Writer, format string, header []string) (*recordWriter, error) {
+	writer := &recordWriter{header: header}
+	switch format {
+	case constants.ExportFormatCSV:
+		writer.csv = csv.NewWriter(w)
+	case constants.ExportFormatNDJSON:
+		writer.json = json.NewEncoder(w)
+	default:
+		return nil, isaacerror.SysErrNotSupportedExportFormat
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		writer.flusher = flusher
+	}
+
+	return writer, nil
+}
+
+// begin writes the CSV header once.
+func (w *recordWriter) begin() error {
+	if w.begun {
+		return nil
+	}
+	w.begun = true
+
+	if w.csv != nil {
+		return w.csv.Write(w.header)
+	}
+	return nil
+}
+
+// write writes the record. v is written in NDJSON, and record is written in CSV.
+func (w *recordWriter) write(v interface{}, record []string) error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+
+	var err error
+	if w.csv != nil {
+		err = w.csv.Write(record)
+	} else {
+		err = w.json.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+
+	w.count++
+	if w.count%flushRecordCount == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// end writes the header if no record, and flushes the rest.
+func (w *recordWriter) end() error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+func (w *recordWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+	return nil
+}
+
+// ExportBlocks writes the blocks searched in channel to w in the format. Returns the count of blocks written.
+func ExportBlocks(w io.Writer, format string, channelName string, search polarbear.BlockSearch) (int64, error) {
+	writer, err := newRecordWriter(w, format, blockCSVHeader)
+	if err != nil {
+		return 0, err
+	}
+
+	err = polarbear.ExportBlocksInChannel(channelName, search, func(block *polarbear.Block) error {
+		record := BlockRecord{
+			Channel:       block.Channel,
+			BlockHeight:   block.BlockHeight,
+			BlockHash:     block.BlockHash,
+			PrevBlockHash: block.PrevBlockHash,
+			PeerID:        block.PeerID,
+			Signature:     block.Signature,
+			Timestamp:     block.Timestamp.Format(time.RFC3339),
+		}
+		return writer.write(&record, []string{record.Channel, strconv.FormatInt(record.BlockHeight, 10),
+			record.BlockHash, record.PrevBlockHash, record.PeerID, record.Signature, record.Timestamp})
+	})
+	if err != nil {
+		return writer.count, err
+	}
+
+	return writer.count, writer.end()
+}
+
+// ExportTxs writes the Txs searched in channel to w in the format. Returns the count of Txs written.
+func ExportTxs(w io.Writer, format string, channelName string, search polarbear.TxSearch) (int64, error) {
+	writer, err := newRecordWriter(w, format, txCSVHeader)
+	if err != nil {
+		return 0, err
+	}
+
+	err = polarbear.ExportTxsInChannel(channelName, search, func(tx *polarbear.Tx) error {
+		var record txs.TxResponse
+		txs.ConvertPbTxToTxResponse(tx, &record)
+		return writer.write(&record, []string{record.TxHash, record.Status, record.Timestamp, record.From,
+			record.To, strconv.FormatInt(record.BlockHeight, 10), record.Value, record.Data,
+			strconv.FormatInt(record.StepUsed, 10), strconv.FormatInt(record.StepPrice, 10), record.Fee,
+			record.ScoreAddress, strconv.FormatInt(record.FailureCode, 10), record.FailureMessage, record.LogsBloom})
+	})
+	if err != nil {
+		return writer.count, err
+	}
+
+	return writer.count, writer.end()
+}
+
+// ExportSymptoms writes the symptoms of the channels between the times to w in the format.
+// The channels are PKs. Returns the count of symptoms written.
+func ExportSymptoms(w io.Writer, format string, channelPKs []string, from time.Time, to time.Time) (int64, error) {
+	writer, err := newRecordWriter(w, format, symptomCSVHeader)
+	if err != nil {
+		return 0, err
+	}
+
+	err = polarbear.ExportSymptoms(channelPKs, from, to, func(symptom *polarbear.Symptom) error {
+		record := SymptomRecord{
+			Channel:   symptom.Channel,
+			Msg:       symptom.Msg,
+			Symptom:   symptom.SymptomType,
+			Timestamp: symptom.Timestamp.Format(time.RFC3339),
+		}
+		return writer.write(&record, []string{record.Channel, record.Msg, record.Symptom, record.Timestamp})
+	})
+	if err != nil {
+		return writer.count, err
+	}
+
+	return writer.count, writer.end()
+}
+
+// GetHandlerBlocks godoc
+// @Tags Export
+// @Summary GET handler to export blocks
+// @Description Export the blocks in the channel as CSV or NDJSON. The blocks are streamed in the order of height without paging.
+// @Accept  json
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param format query string false "'csv' or 'ndjson'. Default is 'ndjson'."
+// @Param fromHeight query integer false "The lowest block height to export."
+// @Param toHeight query integer false "The highest block height to export."
+// @Param from query string false "'from' be used to search timestamp."
+// @Param to query string false "'to' be used to search timestamp."
+// @Success 200 {object} export.BlockRecord "A block in each line"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /export/blocks [get]
+func GetHandlerBlocks(c *gin.Context) {
+	format, channelName, ok := getExportParameters(c)
+	if !ok {
+		return
+	}
+
+	var search polarbear.BlockSearch
+	var err error
+	if search.FromHeight, search.ToHeight, err = getHeightRange(c); err == nil {
+		search.From, search.To, err = getTimeRange(c)
+	}
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	logger.Infof("Export of blocks requested, format:%s in %s", format, channelName)
+
+	setExportHeaders(c, format, channelName+"_blocks")
+	count, err := ExportBlocks(c.Writer, format, channelName, search)
+	endExport(c, count, err)
+}
+
+// GetHandlerTxs godoc
+// @Tags Export
+// @Summary GET handler to export transactions
+// @Description Export the transactions in the channel as CSV or NDJSON. The transactions are streamed in the order of block height without paging.
+// @Description The search parameters are the same as /txs.
+// @Accept  json
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param format query string false "'csv' or 'ndjson'. Default is 'ndjson'."
+// @Param fromHeight query integer false "The lowest block height to export."
+// @Param toHeight query integer false "The highest block height to export."
+// @Param status query string false "'status' be used to search transaction. The kind of 'status' is 'success' and 'failure'."
+// @Param blockHeight query string false "'blockHeight' be used to search transaction belong blockHeight."
+// @Param from query string false "'from' be used to search timestamp. Used with 'to'."
+// @Param to query string false "'to' be used to search timestamp. Used with 'from'."
+// @Param fromAddress query string false "'fromAddress' is send address of transaction. Be use to search."
+// @Param toAddress query string false "'toAddress' is receive address of transaction. Be use to search."
+// @Param data query string false "'data' be used to search specific phrases in data field of transaction."
+// @Success 200 {object} txs.TxResponse "A transaction in each line"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /export/txs [get]
+func GetHandlerTxs(c *gin.Context) {
+	format, channelName, ok := getExportParameters(c)
+	if !ok {
+		return
+	}
+
+	search, err := txs.GetTxSearchItems(c)
+	if err == nil {
+		search.FromHeight, search.ToHeight, err = getHeightRange(c)
+	}
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	logger.Infof("Export of Txs requested, format:%s in %s", format, channelName)
+
+	setExportHeaders(c, format, channelName+"_txs")
+	count, err := ExportTxs(c.Writer, format, channelName, search)
+	endExport(c, count, err)
+}
+
+// GetHandlerSymptoms godoc
+// @Tags Export
+// @Summary GET handler to export symptoms
+// @Description Export the peer symptoms as CSV or NDJSON in the order of time. Without channel, the symptoms of every channel permitted are exported.
+// @Accept  json
+// @Produce  text/csv
+// @Produce  application/x-ndjson
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  false "Channel to query. Can be channel name or PK of channel."
+// @Param format query string false "'csv' or 'ndjson'. Default is 'ndjson'."
+// @Param from query string false "'from' be used to search timestamp."
+// @Param to query string false "'to' be used to search timestamp."
+// @Success 200 {object} export.SymptomRecord "A symptom in each line"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /export/symptoms [get]
+func GetHandlerSymptoms(c *gin.Context) {
+	format, err := getFormat(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := getTimeRange(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// Symptoms are stored with PK of channel.
+	var channelPKs []string
+	if channelID := c.Query(constants.RequestQueryChannel); channelID != "" {
+		channelName, err := db.ConvertPKCHtoChannelName(channelID)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err)
+			return
+		}
+		channelPK, exists := db.GetChannelNameToPKMap()[channelName]
+		if !exists {
+			writeError(c, http.StatusBadRequest, isaacerror.SysErrNoChannelInDB)
+			return
+		}
+		channelPKs = []string{channelPK}
+	} else if permissionChannelList, exists := c.Get(constants.ContextKeyPermissionChannelList); exists {
+		channelPKs = utility.ConvertInterfaceToStringSlice(permissionChannelList)
+	} else {
+		for _, value := range db.GetUserPermissionChannels(constants.AdminPK) {
+			channelPKs = append(channelPKs, value.CHANNEL_PK)
+		}
+	}
+	logger.Infof("Export of symptoms requested, format:%s, from:%s, to:%s", format, from, to)
+
+	setExportHeaders(c, format, "symptoms")
+	count, err := ExportSymptoms(c.Writer, format, channelPKs, from, to)
+	endExport(c, count, err)
+}
+
+// getFormat gets the export format in request. Default is NDJSON.
+func getFormat(c *gin.Context) (string, error) {
+	format := c.DefaultQuery(constants.RequestQueryFormat, constants.ExportFormatNDJSON)
+	if format != constants.ExportFormatCSV && format != constants.ExportFormatNDJSON {
+		return "", isaacerror.SysErrNotSupportedExportFormat
+	}
+	return format, nil
+}
+
+// getExportParameters checks the format and channel in request. The response is written if it fails.
+func getExportParameters(c *gin.Context) (string, string, bool) {
+	format, err := getFormat(c)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return "", "", false
+	}
+
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return "", "", false
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return "", "", false
+	}
+
+	return format, channelName, true
+}
+
+// getHeightRange gets the range of block heights in request. 0 for no limit.
+func getHeightRange(c *gin.Context) (int64, int64, error) {
+	var fromHeight, toHeight int64
+	var err error
+	if value, exist := c.GetQuery(constants.RequestQueryFromHeight); exist {
+		if fromHeight, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, 0, isaacerror.SysErrFailToParseStringToInt
+		}
+	}
+	if value, exist := c.GetQuery(constants.RequestQueryToHeight); exist {
+		if toHeight, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return 0, 0, isaacerror.SysErrFailToParseStringToInt
+		}
+	}
+	return fromHeight, toHeight, nil
+}
+
+// getTimeRange gets the range of time in request. Zero time for no limit.
+func getTimeRange(c *gin.Context) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if value, exist := c.GetQuery(constants.RequestQueryFrom); exist {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+	}
+	if value, exist := c.GetQuery(constants.RequestQueryTo); exist {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+	}
+	return from, to, nil
+}
+
+// setExportHeaders sets the headers to download the export as file.
+func setExportHeaders(c *gin.Context, format string, fileName string) {
+	contentType := constants.HTTPContentTypeNDJSON
+	if format == constants.ExportFormatCSV {
+		contentType = constants.HTTPContentTypeTextCSV
+	}
+	c.Header(constants.HTTPHeaderContentType, contentType)
+	c.Header(constants.HTTPHeaderContentDisposition, "attachment; filename=\""+fileName+"."+format+"\"")
+}
+
+// endExport writes the error response if nothing is streamed yet. Otherwise the error is only logged,
+// because the status is already sent.
+func endExport(c *gin.Context, count int64, err error) {
+	if err == nil {
+		logger.Infof("Exported %d records.", count)
+		return
+	}
+
+	if c.Writer.Written() {
+		logger.Errorf("Fail to export after %d records. %s", count, err)
+		return
+	}
+	c.Writer.Header().Del(constants.HTTPHeaderContentDisposition)
+	writeError(c, http.StatusInternalServerError, err)
+}
+
+func writeError(c *gin.Context, status int, err error) {
+	internalError := err.Error()
+	logger.Error(internalError)
+	message := isaacerror.GetAPIError(isaacerror.ErrorFailToExportData, internalError)
+	c.JSON(status, message)
+}
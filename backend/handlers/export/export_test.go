@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/handlers/txs"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// generateTestDataInDB generates the blocks having a Tx each, and the symptoms.
+func generateTestDataInDB(channelName string, height int) error {
+	now := time.Now()
+	for h := 1; h <= height; h++ {
+		status := "Success"
+		if h%2 == 0 {
+			status = "Failure"
+		}
+		tx := polarbear.Tx{
+			TxHash:      "0x" + strconv.Itoa(h),
+			Status:      status,
+			Channel:     channelName,
+			BlockHeight: int64(h),
+			Timestamp:   now,
+			From:        "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+			To:          "cx54d95fee187faaea03cee908f50623c8381179d0",
+			Value:       "1000",
+			Data:        `{"method":"transfer"}`,
+			Result:      polarbear.TxResult{Channel: channelName, BlockHeight: int64(h), StepUsed: 100, Fee: "1000"},
+		}
+		block := polarbear.Block{
+			Channel:     channelName,
+			BlockHeight: int64(h),
+			BlockHash:   "0x" + strconv.Itoa(h),
+			Timestamp:   now,
+			Txs:         []polarbear.Tx{tx},
+		}
+		if err := polarbear.Database().Save(&block).Error; err != nil {
+			return err
+		}
+
+		symptom := polarbear.Symptom{
+			Channel:     channelName,
+			Channel_PK:  "PKCH_1",
+			Msg:         "[node4]response time slowly [25.106827] sec",
+			SymptomType: "Slow response",
+			Timestamp:   now,
+		}
+		if err := polarbear.Database().Save(&symptom).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestDataInDB("channel1", 12)
+}
+
+func request(router *gin.Engine, url string, query map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, url, nil)
+	q := request.URL.Query()
+	for key, value := range query {
+		q.Add(key, value)
+	}
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	return w
+}
+
+func TestGetHandlerTxs(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.ExportTxsGETAPIURL, GetHandlerTxs)
+
+	// NDJSON in the order of block height.
+	w := request(router, constants.ExportTxsGETAPIURL, map[string]string{"channel": "channel1", "status": "success"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, w.Header().Get(constants.HTTPHeaderContentType), constants.HTTPContentTypeNDJSON)
+
+	var result []txs.TxResponse
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var tx txs.TxResponse
+		assert.Equal(t, json.Unmarshal(scanner.Bytes(), &tx), nil)
+		result = append(result, tx)
+	}
+	assert.Equal(t, len(result), 6)
+	assert.Equal(t, result[0].BlockHeight, int64(1))
+	assert.Equal(t, result[0].Status, "Success")
+	assert.Equal(t, result[0].Fee, "1000")
+
+	// CSV in the range of heights.
+	w = request(router, constants.ExportTxsGETAPIURL, map[string]string{
+		"channel": "channel1", "format": "csv", "fromHeight": "3", "toHeight": "5"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, w.Header().Get(constants.HTTPHeaderContentType), constants.HTTPContentTypeTextCSV)
+	assert.Equal(t, w.Header().Get(constants.HTTPHeaderContentDisposition), "attachment; filename=\"channel1_txs.csv\"")
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 4)
+	assert.Equal(t, records[0], txCSVHeader)
+	assert.Equal(t, records[1][0], "0x3")
+
+	// Wrong format.
+	w = request(router, constants.ExportTxsGETAPIURL, map[string]string{"channel": "channel1", "format": "xml"})
+	assert.Equal(t, 400, w.Code)
+
+	// Wrong status.
+	w = request(router, constants.ExportTxsGETAPIURL, map[string]string{"channel": "channel1", "status": "Unknown"})
+	assert.Equal(t, 400, w.Code)
+
+	// No channel.
+	w = request(router, constants.ExportTxsGETAPIURL, map[string]string{})
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestGetHandlerBlocks(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.ExportBlocksGETAPIURL, GetHandlerBlocks)
+
+	w := request(router, constants.ExportBlocksGETAPIURL, map[string]string{"channel": "channel1", "toHeight": "10"})
+	assert.Equal(t, 200, w.Code)
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	assert.Equal(t, len(lines), 10)
+
+	var block BlockRecord
+	assert.Equal(t, json.Unmarshal([]byte(lines[9]), &block), nil)
+	assert.Equal(t, block.BlockHeight, int64(10))
+	assert.Equal(t, block.BlockHash, "0x10")
+
+	// Only the header without block.
+	w = request(router, constants.ExportBlocksGETAPIURL, map[string]string{
+		"channel": "channel1", "format": "csv", "fromHeight": "100"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), strings.Join(blockCSVHeader, ","))
+
+	// Wrong height.
+	w = request(router, constants.ExportBlocksGETAPIURL, map[string]string{"channel": "channel1", "fromHeight": "0x1"})
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestGetHandlerSymptoms(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.Use(func(c *gin.Context) {
+		c.Set(constants.ContextKeyPermissionChannelList, []string{"PKCH_1"})
+	})
+	router.GET(constants.ExportSymptomsGETAPIURL, GetHandlerSymptoms)
+
+	w := request(router, constants.ExportSymptomsGETAPIURL, map[string]string{"format": "csv"})
+	assert.Equal(t, 200, w.Code)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(records), 13)
+	assert.Equal(t, records[1][0], "channel1")
+	assert.Equal(t, records[1][2], "Slow response")
+}
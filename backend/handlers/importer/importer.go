@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"net/http"
+)
+
+type ImportResponse struct {
+	Imported   int64                 `json:"imported" example:"1000"`
+	Duplicated int64                 `json:"duplicated" example:"10"`
+	Failed     int64                 `json:"failed" example:"1"`
+	Errors     []ImportErrorResponse `json:"errors"`
+}
+
+type ImportErrorResponse struct {
+	Line        int64  `json:"line" example:"12"`
+	BlockHeight int64  `json:"blockHeight" example:"124"`
+	Error       string `json:"error" example:"Another block is already stored at the height."`
+}
+
+// PostHandler godoc
+// @Tags Import
+// @Summary POST handler to import blocks
+// @Description Store the blocks in the dump of icx_getBlockByHeight responses, one block per line.
+// @Description The dump of icx_getTransactionResult responses for the Txs is optional. Without it, every Tx is success.
+// @Description The results are in the order of blocks, and the block whose Tx has no result in it fails.
+// @Description The block already stored at the height is skipped if it has the same hash. Only for admin.
+// @Accept  mpfd
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to import. Can be channel name or PK of channel."
+// @Param blocks formData file true "Dump of blocks"
+// @Param txResults formData file false "Dump of Tx results"
+// @Success 200 {object} importer.ImportResponse "Result of import"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /import [post]
+func PostHandler(c *gin.Context) {
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err := db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	blocksFile, err := c.FormFile(constants.ImportFormFileBlocks)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	blocks, err := blocksFile.Open()
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer blocks.Close()
+
+	// Tx results are optional.
+	var txResults io.Reader
+	if txResultsFile, err := c.FormFile(constants.ImportFormFileTxResults); err == nil {
+		file, err := txResultsFile.Open()
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, err)
+			return
+		}
+		defer file.Close()
+		txResults = file
+	} else if err != http.ErrMissingFile {
+		writeError(c, http.StatusBadRequest, err)
+		return
+	}
+	logger.Infof("Import of blocks requested, %s in %s", blocksFile.Filename, channelName)
+
+	result, err := polarbear.ImportBlocks(channelName, blocks, txResults)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == isaacerror.SysErrFailToImportTxResults {
+			status = http.StatusBadRequest
+		}
+		writeError(c, status, err)
+		return
+	}
+
+	resp := ImportResponse{
+		Imported:   result.Imported,
+		Duplicated: result.Duplicated,
+		Failed:     result.Failed,
+		Errors:     make([]ImportErrorResponse, len(result.Errors)),
+	}
+	for i, e := range result.Errors {
+		resp.Errors[i] = ImportErrorResponse{Line: e.Line, BlockHeight: e.BlockHeight, Error: e.Error}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func writeError(c *gin.Context, status int, err error) {
+	internalError := err.Error()
+	logger.Error(internalError)
+	message := isaacerror.GetAPIError(isaacerror.ErrorFailToImportBlocks, internalError)
+	c.JSON(status, message)
+}
@@ -0,0 +1,83 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"mime/multipart"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// blockLine returns the block in version 0.3 without Tx.
+func blockLine(height int64, hash string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","result":{"version":"0.3","height":%d,"hash":"%s",`+
+		`"prevHash":"","timestamp":1560000000000000,"transactions":[],"leader":"hx01"},"id":1}`,
+		height, strings.Repeat(hash, 64))
+}
+
+func request(router *gin.Engine, channel string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, content := range files {
+		part, _ := writer.CreateFormFile(name, name+".json")
+		_, _ = part.Write([]byte(content))
+	}
+	_ = writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(constants.HTTPMethodPOST, constants.ImportPOSTAPIURL+"?channel="+channel, body)
+	req.Header.Set(constants.HTTPHeaderContentType, writer.FormDataContentType())
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestPostHandler(t *testing.T) {
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	router := gin.Default()
+	router.POST(constants.ImportPOSTAPIURL, PostHandler)
+
+	blocks := blockLine(1, "a") + "\n" + blockLine(2, "b") + "\n"
+	w := request(router, "channel1", map[string]string{constants.ImportFormFileBlocks: blocks})
+	assert.Equal(t, w.Code, 200)
+
+	var resp ImportResponse
+	assert.Equal(t, json.Unmarshal(w.Body.Bytes(), &resp), nil)
+	assert.Equal(t, resp.Imported, int64(2))
+	assert.Equal(t, polarbear.GetCurrentBlockHeightInDB("channel1"), int64(2))
+
+	// The same block is skipped, and the other block at the same height fails.
+	blocks = blockLine(2, "b") + "\n" + blockLine(1, "c") + "\n"
+	w = request(router, "channel1", map[string]string{constants.ImportFormFileBlocks: blocks})
+	assert.Equal(t, w.Code, 200)
+
+	resp = ImportResponse{}
+	assert.Equal(t, json.Unmarshal(w.Body.Bytes(), &resp), nil)
+	assert.Equal(t, resp.Duplicated, int64(1))
+	assert.Equal(t, resp.Failed, int64(1))
+	assert.Equal(t, resp.Errors[0].Line, int64(2))
+	assert.Equal(t, resp.Errors[0].BlockHeight, int64(1))
+
+	// Wrong Tx results.
+	w = request(router, "channel1", map[string]string{
+		constants.ImportFormFileBlocks:    blocks,
+		constants.ImportFormFileTxResults: "{}\n",
+	})
+	assert.Equal(t, w.Code, 400)
+
+	// No blocks.
+	w = request(router, "channel1", map[string]string{})
+	assert.Equal(t, w.Code, 400)
+
+	// No channel.
+	w = request(router, "", map[string]string{constants.ImportFormFileBlocks: blocks})
+	assert.Equal(t, w.Code, 400)
+}
@@ -0,0 +1,227 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type QuarantinedBlockResponseList struct {
+	Data  []QuarantinedBlockResponse `json:"data"`
+	Total int                        `json:"total" example:"1" format:"int32"`
+}
+
+type QuarantinedBlockResponse struct {
+	BlockHeight  int64           `json:"blockHeight" example:"124" `
+	NodeIP       string          `json:"nodeIP" example:"http://127.0.0.1:9000" `
+	Error        string          `json:"error" example:"invalid receiver \"hx01\"" `
+	Status       string          `json:"status" example:"Pending" `
+	CountOfTrial int             `json:"countOfTrial" example:"1" `
+	Timestamp    string          `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+	RawData      json.RawMessage `json:"rawData,omitempty" swaggertype:"object"`
+}
+
+// GetHandlerList godoc
+// @Tags Quarantine
+// @Summary GET handler of quarantined blocks
+// @Description Get many blocks which failed to be decoded in the channel. The raw block data is not in the list.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param status query string  false "Status of quarantined block. Pending or Resolved."
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Success 200 {object} quarantine.QuarantinedBlockResponseList "Result for many quarantined blocks"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /quarantine [get]
+func GetHandlerList(c *gin.Context) {
+	offset, limit, err := utility.GetOffsetListFromRequest(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	channelName, ok := getChannelName(c, isaacerror.ErrorFailToQueryQuarantinedBlockList)
+	if !ok {
+		return
+	}
+
+	status := c.Query(constants.RequestQueryStatus)
+	if status != "" && status != polarbear.QuarantineStatusPending && status != polarbear.QuarantineStatusResolved {
+		errMsg := fmt.Sprintf("%s is not right status. ", status)
+		logger.Error(errMsg)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryQuarantinedBlockList, errMsg)
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Quarantined blocks requested, limit:%d, offset:%d, status:%s in %s", limit, offset, status, channelName)
+
+	var blocks []polarbear.QuarantinedBlock
+	count, err := polarbear.QueryQuarantinedBlocksInChannel(channelName, status, limit, offset, &blocks)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryQuarantinedBlockList, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp QuarantinedBlockResponseList
+	resp.Data = make([]QuarantinedBlockResponse, len(blocks))
+	resp.Total = int(count)
+
+	for i := 0; i < len(blocks); i++ {
+		convertPbQuarantinedBlockToResponse(&blocks[i], &resp.Data[i])
+	}
+
+	// Put total information.
+	bytes := "bytes 0-" + strconv.Itoa(resp.Total) + "/" + strconv.Itoa(resp.Total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(resp.Total))
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHandler godoc
+// @Tags Quarantine
+// @Summary GET handler of a quarantined block
+// @Description Get the quarantined block at the height with the raw block data responded from node.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param blockheight path integer true "Block height"
+// @Success 200 {object} quarantine.QuarantinedBlockResponse "Result for a quarantined block"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /quarantine/{blockheight} [get]
+func GetHandler(c *gin.Context) {
+	channelName, height, ok := getBlockParameters(c, isaacerror.ErrorFailToQueryQuarantinedBlock)
+	if !ok {
+		return
+	}
+	logger.Infof("Quarantined block requested, %d in %s", height, channelName)
+
+	var block polarbear.QuarantinedBlock
+	if err := polarbear.QueryQuarantinedBlockInChannel(channelName, height, &block); err != nil {
+		writeError(c, err, isaacerror.ErrorFailToQueryQuarantinedBlock)
+		return
+	}
+
+	var resp QuarantinedBlockResponse
+	convertPbQuarantinedBlockToResponse(&block, &resp)
+	resp.RawData = json.RawMessage(block.RawData)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// PostHandlerRetry godoc
+// @Tags Quarantine
+// @Summary POST handler to retry a quarantined block
+// @Description Crawl the quarantined block at the height again. If it is decoded and stored, the block is resolved.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param blockheight path integer true "Block height"
+// @Success 200 {object} quarantine.QuarantinedBlockResponse "The resolved block"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 409 {object} isaacerror.APIError "Crawling is suspended in the channel."
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /quarantine/{blockheight}/retry [post]
+func PostHandlerRetry(c *gin.Context) {
+	channelName, height, ok := getBlockParameters(c, isaacerror.ErrorFailToRetryQuarantinedBlock)
+	if !ok {
+		return
+	}
+	logger.Infof("Retry of quarantined block requested, %d in %s", height, channelName)
+
+	var block polarbear.QuarantinedBlock
+	if err := polarbear.RetryQuarantinedBlock(channelName, height, &block); err != nil {
+		writeError(c, err, isaacerror.ErrorFailToRetryQuarantinedBlock)
+		return
+	}
+
+	var resp QuarantinedBlockResponse
+	convertPbQuarantinedBlockToResponse(&block, &resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// getChannelName checks the channel in request. The response is written if it fails.
+func getChannelName(c *gin.Context, apiError string) (string, bool) {
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return "", false
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err := db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(apiError, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return "", false
+	}
+
+	return channelName, true
+}
+
+// getBlockParameters checks the channel and block height in request. The response is written if it fails.
+func getBlockParameters(c *gin.Context, apiError string) (string, int64, bool) {
+	channelName, ok := getChannelName(c, apiError)
+	if !ok {
+		return "", 0, false
+	}
+
+	blockID := c.Param(constants.RequestParamBlockHeight)
+	height, err := strconv.ParseInt(blockID, 10, 64)
+	if err != nil || height < 0 {
+		errMsg := fmt.Sprintf("%s is not right block height. ", blockID)
+		logger.Error(errMsg)
+		message := isaacerror.GetAPIError(apiError, errMsg)
+		c.JSON(http.StatusBadRequest, message)
+		return "", 0, false
+	}
+
+	return channelName, height, true
+}
+
+// writeError writes the error response. No quarantined block is the error of parameter,
+// and the retry while crawling is suspended is the conflict.
+func writeError(c *gin.Context, err error, apiError string) {
+	internalError := err.Error()
+	logger.Error(internalError)
+	message := isaacerror.GetAPIError(apiError, internalError)
+	switch err {
+	case isaacerror.SysErrNoQuarantinedBlock:
+		c.JSON(http.StatusBadRequest, message)
+	case isaacerror.SysErrCrawlSuspended:
+		c.JSON(http.StatusConflict, message)
+	default:
+		c.JSON(http.StatusInternalServerError, message)
+	}
+}
+
+func convertPbQuarantinedBlockToResponse(block *polarbear.QuarantinedBlock, out *QuarantinedBlockResponse) {
+	out.BlockHeight = block.BlockHeight
+	out.NodeIP = block.NodeIP
+	out.Error = block.Error
+	out.Status = block.Status
+	out.CountOfTrial = block.CountOfTrial
+	out.Timestamp = block.Timestamp.Format(time.RFC3339)
+}
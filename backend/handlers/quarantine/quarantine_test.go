@@ -0,0 +1,116 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// generateTestQuarantinedBlocksInDB generates the quarantined blocks. The odd heights are resolved.
+func generateTestQuarantinedBlocksInDB(channelName string, height int) error {
+	for h := 1; h <= height; h++ {
+		status := polarbear.QuarantineStatusPending
+		if h%2 == 1 {
+			status = polarbear.QuarantineStatusResolved
+		}
+
+		block := polarbear.QuarantinedBlock{
+			Channel:      channelName,
+			BlockHeight:  int64(h),
+			NodeIP:       "http://127.0.0.1:9000",
+			RawData:      `{"height":` + strconv.Itoa(h) + `}`,
+			Error:        "invalid block hash \"\"",
+			Status:       status,
+			CountOfTrial: 1,
+			Timestamp:    time.Now(),
+		}
+		if err := polarbear.Database().Save(&block).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestQuarantinedBlocksInDB("channel1", 12)
+}
+
+func request(router *gin.Engine, url string, status string, out interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, url, nil)
+	q := request.URL.Query()
+	q.Add("limit", "10")
+	q.Add("offset", "0")
+	q.Add("channel", "channel1")
+	if status != "" {
+		q.Add("status", status)
+	}
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	_ = json.Unmarshal(w.Body.Bytes(), out)
+	return w
+}
+
+func TestGetHandlerList(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.QuarantineGETListAPIURL, GetHandlerList)
+
+	var resultList QuarantinedBlockResponseList
+	w := request(router, constants.QuarantineGETListAPIURL, "", &resultList)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 12)
+	assert.Equal(t, len(resultList.Data), 10)
+	assert.Equal(t, strconv.Itoa(resultList.Total), w.Header().Get("X-Total-Count"))
+	assert.Equal(t, resultList.Data[0].BlockHeight, int64(12))
+	assert.Equal(t, len(resultList.Data[0].RawData), 0)
+
+	// Only pending blocks.
+	resultList = QuarantinedBlockResponseList{}
+	w = request(router, constants.QuarantineGETListAPIURL, polarbear.QuarantineStatusPending, &resultList)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 6)
+	assert.Equal(t, resultList.Data[0].Status, polarbear.QuarantineStatusPending)
+
+	// Wrong status.
+	w = request(router, constants.QuarantineGETListAPIURL, "Unknown", &resultList)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestGetHandler(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.QuarantineGETAPIURL, GetHandler)
+
+	var result QuarantinedBlockResponse
+	w := request(router, constants.QuarantineAPIBaseURL+"/4", "", &result)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, result.BlockHeight, int64(4))
+	assert.Equal(t, result.Status, polarbear.QuarantineStatusPending)
+	assert.Equal(t, string(result.RawData), `{"height":4}`)
+
+	// No quarantined block.
+	w = request(router, constants.QuarantineAPIBaseURL+"/100", "", &result)
+	assert.Equal(t, 400, w.Code)
+
+	// Wrong height.
+	w = request(router, constants.QuarantineAPIBaseURL+"/0x1", "", &result)
+	assert.Equal(t, 400, w.Code)
+}
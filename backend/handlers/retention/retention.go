@@ -0,0 +1,98 @@
+package retention
+
+import (
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"time"
+)
+
+type RetentionResponse struct {
+	Channel                string               `json:"channel" example:"channel1"`
+	MaxAgeInDays           int                  `json:"maxAgeInDays" example:"90"`
+	MaxBlocks              int64                `json:"maxBlocks" example:"0"`
+	Pruned                 *PrunedRangeResponse `json:"pruned,omitempty"`
+	RetainedSinceHeight    int64                `json:"retainedSinceHeight" example:"1024"`
+	RetainedSince          string               `json:"retainedSince" example:"2006-01-02T15:04:05Z07:00"`
+	SymptomRetentionInDays int                  `json:"symptomRetentionInDays" example:"30"`
+	SymptomRetainedSince   string               `json:"symptomRetainedSince" example:"2006-01-02T15:04:05Z07:00"`
+}
+
+type PrunedRangeResponse struct {
+	BeginHeight   int64  `json:"beginHeight" example:"1"`
+	EndHeight     int64  `json:"endHeight" example:"1023"`
+	CountOfBlocks int64  `json:"countOfBlocks" example:"1023"`
+	Timestamp     string `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+}
+
+// GetHandler godoc
+// @Tags Retention
+// @Summary GET handler of data retention
+// @Description Get the retention policy of the channel, the range of pruned blocks and since when the data is retained.
+// @Description retainedSince and symptomRetainedSince are empty if there is no data.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Success 200 {object} retention.RetentionResponse "Result for retention"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /retention [get]
+func GetHandler(c *gin.Context) {
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err := db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryRetention, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Retention requested in %s", channelName)
+
+	var status polarbear.RetentionStatus
+	if err := polarbear.QueryRetentionInChannel(channelName, &status); err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryRetention, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp RetentionResponse
+	convertPbRetentionToResponse(&status, &resp)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func convertPbRetentionToResponse(status *polarbear.RetentionStatus, out *RetentionResponse) {
+	out.Channel = status.Channel
+	out.MaxAgeInDays = status.MaxAgeInDays
+	out.MaxBlocks = status.MaxBlocks
+	out.RetainedSinceHeight = status.RetainedSinceHeight
+	out.SymptomRetentionInDays = status.SymptomRetentionInDays
+
+	if status.PrunedRange != nil {
+		out.Pruned = &PrunedRangeResponse{
+			BeginHeight:   status.PrunedRange.BeginHeight,
+			EndHeight:     status.PrunedRange.EndHeight,
+			CountOfBlocks: status.PrunedRange.CountOfBlocks,
+			Timestamp:     status.PrunedRange.Timestamp.Format(time.RFC3339),
+		}
+	}
+	if !status.RetainedSince.IsZero() {
+		out.RetainedSince = status.RetainedSince.Format(time.RFC3339)
+	}
+	if !status.SymptomRetainedSince.IsZero() {
+		out.SymptomRetainedSince = status.SymptomRetainedSince.Format(time.RFC3339)
+	}
+}
@@ -0,0 +1,90 @@
+package retention
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data. The blocks up to 9 are pruned.
+	now := time.Now()
+	for h := 10; h <= 12; h++ {
+		block := polarbear.Block{
+			Channel:     "channel1",
+			BlockHeight: int64(h),
+			BlockHash:   "0x1234",
+			Timestamp:   now.AddDate(0, 0, h-12),
+		}
+		_ = polarbear.Database().Save(&block).Error
+	}
+	prunedRange := polarbear.PrunedRange{
+		Channel:       "channel1",
+		BeginHeight:   1,
+		EndHeight:     9,
+		CountOfBlocks: 9,
+		Timestamp:     now,
+	}
+	_ = polarbear.Database().Save(&prunedRange).Error
+
+	configuration.Conf().Blockchain.Retention = []configuration.Retention{{Channel: "", MaxAgeInDays: 2}}
+	configuration.Conf().Blockchain.SymptomRetentionInDays = 30
+}
+
+func request(router *gin.Engine, channelName string, out interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, constants.RetentionGETAPIURL, nil)
+	q := request.URL.Query()
+	if channelName != "" {
+		q.Add("channel", channelName)
+	}
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	_ = json.Unmarshal(w.Body.Bytes(), out)
+	return w
+}
+
+func TestGetHandler(t *testing.T) {
+	setup()
+	defer func() {
+		configuration.Conf().Blockchain.Retention = nil
+		configuration.Conf().Blockchain.SymptomRetentionInDays = 0
+	}()
+
+	router := gin.Default()
+	router.GET(constants.RetentionGETAPIURL, GetHandler)
+
+	var result RetentionResponse
+	w := request(router, "channel1", &result)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, result.Channel, "channel1")
+	assert.Equal(t, result.MaxAgeInDays, 2)
+	assert.Equal(t, result.Pruned.EndHeight, int64(9))
+	assert.Equal(t, result.RetainedSinceHeight, int64(10))
+	assert.NotEqual(t, result.RetainedSince, "")
+	assert.Equal(t, result.SymptomRetentionInDays, 30)
+	assert.Equal(t, result.SymptomRetainedSince, "")
+
+	// No pruned block in channel.
+	result = RetentionResponse{}
+	w = request(router, "channel2", &result)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, result.Pruned == nil, true)
+	assert.Equal(t, result.RetainedSince, "")
+
+	// No channel.
+	w = request(router, "", &result)
+	assert.Equal(t, 400, w.Code)
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type SearchResponseList struct {
+	Query string              `json:"query" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82"`
+	Kind  string              `json:"kind" example:"address"`
+	Data  []SearchHitResponse `json:"data"`
+	Total int                 `json:"total" example:"1" format:"int32"`
+}
+
+type SearchHitResponse struct {
+	Type        string `json:"type" example:"block"`
+	Channel     string `json:"channel" example:"loopchain_default"`
+	Key         string `json:"key" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea"`
+	BlockHeight int64  `json:"blockHeight" example:"124"`
+	Timestamp   string `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+	Rank        int    `json:"rank" example:"3"`
+}
+
+// GetHandler godoc
+// @Tags Search
+// @Summary GET handler of search
+// @Description Search the block height, block hash, Tx hash, address or contract in every channel with permission.
+// @Description The kind of query is detected, and the hits are ordered by the rank and the latest.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param q query string true "Block height, block hash, Tx hash, address(hx) or contract(cx) to search."
+// @Success 200 {object} search.SearchResponseList "Result for the hits in channels"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /search [get]
+func GetHandler(c *gin.Context) {
+	// Check the parameters.
+	query := c.Query(constants.RequestQuerySearch)
+	if query == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	// Search in the channels with permission. All channels if no permission channel list from middleware.
+	var channelTB []db.CONFIGURATION_CHANNEL_TB
+	if permissionChannelList, exists := c.Get(constants.ContextKeyPermissionChannelList); exists {
+		permissionChannelListString := utility.ConvertInterfaceToStringSlice(permissionChannelList)
+		if len(permissionChannelListString) != 0 {
+			channelTB = db.GetConfigurationChannelInfoByList(permissionChannelListString)
+		}
+	} else {
+		channelTB = db.GetConfigurationChannelTable()
+	}
+	channelNames := make([]string, 0, len(channelTB))
+	for _, value := range channelTB {
+		channelNames = append(channelNames, value.CHANNEL_NAME)
+	}
+	logger.Infof("Search requested, %s in %d channels", query, len(channelNames))
+
+	// Query data.
+	var hits []polarbear.SearchHit
+	kind, err := polarbear.SearchInChannels(channelNames, query, &hits)
+	if err == isaacerror.SysErrInvalidSearchQuery {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorInvalidSearchQuery, internalError)
+		c.JSON(http.StatusBadRequest, message)
+		return
+	} else if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToSearch, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp SearchResponseList
+	resp.Query = query
+	resp.Kind = kind
+	resp.Data = make([]SearchHitResponse, len(hits))
+	resp.Total = len(hits)
+
+	for i := 0; i < len(hits); i++ {
+		convertPbSearchHitToSearchHitResponse(&hits[i], &resp.Data[i])
+	}
+
+	// Put total information.
+	bytes := "bytes 0-" + strconv.Itoa(resp.Total) + "/" + strconv.Itoa(resp.Total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(resp.Total))
+
+	// Return body.
+	c.JSON(http.StatusOK, resp)
+}
+
+func convertPbSearchHitToSearchHitResponse(hit *polarbear.SearchHit, out *SearchHitResponse) {
+	out.Type = hit.Type
+	out.Channel = hit.Channel
+	out.Key = hit.Key
+	out.BlockHeight = hit.BlockHeight
+	out.Rank = hit.Rank
+
+	// The contract deployed in genesis has no timestamp.
+	if !hit.Timestamp.IsZero() {
+		out.Timestamp = hit.Timestamp.Format(time.RFC3339)
+	}
+}
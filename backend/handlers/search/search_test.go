@@ -0,0 +1,101 @@
+package search
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testBlockHash = "0x1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57"
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Channels in ISAAC DB.
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	db.DBgorm().CreateTable(&db.CONFIGURATION_CHANNEL_TB{})
+	db.DBgorm().Create(&db.CONFIGURATION_CHANNEL_TB{CHANNEL_PK: "PKCH_1", CHANNEL_NAME: "channel1"})
+	db.DBgorm().Create(&db.CONFIGURATION_CHANNEL_TB{CHANNEL_PK: "PKCH_2", CHANNEL_NAME: "channel2"})
+
+	// Generate pseudo test data. The block at height 1 in both channels.
+	now := time.Now()
+	_ = polarbear.Database().Save(&polarbear.Block{Channel: "channel1", BlockHeight: 1, BlockHash: testBlockHash,
+		Timestamp: now}).Error
+	_ = polarbear.Database().Save(&polarbear.Block{Channel: "channel2", BlockHeight: 1, BlockHash: "0x1234",
+		Timestamp: now.Add(time.Minute)}).Error
+}
+
+func request(router *gin.Engine, query string, out interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, constants.SearchGETAPIURL+"?q="+url.QueryEscape(query), nil)
+	router.ServeHTTP(w, request)
+
+	_ = json.Unmarshal(w.Body.Bytes(), out)
+	return w
+}
+
+func TestGetHandler(t *testing.T) {
+	setup()
+	defer db.CloseDBInstance()
+
+	router := gin.Default()
+	router.GET(constants.SearchGETAPIURL, GetHandler)
+
+	// Every channel without permission channel list.
+	var resp SearchResponseList
+	w := request(router, "1", &resp)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Kind, polarbear.SearchQueryHeight)
+	assert.Equal(t, resp.Total, 2)
+	assert.Equal(t, resp.Data[0].Channel, "channel2")
+	assert.Equal(t, resp.Data[0].Type, polarbear.SearchHitBlock)
+	assert.Equal(t, w.Header().Get(constants.HTTPHeaderXTotalCount), "2")
+
+	resp = SearchResponseList{}
+	w = request(router, testBlockHash, &resp)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Kind, polarbear.SearchQueryHash)
+	assert.Equal(t, resp.Total, 1)
+	assert.Equal(t, resp.Data[0].Key, testBlockHash)
+	assert.Equal(t, resp.Data[0].Channel, "channel1")
+
+	// Invalid query.
+	w = request(router, "block", &resp)
+	assert.Equal(t, 400, w.Code)
+	w = request(router, "", &resp)
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestGetHandlerWithPermission(t *testing.T) {
+	setup()
+	defer db.CloseDBInstance()
+
+	permissionChannelList := []string{"PKCH_1"}
+	router := gin.Default()
+	router.GET(constants.SearchGETAPIURL, func(c *gin.Context) {
+		c.Set(constants.ContextKeyPermissionChannelList, permissionChannelList)
+	}, GetHandler)
+
+	// Only in the channels with permission.
+	var resp SearchResponseList
+	w := request(router, "1", &resp)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Total, 1)
+	assert.Equal(t, resp.Data[0].Channel, "channel1")
+
+	// Nothing without permission of any channel.
+	permissionChannelList = []string{}
+	resp = SearchResponseList{}
+	w = request(router, "1", &resp)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.Total, 0)
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"time"
+)
+
+type VolumeResponse struct {
+	Address        string                 `json:"address,omitempty" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	Volume         string                 `json:"volume" example:"1000000000000000000" ` // In loop.
+	SentVolume     string                 `json:"sentVolume,omitempty" example:"1000000000000000000" `
+	ReceivedVolume string                 `json:"receivedVolume,omitempty" example:"0" `
+	Fee            string                 `json:"fee" example:"1000000000000000" ` // In loop.
+	TxCount        int64                  `json:"txCount" example:"1" `
+	Buckets        []VolumeBucketResponse `json:"buckets,omitempty"`
+}
+
+type VolumeBucketResponse struct {
+	Timestamp      string `json:"timeStamp" example:"2006-01-02T00:00:00Z"`
+	Volume         string `json:"volume" example:"1000000000000000000" `
+	SentVolume     string `json:"sentVolume,omitempty" example:"1000000000000000000" `
+	ReceivedVolume string `json:"receivedVolume,omitempty" example:"0" `
+	Fee            string `json:"fee" example:"1000000000000000" `
+	TxCount        int64  `json:"txCount" example:"1" `
+}
+
+// GetHandlerVolume godoc
+// @Tags Stats
+// @Summary GET handler of volume statistics
+// @Description Get the transferred ICX volume and total fees in loop, in the channel or of the address.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param address query string false "'address' be used to get the volume sent and received, and the fees paid by the address."
+// @Param from query string false "'from' be used to search timestamp. Greater than or equal to."
+// @Param to query string false "'to' be used to search timestamp. Less than or equal to."
+// @Param interval query string false "'interval' is 'hour', 'day' or 'month'. Be used to get the statistics per time bucket in UTC."
+// @Success 200 {object} stats.VolumeResponse "Result for volume statistics"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /stats/volume [get]
+func GetHandlerVolume(c *gin.Context) {
+	// Check the parameters.
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	search, err := getVolumeSearchItems(c)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorInvalidParameter, internalError)
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryVolumeStats, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return
+	}
+	logger.Infof("Volume statistics requested, address:%s, interval:%s in %s", search.Address, search.Interval, channelName)
+
+	var total polarbear.VolumeStats
+	var buckets []polarbear.VolumeBucket
+	if err := polarbear.QueryVolumeStatsInChannel(channelName, search, &total, &buckets); err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryVolumeStats, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	resp := VolumeResponse{
+		Address: search.Address,
+		Volume:  total.Volume.String(),
+		Fee:     total.Fee.String(),
+		TxCount: total.TxCount,
+	}
+	if search.Address != "" {
+		resp.SentVolume = total.SentVolume.String()
+		resp.ReceivedVolume = total.ReceivedVolume.String()
+	}
+
+	resp.Buckets = make([]VolumeBucketResponse, len(buckets))
+	for i := 0; i < len(buckets); i++ {
+		convertPbVolumeBucketToVolumeBucketResponse(&buckets[i], search.Address, &resp.Buckets[i])
+	}
+
+	// Return body.
+	c.JSON(http.StatusOK, resp)
+}
+
+func getVolumeSearchItems(c *gin.Context) (polarbear.VolumeSearch, error) {
+	var search polarbear.VolumeSearch
+
+	search.Address = c.Query(constants.RequestQueryAddress)
+
+	search.Interval = c.Query(constants.RequestQueryInterval)
+	switch search.Interval {
+	case "", polarbear.VolumeIntervalHour, polarbear.VolumeIntervalDay, polarbear.VolumeIntervalMonth:
+	default:
+		return search, isaacerror.SysErrInvalidParameter
+	}
+
+	if from, exist := c.GetQuery(constants.RequestQueryFrom); exist {
+		fromTimer, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return search, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+		search.From = fromTimer
+	}
+
+	if to, exist := c.GetQuery(constants.RequestQueryTo); exist {
+		toTimer, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return search, isaacerror.SysErrFailToParseTimeStringToTimeObject
+		}
+		search.To = toTimer
+	}
+
+	return search, nil
+}
+
+func convertPbVolumeBucketToVolumeBucketResponse(bucket *polarbear.VolumeBucket, address string, out *VolumeBucketResponse) {
+	out.Timestamp = bucket.Timestamp.Format(time.RFC3339)
+	out.Volume = bucket.Volume.String()
+	out.Fee = bucket.Fee.String()
+	out.TxCount = bucket.TxCount
+	if address != "" {
+		out.SentVolume = bucket.SentVolume.String()
+		out.ReceivedVolume = bucket.ReceivedVolume.String()
+	}
+}
@@ -0,0 +1,131 @@
+package stats
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const sender = "hx5d91dee6102ead2aca60256cf33ebf9aab102c82"
+const receiver = "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b"
+
+// generateTestTxsInDB generates a Tx transferring 5 ICX every 12 hours. Every third Tx fails.
+func generateTestTxsInDB(channelName string, count int) error {
+	baseTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	for h := 1; h <= count; h++ {
+		status := "Success"
+		if h%3 == 0 {
+			status = "Failure"
+		}
+
+		txHash := "0x" + strconv.FormatInt(int64(h), 16)
+		block := polarbear.Block{
+			Channel:     channelName,
+			BlockHeight: int64(h),
+			BlockHash:   "0x" + strconv.FormatInt(int64(h*1000), 16),
+			Timestamp:   baseTime.Add(time.Duration(h-1) * 12 * time.Hour),
+			Txs: []polarbear.Tx{
+				{
+					TxHash:      txHash,
+					Channel:     channelName,
+					Status:      status,
+					BlockHeight: int64(h),
+					From:        sender,
+					To:          receiver,
+					Value:       "5000000000000000000",
+					Timestamp:   baseTime.Add(time.Duration(h-1) * 12 * time.Hour),
+					Result: polarbear.TxResult{
+						TxHash:      txHash,
+						Channel:     channelName,
+						BlockHeight: int64(h),
+						Fee:         "1000000000000000",
+					},
+				},
+			},
+		}
+		if err := polarbear.Database().Save(&block).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestTxsInDB("channel1", 6)
+}
+
+func requestVolume(router *gin.Engine, query map[string]string) (*httptest.ResponseRecorder, VolumeResponse) {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.StatsVolumeGETAPIURL,
+		nil)
+	q := request.URL.Query()
+	for k, v := range query {
+		q.Add(k, v)
+	}
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var resp VolumeResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	return w, resp
+}
+
+func TestGetHandlerVolume(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.StatsVolumeGETAPIURL, GetHandlerVolume)
+
+	// The volume of channel. The failed Txs pay the fee without transfer.
+	w, resp := requestVolume(router, map[string]string{"channel": "channel1"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.TxCount, int64(6))
+	assert.Equal(t, resp.Volume, "20000000000000000000")
+	assert.Equal(t, resp.Fee, "6000000000000000")
+	assert.Equal(t, len(resp.Buckets), 0)
+
+	// The volume of receiver per day.
+	w, resp = requestVolume(router, map[string]string{"channel": "channel1", "address": receiver, "interval": "day"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.ReceivedVolume, "20000000000000000000")
+	assert.Equal(t, resp.SentVolume, "0")
+	assert.Equal(t, resp.Fee, "0")
+	assert.Equal(t, len(resp.Buckets), 3)
+	assert.Equal(t, resp.Buckets[0].Timestamp, "2019-01-01T00:00:00Z")
+	assert.Equal(t, resp.Buckets[1].ReceivedVolume, "5000000000000000000")
+	assert.Equal(t, resp.Buckets[1].TxCount, int64(2))
+
+	// The volume of sender in time range.
+	w, resp = requestVolume(router, map[string]string{
+		"channel": "channel1", "address": sender, "from": "2019-01-02T00:00:00Z", "to": "2019-01-03T23:59:59Z"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resp.TxCount, int64(4))
+	assert.Equal(t, resp.SentVolume, "10000000000000000000")
+	assert.Equal(t, resp.Fee, "4000000000000000")
+}
+
+// Test to return error with the invalid interval.
+func TestGetHandlerVolumeInvalidParameter(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.StatsVolumeGETAPIURL, GetHandlerVolume)
+
+	w, _ := requestVolume(router, map[string]string{"channel": "channel1", "interval": "week"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
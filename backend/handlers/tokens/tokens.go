@@ -0,0 +1,234 @@
+package tokens
+
+import (
+	"github.com/gin-gonic/gin"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"motherbear/backend/polarbear"
+	"motherbear/backend/utility"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type TokenResponseList struct {
+	Data  []TokenResponse `json:"data"`
+	Total int             `json:"total" example:"1" format:"int32"`
+}
+
+type TokenResponse struct {
+	Address       string `json:"address" example:"cx54d95fee187faaea03cee908f50623c8381179d0" `
+	FirstHeight   int64  `json:"firstHeight" example:"124" `
+	FirstTxHash   string `json:"firstTxHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+	TransferCount int64  `json:"transferCount" example:"10" `
+	HolderCount   int64  `json:"holderCount" example:"3" `
+}
+
+type TokenHolderResponseList struct {
+	Data  []TokenHolderResponse `json:"data"`
+	Total int                   `json:"total" example:"1" format:"int32"`
+}
+
+type TokenHolderResponse struct {
+	Holder  string `json:"holder" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	Balance string `json:"balance" example:"1000000000000000000" `
+}
+
+type TokenTransferResponseList struct {
+	Data  []TokenTransferResponse `json:"data"`
+	Total int                     `json:"total" example:"1" format:"int32"`
+}
+
+type TokenTransferResponse struct {
+	TxHash      string `json:"txHash" example:"0xf6a9cfccbcb40a8fa2a6226c8087f01917b3f6ab0a44b809874b46b3348aabea" `
+	BlockHeight int64  `json:"blockHeight" example:"124" `
+	Timestamp   string `json:"timeStamp" example:"2006-01-02T15:04:05Z07:00"`
+	LogIndex    int    `json:"logIndex" example:"0" `
+	From        string `json:"from" example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82" `
+	To          string `json:"to" example:"hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b" `
+	Amount      string `json:"amount" example:"1000000000000000000" `
+}
+
+// GetHandlerList godoc
+// @Tags Tokens
+// @Summary GET handler of tokens
+// @Description Get many IRC-2 tokens which transferred in the channel.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Success 200 {object} tokens.TokenResponseList "Result for many token resources"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /tokens [get]
+func GetHandlerList(c *gin.Context) {
+	offset, limit, channelName, ok := getListParameters(c, isaacerror.ErrorFailToQueryTokenList)
+	if !ok {
+		return
+	}
+	logger.Infof("Tokens requested, limit:%d, offset:%d in %s", limit, offset, channelName)
+
+	var tokenList []polarbear.TokenSummary
+	count, err := polarbear.QueryTokensInChannel(channelName, limit, offset, &tokenList)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryTokenList, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp TokenResponseList
+	resp.Data = make([]TokenResponse, len(tokenList))
+	resp.Total = int(count)
+
+	for i := 0; i < len(tokenList); i++ {
+		resp.Data[i] = TokenResponse{
+			Address:       tokenList[i].Address,
+			FirstHeight:   tokenList[i].FirstHeight,
+			FirstTxHash:   tokenList[i].FirstTxHash,
+			TransferCount: tokenList[i].TransferCount,
+			HolderCount:   tokenList[i].HolderCount,
+		}
+	}
+
+	putTotalHeader(c, resp.Total)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHandlerHolders godoc
+// @Tags Tokens
+// @Summary GET handler of token holders
+// @Description Get the holders of the IRC-2 token, ordered by the balance. The balance is summed by the transfers crawled.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param address path string true "Token address"
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Success 200 {object} tokens.TokenHolderResponseList "Result for many token holders"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /tokens/{address}/holders [get]
+func GetHandlerHolders(c *gin.Context) {
+	offset, limit, channelName, ok := getListParameters(c, isaacerror.ErrorFailToQueryTokenHolders)
+	if !ok {
+		return
+	}
+	tokenAddress := c.Param(constants.RequestParamAddress)
+	logger.Infof("Token holders requested, %s, limit:%d, offset:%d in %s", tokenAddress, limit, offset, channelName)
+
+	var balances []polarbear.TokenBalance
+	count, err := polarbear.QueryTokenHoldersInChannel(channelName, tokenAddress, limit, offset, &balances)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryTokenHolders, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp TokenHolderResponseList
+	resp.Data = make([]TokenHolderResponse, len(balances))
+	resp.Total = int(count)
+
+	for i := 0; i < len(balances); i++ {
+		resp.Data[i] = TokenHolderResponse{Holder: balances[i].Holder, Balance: balances[i].Balance}
+	}
+
+	putTotalHeader(c, resp.Total)
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetHandlerTransfers godoc
+// @Tags Tokens
+// @Summary GET handler of token transfers
+// @Description Get the transfers of the IRC-2 token, extracted from the Transfer event logs.
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Authentication header ('Bearer '+ JWT token)"
+// @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
+// @Param address path string true "Token address"
+// @Param limit query integer true "Identify the number of results returned from a result set."
+// @Param offset query integer  true "Identify the starting point to return data from a result set."
+// @Success 200 {object} tokens.TokenTransferResponseList "Result for many token transfers"
+// @Failure 400 {object} isaacerror.APIError "Invalid parameter."
+// @Failure 401 {object} isaacerror.APIError "Unauthorized"
+// @Failure 500 {object} isaacerror.APIError "Internal server error."
+// @Router /tokens/{address}/transfers [get]
+func GetHandlerTransfers(c *gin.Context) {
+	offset, limit, channelName, ok := getListParameters(c, isaacerror.ErrorFailToQueryTokenTransfers)
+	if !ok {
+		return
+	}
+	tokenAddress := c.Param(constants.RequestParamAddress)
+	logger.Infof("Token transfers requested, %s, limit:%d, offset:%d in %s", tokenAddress, limit, offset, channelName)
+
+	var transfers []polarbear.TokenTransfer
+	count, err := polarbear.QueryTokenTransfersInChannel(channelName, tokenAddress, limit, offset, &transfers)
+	if err != nil {
+		internalError := err.Error()
+		logger.Error(internalError)
+		message := isaacerror.GetAPIError(isaacerror.ErrorFailToQueryTokenTransfers, internalError)
+		c.JSON(http.StatusInternalServerError, message)
+		return
+	}
+
+	var resp TokenTransferResponseList
+	resp.Data = make([]TokenTransferResponse, len(transfers))
+	resp.Total = int(count)
+
+	for i := 0; i < len(transfers); i++ {
+		resp.Data[i] = TokenTransferResponse{
+			TxHash:      transfers[i].TxHash,
+			BlockHeight: transfers[i].BlockHeight,
+			Timestamp:   transfers[i].Timestamp.Format(time.RFC3339),
+			LogIndex:    transfers[i].LogIndex,
+			From:        transfers[i].From,
+			To:          transfers[i].To,
+			Amount:      transfers[i].Amount,
+		}
+	}
+
+	putTotalHeader(c, resp.Total)
+	c.JSON(http.StatusOK, resp)
+}
+
+// getListParameters checks the offset, limit and channel in request. The response is written if it fails.
+func getListParameters(c *gin.Context, apiError string) (int, int, string, bool) {
+	offset, limit, err := utility.GetOffsetListFromRequest(c)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return 0, 0, "", false
+	}
+
+	channelName := c.Query(constants.RequestQueryChannel)
+	if channelName == "" {
+		c.Status(http.StatusBadRequest)
+		return 0, 0, "", false
+	}
+
+	// If user queried channel as PK, then get the real name of channel.
+	channelName, err = db.ConvertPKCHtoChannelName(channelName)
+	if err != nil {
+		message := isaacerror.GetAPIError(apiError, err.Error())
+		c.JSON(http.StatusBadRequest, message)
+		return 0, 0, "", false
+	}
+
+	return offset, limit, channelName, true
+}
+
+// putTotalHeader puts total information.
+func putTotalHeader(c *gin.Context, total int) {
+	bytes := "bytes 0-" + strconv.Itoa(total) + "/" + strconv.Itoa(total)
+	c.Header(constants.HTTPHeaderContentRange, bytes)
+	c.Header(constants.HTTPHeaderXTotalCount, strconv.Itoa(total))
+}
@@ -0,0 +1,130 @@
+package tokens
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/go-playground/assert.v1"
+	"motherbear/backend/constants"
+	"motherbear/backend/polarbear"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const tokenAddress = "cx54d95fee187faaea03cee908f50623c8381179d0"
+
+// generateTestTokenInDB generates the token transferred to a new holder in every block.
+func generateTestTokenInDB(channelName string, height int) error {
+	token := polarbear.Token{Channel: channelName, Address: tokenAddress, FirstHeight: 1, FirstTxHash: "0x1"}
+	if err := polarbear.Database().Save(&token).Error; err != nil {
+		return err
+	}
+
+	for h := 1; h <= height; h++ {
+		holder := "hx" + strconv.FormatInt(int64(h), 16)
+		transfer := polarbear.TokenTransfer{
+			Channel:      channelName,
+			TokenAddress: tokenAddress,
+			TxHash:       "0x" + strconv.FormatInt(int64(h), 16),
+			BlockHeight:  int64(h),
+			Timestamp:    time.Now(),
+			From:         "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+			To:           holder,
+			Amount:       strconv.Itoa(h),
+		}
+		if err := polarbear.Database().Save(&transfer).Error; err != nil {
+			return err
+		}
+
+		balance := polarbear.TokenBalance{
+			Channel:      channelName,
+			TokenAddress: tokenAddress,
+			Holder:       holder,
+			Balance:      strconv.Itoa(h),
+		}
+		if err := polarbear.Database().Save(&balance).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setup() {
+	// Init polarbear module.
+	_ = polarbear.Init(constants.DBTypeSqlite3, ":memory:")
+
+	// Generate pseudo test data.
+	_ = generateTestTokenInDB("channel1", 12)
+}
+
+func request(router *gin.Engine, url string, out interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	request, _ := http.NewRequest(constants.HTTPMethodGET, url, nil)
+	q := request.URL.Query()
+	q.Add("limit", "10")
+	q.Add("offset", "0")
+	q.Add("channel", "channel1")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	_ = json.Unmarshal(w.Body.Bytes(), out)
+	return w
+}
+
+func TestGetHandlerList(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.TokenGETListAPIURL, GetHandlerList)
+
+	var resultList TokenResponseList
+	w := request(router, constants.TokenGETListAPIURL, &resultList)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 1)
+	assert.Equal(t, resultList.Data[0].Address, tokenAddress)
+	assert.Equal(t, resultList.Data[0].TransferCount, int64(12))
+	assert.Equal(t, resultList.Data[0].HolderCount, int64(12))
+}
+
+func TestGetHandlerHolders(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.TokenHoldersGETAPIURL, GetHandlerHolders)
+
+	var resultList TokenHolderResponseList
+	w := request(router, constants.TokenAPIBaseURL+"/"+tokenAddress+"/holders", &resultList)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 12)
+	assert.Equal(t, len(resultList.Data), 10)
+	assert.Equal(t, strconv.Itoa(resultList.Total), w.Header().Get("X-Total-Count"))
+	assert.Equal(t, resultList.Data[0].Holder, "hxc")
+	assert.Equal(t, resultList.Data[0].Balance, "12")
+}
+
+func TestGetHandlerTransfers(t *testing.T) {
+	setup()
+
+	router := gin.Default()
+	router.GET(constants.TokenTransfersGETAPIURL, GetHandlerTransfers)
+
+	var resultList TokenTransferResponseList
+	w := request(router, constants.TokenAPIBaseURL+"/"+tokenAddress+"/transfers", &resultList)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 12)
+	assert.Equal(t, len(resultList.Data), 10)
+	assert.Equal(t, resultList.Data[0].BlockHeight, int64(12))
+	assert.Equal(t, resultList.Data[0].Amount, "12")
+
+	// No transfer of the other token.
+	resultList = TokenTransferResponseList{}
+	w = request(router, constants.TokenAPIBaseURL+"/cx0000000000000000000000000000000000000002/transfers", &resultList)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, resultList.Total, 0)
+}
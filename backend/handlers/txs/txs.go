@@ -26,10 +26,20 @@ type TxResponse struct {
 	From        string `json:"from"  example:"hx5d91dee6102ead2aca60256cf33ebf9aab102c82"`
 	To          string `json:"to"  example:"cx54d95fee187faaea03cee908f50623c8381179d0" `
 	BlockHeight int64  `json:"blockHeight"  example:"124" `
+	Value       string `json:"value" example:"1000000000000000000" ` // In loop.
 	Data        string `json:"data"  example:"{ 'method': 'make' }" `
+
+	// Result of transaction.
+	StepUsed       int64  `json:"stepUsed" example:"100000" `
+	StepPrice      int64  `json:"stepPrice" example:"10000000000" `
+	Fee            string `json:"fee" example:"1000000000000000" ` // In loop.
+	ScoreAddress   string `json:"scoreAddress" example:"cx54d95fee187faaea03cee908f50623c8381179d0" `
+	FailureCode    int64  `json:"failureCode" example:"32" `
+	FailureMessage string `json:"failureMessage" example:"Out of step: cumulativeStepUsed" `
+	LogsBloom      string `json:"logsBloom" example:"0x00000000" `
 }
 
-var allowTransactionStatus = []string{"Success", "Failure"}
+var allowTransactionStatus = []string{polarbear.TxStatusSuccess, polarbear.TxStatusFailure, polarbear.TxStatusPending}
 
 // GetHandlerList godoc
 // @Tags Transactions
@@ -41,7 +51,7 @@ var allowTransactionStatus = []string{"Success", "Failure"}
 // @Param channel query string  true "Channel to query. Can be channel name or PK of channel."
 // @Param limit query integer true "Identify the number of results returned from a result set."
 // @Param offset query integer  true "Identify the starting point to return data from a result set."
-// @Param status query string false "'status' be used to search transaction. The kind of 'status' is 'success' and 'failure'."
+// @Param status query string false "'status' be used to search transaction. The kind of 'status' is 'success', 'failure' and 'pending'."
 // @Param blockHeight query string false "'blockHeight' be used to search transaction belong blockHeight."
 // @Param from query string false "'from' be used to search timestamp. Used with 'to'."
 // @Param to query string false "'to' be used to search timestamp. Used with 'from'."
@@ -68,7 +78,7 @@ func GetHandlerList(c *gin.Context) {
 	}
 
 	// Get search items in http query.
-	txSearch, err := getTxSearchItems(c)
+	txSearch, err := GetTxSearchItems(c)
 	if err != nil {
 		internalError := err.Error()
 		logger.Error(internalError)
@@ -103,7 +113,7 @@ func GetHandlerList(c *gin.Context) {
 	resp.Total = int(count)
 
 	for i := 0; i < len(txInCh); i++ {
-		convertPbTxToTxResponse(&txInCh[i], &resp.Data[i])
+		ConvertPbTxToTxResponse(&txInCh[i], &resp.Data[i])
 	}
 
 	// Put total information.
@@ -115,7 +125,8 @@ func GetHandlerList(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-func getTxSearchItems(c *gin.Context) (polarbear.TxSearch, error) {
+// GetTxSearchItems gets the search items of Txs in the query of request.
+func GetTxSearchItems(c *gin.Context) (polarbear.TxSearch, error) {
 	txSearch := polarbear.TxSearch{
 		Status:      "",
 		BlockHeight: -1,
@@ -175,7 +186,8 @@ func getTxSearchItems(c *gin.Context) (polarbear.TxSearch, error) {
 	return txSearch, nil
 }
 
-func convertPbTxToTxResponse(resp *polarbear.Tx, out *TxResponse) {
+// ConvertPbTxToTxResponse converts Tx in DB into the response of Tx API.
+func ConvertPbTxToTxResponse(resp *polarbear.Tx, out *TxResponse) {
 	out.TxHash = resp.TxHash
 	out.Data = resp.Data
 	out.From = resp.From
@@ -183,6 +195,15 @@ func convertPbTxToTxResponse(resp *polarbear.Tx, out *TxResponse) {
 	out.Timestamp = resp.Timestamp.Format(time.RFC3339)
 	out.Status = resp.Status
 	out.BlockHeight = resp.BlockHeight
+	out.Value = resp.Value
+
+	out.StepUsed = resp.Result.StepUsed
+	out.StepPrice = resp.Result.StepPrice
+	out.Fee = resp.Result.Fee
+	out.ScoreAddress = resp.Result.ScoreAddress
+	out.FailureCode = resp.Result.FailureCode
+	out.FailureMessage = resp.Result.FailureMessage
+	out.LogsBloom = resp.Result.LogsBloom
 }
 
 // GetHandler godoc
@@ -230,7 +251,7 @@ func GetHandler(c *gin.Context) {
 	}
 
 	var resp TxResponse
-	convertPbTxToTxResponse(&txInChannel, &resp)
+	ConvertPbTxToTxResponse(&txInChannel, &resp)
 	// Return body.
 	c.JSON(http.StatusOK, resp)
 
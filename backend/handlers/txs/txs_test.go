@@ -185,3 +185,62 @@ func TestGetHandlerList3(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Equal(t, isaacerror.ErrorFailToQueryTxList, err.Errors[0].UserMessage)
 }
+
+// Test to get the result of transaction.
+func TestGetHandlerTxResult(t *testing.T) {
+	setup()
+	defer tearDown()
+
+	// Add the failed transaction with result.
+	txHash := "0xb903239f8543d04b5dc1ba6579132b143087c68db1b2168786408fcbce568238"
+	block := polarbear.Block{
+		Channel:     "channel2",
+		BlockHeight: 101,
+		BlockHash:   "0x9a1cf8fbc5e5e0dc64a6a36bc0b6fe0bbde1e43e4d1bcbd4a22c2bb4ab0e5c41",
+		Txs: []polarbear.Tx{
+			{
+				TxHash:      txHash,
+				Channel:     "channel2",
+				Status:      "Failure",
+				BlockHeight: 101,
+				Timestamp:   time.Now(),
+				Result: polarbear.TxResult{
+					TxHash:         txHash,
+					Channel:        "channel2",
+					BlockHeight:    101,
+					StepUsed:       189000,
+					StepPrice:      10000000000,
+					Fee:            "1890000000000000",
+					ScoreAddress:   "cx54d95fee187faaea03cee908f50623c8381179d0",
+					FailureCode:    32,
+					FailureMessage: "Out of balance",
+				},
+			},
+		},
+	}
+	_ = polarbear.Database().Save(&block).Error
+
+	router := gin.Default()
+	router.GET(constants.TxGETAPIURL, GetHandler)
+	w := httptest.NewRecorder()
+
+	request, _ := http.NewRequest(
+		constants.HTTPMethodGET,
+		constants.TxAPIBaseURL+"/"+txHash,
+		nil)
+	q := request.URL.Query()
+	q.Add("channel", "channel2")
+	request.URL.RawQuery = q.Encode()
+	router.ServeHTTP(w, request)
+
+	var resultBody TxResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resultBody)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, resultBody.Status, "Failure")
+	assert.Equal(t, resultBody.StepUsed, int64(189000))
+	assert.Equal(t, resultBody.StepPrice, int64(10000000000))
+	assert.Equal(t, resultBody.Fee, "1890000000000000")
+	assert.Equal(t, resultBody.ScoreAddress, "cx54d95fee187faaea03cee908f50623c8381179d0")
+	assert.Equal(t, resultBody.FailureCode, int64(32))
+	assert.Equal(t, resultBody.FailureMessage, "Out of balance")
+}
@@ -55,6 +55,50 @@ const ErrorFailToQueryBlockList = "ErrorFailToQueryBlockList"
 const ErrorFailToQueryTxList = "ErrorFailToQueryTxList"
 const ErrorFailToQueryTx = "ErrorFailToQueryTx"
 
+// Events
+const ErrorFailToQueryEventList = "ErrorFailToQueryEventList"
+
+// Addresses
+const ErrorFailToQueryAddress = "ErrorFailToQueryAddress"
+
+// Contracts
+const ErrorFailToQueryContractList = "ErrorFailToQueryContractList"
+const ErrorFailToQueryContract = "ErrorFailToQueryContract"
+
+// Stats
+const ErrorFailToQueryVolumeStats = "ErrorFailToQueryVolumeStats"
+
+// Tokens
+const ErrorFailToQueryTokenList = "ErrorFailToQueryTokenList"
+const ErrorFailToQueryTokenHolders = "ErrorFailToQueryTokenHolders"
+const ErrorFailToQueryTokenTransfers = "ErrorFailToQueryTokenTransfers"
+
+// Quarantine
+const ErrorFailToQueryQuarantinedBlockList = "ErrorFailToQueryQuarantinedBlockList"
+const ErrorFailToQueryQuarantinedBlock = "ErrorFailToQueryQuarantinedBlock"
+const ErrorFailToRetryQuarantinedBlock = "ErrorFailToRetryQuarantinedBlock"
+
+// Retention
+const ErrorFailToQueryRetention = "ErrorFailToQueryRetention"
+
+// Export
+const ErrorFailToExportData = "ErrorFailToExportData"
+
+// Import
+const ErrorFailToImportBlocks = "ErrorFailToImportBlocks"
+
+// Crawler
+const ErrorFailToQueryCrawlerStatus = "ErrorFailToQueryCrawlerStatus"
+const ErrorFailToControlCrawler = "ErrorFailToControlCrawler"
+const ErrorFailToQueryCrawlJobList = "ErrorFailToQueryCrawlJobList"
+const ErrorFailToQueryCrawlJob = "ErrorFailToQueryCrawlJob"
+const ErrorFailToStartCrawlJob = "ErrorFailToStartCrawlJob"
+const ErrorFailToCancelCrawlJob = "ErrorFailToCancelCrawlJob"
+
+// Search
+const ErrorInvalidSearchQuery = "ErrorInvalidSearchQuery"
+const ErrorFailToSearch = "ErrorFailToSearch"
+
 // Data
 const ErrorNotSupportedDataName = "ErrorNotSupportedDataName"
 const ErrorFailToGetLoginLogoImage = "ErrorFailToGetLoginLogoImage"
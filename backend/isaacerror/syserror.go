@@ -80,13 +80,54 @@ var (
 	SysErrFailToQueryBlockInChannel  = errors.New("Fail to query the block in channel from DB.  ")
 	SysErrFailToQueryTxInChannel     = errors.New("Fail to query the Tx in channel from DB. ")
 	SysErrFailToGetBlockData         = errors.New("Cannot get the block by height.")
+	SysErrFailToParseBlockData       = errors.New("Cannot parse the block. Unknown block format.")
 	SysErrInvalidTimeSearchCondition = errors.New("Invalid time search condition, Both 'from/to' must be present.")
+	SysErrFailToDeleteBlockData      = errors.New("Fail to delete the blocks in channel from DB. ")
+	SysErrFailToRepairChainReorg     = errors.New("Fail to repair the reorganized blocks in channel. ")
+	SysErrFailToUpdateCrawlState     = errors.New("Fail to update the crawl state in channel. ")
+	SysErrFailToQueryCrawlState      = errors.New("Fail to query the crawl state in channel. ")
+	SysErrNoAvailableNodeInChannel   = errors.New("No available node in channel. Every node is failing.")
+	SysErrFailToGetLastBlockHeight   = errors.New("Cannot get the last block height.")
+	SysErrFailToGetTxStatus          = errors.New("Cannot get the Tx status.")
+	SysErrFailToQueryEventLogs       = errors.New("Fail to query the event logs in channel from DB. ")
+	SysErrFailToQueryAddress         = errors.New("Fail to query the address in channel from DB. ")
+	SysErrFailToQueryContracts       = errors.New("Fail to query the contracts in channel from DB. ")
+	SysErrFailToQueryVolumeStats     = errors.New("Fail to query the volume statistics in channel from DB. ")
+	SysErrFailToQueryTokens          = errors.New("Fail to query the tokens in channel from DB. ")
+	SysErrFailToQueryQuarantine      = errors.New("Fail to query the quarantined blocks in channel from DB. ")
+	SysErrNoQuarantinedBlock         = errors.New("No quarantined block at the height in channel.")
+	SysErrFailToSubscribeBlocks      = errors.New("Cannot subscribe the blocks through WebSocket.")
+	SysErrFailToQueryRetention       = errors.New("Fail to query the retention in channel from DB. ")
+	SysErrFailToExportData           = errors.New("Fail to export the data from DB. ")
+	SysErrNotSupportedExportFormat   = errors.New("Not supported export format.")
+	SysErrFailToImportBlocks         = errors.New("Fail to import the blocks in channel. ")
+	SysErrFailToImportTxResults      = errors.New("Fail to read the Tx results to import. ")
+	SysErrNoTxResultInDump           = errors.New("No result of Tx in the dump of Tx results.")
+	SysErrConflictedBlockHash        = errors.New("Another block is already stored at the height.")
+	SysErrFailToQueryCrawlerStatus   = errors.New("Fail to query the crawler status in channel. ")
+	SysErrFailToControlCrawler       = errors.New("Fail to pause or resume crawling in channel. ")
+	SysErrFailToQueryCrawlJobs       = errors.New("Fail to query the crawl jobs from DB. ")
+	SysErrNoCrawlJob                 = errors.New("No crawl job of the ID.")
+	SysErrInvalidCrawlJob            = errors.New("Invalid kind or height range of crawl job.")
+	SysErrCrawlJobRunning            = errors.New("Another crawl job is running in channel.")
+	SysErrCrawlJobNotRunning         = errors.New("The crawl job is not running.")
+	SysErrCrawlJobInterrupted        = errors.New("The crawl job is interrupted.")
+	SysErrFailToGetTxResult          = errors.New("Fail to get the result of Tx from nodes.")
+	SysErrFailToQueryPendingTxs      = errors.New("Fail to query the pending Txs in channel from DB. ")
+	SysErrCrawlerStopped             = errors.New("The crawler is stopped.")
+	SysErrCrawlSuspended             = errors.New("Crawling in channel is paused or disabled, or a crawl job is running.")
+	SysErrInvalidSearchQuery         = errors.New("Not block height, hash, address or contract to search.")
+	SysErrFailToSearch               = errors.New("Fail to search in channel from DB. ")
 
 	// Data error.
 	SysErrInvalidImageFileExtension      = errors.New("Invalid image file extension.")
 	SysErrNotSupportedImageFileExtension = errors.New("Not supported image file extension.")
 	SysErrFailToGetLoginLogoImage        = errors.New("Fail to get login logo image.")
 	SysErrNotSupportedDataName           = errors.New("Not Supported Data name.")
+	SysErrNotSupportedDBType             = errors.New("Not supported DB type.")
+	SysErrDBDriverNotLinked              = errors.New("The driver of DB type is not linked in the binary.")
+	SysErrInvalidMigrationOrder          = errors.New("The migrations are not in the order of version.")
+	SysErrNewerSchemaVersion             = errors.New("The schema version of DB is newer than the migrations.")
 
 	// Peer Symptom error.
 	SysErrFailToQueryPeerSymptom = errors.New("Fail to query the symptom in peer from DB.  ")
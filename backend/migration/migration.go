@@ -0,0 +1,146 @@
+package migration
+
+import (
+	"fmt"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration changes the schema of DB from the version before to the version.
+// The DDL of mysql is not rolled back, so Up should be safe to run again after it fails in the middle.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(database *gorm.DB) error
+}
+
+// SchemaMigration is the record of the migration applied.
+type SchemaMigration struct {
+	Version     int    `gorm:"primary_key;auto_increment:false"`
+	Description string `gorm:"type:VARCHAR(256)"`
+	AppliedAt   time.Time
+}
+
+// Migrator applies the migrations in the order of version, and records the versions applied in the table.
+// The migrations released should not be changed. Append the new one with the next version.
+type Migrator struct {
+	Table      string
+	Migrations []Migration
+}
+
+// Version returns the schema version of DB. 0 if no migration applied.
+func (m *Migrator) Version(database *gorm.DB) (int, error) {
+	if !database.HasTable(m.Table) {
+		return 0, nil
+	}
+
+	var versions []int
+	if err := database.Table(m.Table).Order("version desc").Limit(1).Pluck("version", &versions).Error; err != nil {
+		return -1, err
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	return versions[0], nil
+}
+
+// Pending returns the migrations not applied to DB yet, in the order of version.
+func (m *Migrator) Pending(database *gorm.DB) ([]Migration, error) {
+	for i := range m.Migrations {
+		if m.Migrations[i].Version <= 0 || (i > 0 && m.Migrations[i].Version <= m.Migrations[i-1].Version) {
+			return nil, isaacerror.SysErrInvalidMigrationOrder
+		}
+	}
+
+	version, err := m.Version(database)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Migrations) != 0 && version > m.Migrations[len(m.Migrations)-1].Version {
+		return nil, isaacerror.SysErrNewerSchemaVersion
+	}
+
+	pending := make([]Migration, 0)
+	for _, migration := range m.Migrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations to DB, and returns them. Nothing is applied in dry run.
+// It stops at the migration failed, and the versions before remain applied.
+func (m *Migrator) Up(database *gorm.DB, dryRun bool) ([]Migration, error) {
+	pending, err := m.Pending(database)
+	if err != nil || dryRun || len(pending) == 0 {
+		return pending, err
+	}
+
+	if !database.HasTable(m.Table) {
+		if err := database.Table(m.Table).CreateTable(&SchemaMigration{}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	for i, migration := range pending {
+		logger.Infof("Migrate %s to version %d. %s", m.Table, migration.Version, migration.Description)
+		if err := migration.Up(database); err != nil {
+			return pending[:i], fmt.Errorf("migration %d failed: %s", migration.Version, err)
+		}
+
+		record := SchemaMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		if err := database.Table(m.Table).Create(&record).Error; err != nil {
+			return pending[:i], err
+		}
+	}
+
+	return pending, nil
+}
+
+// AddColumnIfNotExist adds the column of the field to the table of model.
+func AddColumnIfNotExist(database *gorm.DB, model interface{}, fieldName string) error {
+	scope := database.NewScope(model)
+	field, ok := scope.FieldByName(fieldName)
+	if !ok {
+		return fmt.Errorf("no field %s in %s", fieldName, scope.TableName())
+	}
+	if database.Dialect().HasColumn(scope.TableName(), field.DBName) {
+		return nil
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s ADD %s %s", scope.QuotedTableName(),
+		scope.Quote(field.DBName), database.Dialect().DataTypeOf(field.StructField))
+	return database.Exec(query).Error
+}
+
+// AddIndexIfNotExist adds the index of the columns to the table of model.
+func AddIndexIfNotExist(database *gorm.DB, model interface{}, indexName string, columns ...string) error {
+	tableName := database.NewScope(model).TableName()
+	if database.Dialect().HasIndex(tableName, indexName) {
+		return nil
+	}
+
+	return database.Model(model).AddIndex(indexName, columns...).Error
+}
+
+// CreateTablesIfNotExist creates the tables of models not exist.
+func CreateTablesIfNotExist(database *gorm.DB, models ...interface{}) error {
+	for _, model := range models {
+		if database.HasTable(model) {
+			continue
+		}
+		if err := database.CreateTable(model).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
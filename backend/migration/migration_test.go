@@ -0,0 +1,100 @@
+package migration
+
+import (
+	"errors"
+	"motherbear/backend/isaacerror"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+type testRecord struct {
+	gorm.Model
+	Name  string `gorm:"type:VARCHAR(40)"`
+	Value string `gorm:"type:VARCHAR(40)"`
+}
+
+type testRecordV1 struct {
+	gorm.Model
+	Name string `gorm:"type:VARCHAR(40)"`
+}
+
+func (testRecordV1) TableName() string {
+	return "test_records"
+}
+
+func TestMigrator(t *testing.T) {
+	database, err := gorm.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	applied := 0
+	migrator := Migrator{
+		Table: "test_schema_migrations",
+		Migrations: []Migration{
+			{Version: 1, Description: "Create.", Up: func(database *gorm.DB) error {
+				applied++
+				return CreateTablesIfNotExist(database, &testRecordV1{})
+			}},
+			{Version: 2, Description: "Add value.", Up: func(database *gorm.DB) error {
+				applied++
+				if err := AddColumnIfNotExist(database, &testRecord{}, "Value"); err != nil {
+					return err
+				}
+				return AddIndexIfNotExist(database, &testRecord{}, "idx_test_records_value", "value")
+			}},
+		},
+	}
+
+	version, err := migrator.Version(database)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version, 0)
+
+	// Nothing applied in dry run.
+	pending, err := migrator.Up(database, true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 2)
+	assert.Equal(t, applied, 0)
+	assert.Equal(t, database.HasTable(&testRecord{}), false)
+
+	pending, err = migrator.Up(database, false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 2)
+	assert.Equal(t, applied, 2)
+	assert.Equal(t, database.Create(&testRecord{Name: "a", Value: "b"}).Error, nil)
+	assert.Equal(t, database.Dialect().HasIndex("test_records", "idx_test_records_value"), true)
+
+	version, _ = migrator.Version(database)
+	assert.Equal(t, version, 2)
+
+	// Applied once.
+	pending, err = migrator.Up(database, false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), 0)
+	assert.Equal(t, applied, 2)
+
+	// The migration failed stops the next ones.
+	failure := errors.New("failure")
+	migrator.Migrations = append(migrator.Migrations,
+		Migration{Version: 3, Description: "Fail.", Up: func(*gorm.DB) error { return failure }},
+		Migration{Version: 4, Description: "Never.", Up: func(*gorm.DB) error { return nil }})
+	pending, err = migrator.Up(database, false)
+	assert.NotEqual(t, err, nil)
+	assert.Equal(t, len(pending), 0)
+	version, _ = migrator.Version(database)
+	assert.Equal(t, version, 2)
+
+	// DB newer than the migrations.
+	migrator.Migrations = migrator.Migrations[:1]
+	_, err = migrator.Pending(database)
+	assert.Equal(t, err, isaacerror.SysErrNewerSchemaVersion)
+
+	// Migrations not in order.
+	migrator.Migrations = []Migration{{Version: 2}, {Version: 1}}
+	_, err = migrator.Pending(database)
+	assert.Equal(t, err, isaacerror.SysErrInvalidMigrationOrder)
+}
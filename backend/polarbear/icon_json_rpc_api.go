@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"motherbear/backend/isaacerror"
 	"motherbear/backend/logger"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -12,6 +13,15 @@ import (
 	"github.com/ybbus/jsonrpc"
 )
 
+// The timeout of request to node. The dead node should not stall the crawling.
+const nodeRequestTimeout = 10 * time.Second
+
+func newRPCClient(apiURI string) jsonrpc.RPCClient {
+	return jsonrpc.NewClientWithOpts(apiURI, &jsonrpc.RPCClientOpts{
+		HTTPClient: &http.Client{Timeout: nodeRequestTimeout},
+	})
+}
+
 func generalJSONRPCReq(out interface{}, URI string,
 	channelName string, method string, params ...interface{}) error {
 	apiURI := URI + "/api/v3"
@@ -22,7 +32,7 @@ func generalJSONRPCReq(out interface{}, URI string,
 		}
 	}
 
-	rpcClient := jsonrpc.NewClient(apiURI)
+	rpcClient := newRPCClient(apiURI)
 
 	var err error
 	if len(params) == 0 {
@@ -41,36 +51,29 @@ func getLastBlockHeight(URI string, channelName string) (int64, error) {
 	var body map[string]interface{}
 	err := generalJSONRPCReq(&body, URI, channelName, "icx_getLastBlock")
 	if err == nil {
-		height := int64(body["height"].(float64))
-		return height, err
+		// Height is number in the block 0.1a, and hex string in the block 0.3 or later.
+		height, ok := body["height"]
+		if !ok || height == nil {
+			return -1, isaacerror.SysErrFailToGetLastBlockHeight
+		}
+		return parseHexInt64(height), err
 	} else {
 		return -1, err
 	}
 }
 
-
-const countOfTrial = 10
 const durationInMiliSec = 500
 
-func getTxStatus(URI string, channelName string, txHash string) (string, error) {
-
-	var txStatus string
-	var err error
-	err = isaacerror.SysUnknownError
-	for i := 0; i< countOfTrial; i++ {
-		txStatus, err =_getTxStatus(URI, channelName, txHash)
-		if err == nil {
-			return  txStatus, err
-		}else{
-			time.Sleep( durationInMiliSec * time.Microsecond)
-		}
+// parseTxStatus converts the status in the Tx result to TxStatusSuccess or TxStatusFailure.
+func parseTxStatus(result map[string]interface{}) string {
+	if result["status"] == "0x1" {
+		return TxStatusSuccess
 	}
-
-	return "", err
+	return TxStatusFailure
 }
 
-
-func _getTxStatus(URI string, channelName string, txHash string) (string, error) {
+// _getTxResult requests the result of Tx.
+func _getTxResult(URI string, channelName string, txHash string) (map[string]interface{}, error) {
 
 	apiURI := URI + "/api/v3"
 	if channelName != "" {
@@ -79,59 +82,45 @@ func _getTxStatus(URI string, channelName string, txHash string) (string, error)
 		}
 	}
 
-	// Try to call JSON RPC.
-	rpcClient := jsonrpc.NewClient(apiURI)
+	// Try to call JSON RPC. The node pool tries the other nodes if there is no response.
+	rpcClient := newRPCClient(apiURI)
 	res, err := rpcClient.Call(
 		"icx_getTransactionResult",
 		map[string]interface{}{
 			"txHash": txHash,
 		})
-
-	// If there are no response, try again.
 	if err != nil {
-		res, err = rpcClient.Call(
-			"icx_getTransactionResult",
-			map[string]interface{}{
-				"txHash": txHash,
-			})
-
-		if err != nil {
-			logger.Fatalln("Fail  to request tx status : ", txHash)
-			return "", err
-		}
+		logger.Errorf("Fail  to request tx status : %s", txHash)
+		return nil, err
 	}
 
 	// Convert res => byte
 	resBytes := new(bytes.Buffer)
 	err = json.NewEncoder(resBytes).Encode(res)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Convert byte to map[string]interface{}
 	var f interface{}
 	err = json.Unmarshal(resBytes.Bytes(), &f)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Convert byte to map[string]interface{}
-	response := f.(map[string]interface{})
-	result := response["result"].(map[string]interface{})
-
-	// Parse status information.
-	var txStatus string
-	if result["status"].(string) == "0x1" {
-		txStatus = "Success"
-	} else {
-		txStatus = "Failure"
+	response, _ := f.(map[string]interface{})
+	if err := newNodeResponseError(response); err != nil {
+		return nil, err
+	}
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		return nil, isaacerror.SysErrFailToGetTxStatus
 	}
 
-	return txStatus, err
+	return result, nil
 }
 
-
-
 func getBlockByHeight(out *map[string]interface{}, URI string,
 	channelName string, height int64) error {
 
@@ -146,7 +135,7 @@ func getBlockByHeight(out *map[string]interface{}, URI string,
 		}
 	}
 
-	rpcClient := jsonrpc.NewClient(apiURI)
+	rpcClient := newRPCClient(apiURI)
 	res, err := rpcClient.Call(
 		"icx_getBlockByHeight",
 		map[string]interface{}{
@@ -154,7 +143,7 @@ func getBlockByHeight(out *map[string]interface{}, URI string,
 		})
 
 	if err != nil {
-		logger.Fatalln("Fail  to request block by height : ", height)
+		logger.Errorf("Fail  to request block by height : %d", height)
 		return err
 	}
 
@@ -162,7 +151,7 @@ func getBlockByHeight(out *map[string]interface{}, URI string,
 	resBytes := new(bytes.Buffer)
 	err = json.NewEncoder(resBytes).Encode(res)
 	if err != nil {
-		logger.Fatalln("Fail  to encode response.  ", res)
+		logger.Errorf("Fail  to encode response.  %v", res)
 		return err
 	}
 
@@ -170,7 +159,7 @@ func getBlockByHeight(out *map[string]interface{}, URI string,
 	var f interface{}
 	err = json.Unmarshal(resBytes.Bytes(), &f)
 	if err != nil {
-		logger.Fatalln("Fail  to decode byte to JSON data.  ", resBytes.Bytes())
+		logger.Errorf("Fail  to decode byte to JSON data.  %s", resBytes.Bytes())
 		return err
 	}
 
@@ -178,6 +167,98 @@ func getBlockByHeight(out *map[string]interface{}, URI string,
 	return err
 }
 
+// The maximum count of requests in a batch request.
+const maxCountOfBatchRequest = 100
+
+// batchJSONRPCReq calls the method with each params in a JSON-RPC 2.0 batch request.
+// Returns the responses in the order of params. Each response has "result" or "error".
+func batchJSONRPCReq(URI string, channelName string, method string,
+	paramsList []map[string]interface{}) ([]map[string]interface{}, error) {
+
+	apiURI := URI + "/api/v3"
+	if channelName != "" {
+		if channelName != "default" {
+			apiURI = apiURI + "/" + channelName
+		}
+	}
+
+	requests := make(jsonrpc.RPCRequests, 0, len(paramsList))
+	for _, params := range paramsList {
+		requests = append(requests, jsonrpc.NewRequest(method, params))
+	}
+
+	rpcClient := newRPCClient(apiURI)
+	res, err := rpcClient.CallBatch(requests)
+	if err != nil {
+		logger.Errorf("Fail  to request %d %s in batch.", len(paramsList), method)
+		return nil, err
+	}
+
+	// Responses may be in any order, so sort them by ID.
+	responseByID := res.AsMap()
+	responses := make([]map[string]interface{}, len(paramsList))
+	for i := range paramsList {
+		r, ok := responseByID[i]
+		if !ok {
+			responses[i] = map[string]interface{}{
+				"error": map[string]interface{}{"message": "No response in batch."},
+			}
+			continue
+		}
+
+		// Convert res => byte => map[string]interface{}
+		resBytes, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(resBytes, &responses[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return responses, nil
+}
+
+// getBlocksByHeights requests the blocks at the heights in a batch request.
+func getBlocksByHeights(URI string, channelName string, heights []int64) ([]map[string]interface{}, error) {
+	paramsList := make([]map[string]interface{}, 0, len(heights))
+	for _, h := range heights {
+		paramsList = append(paramsList, map[string]interface{}{
+			"height": "0x" + strconv.FormatInt(h, 16),
+		})
+	}
+
+	return batchJSONRPCReq(URI, channelName, "icx_getBlockByHeight", paramsList)
+}
+
+// getTxResults requests the result of Txs in a batch request. Returns the result by Tx hash.
+// The Tx which failed to get the result is not in the result.
+func getTxResults(URI string, channelName string, txHashes []string) (map[string]map[string]interface{}, error) {
+	paramsList := make([]map[string]interface{}, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		paramsList = append(paramsList, map[string]interface{}{
+			"txHash": txHash,
+		})
+	}
+
+	responses, err := batchJSONRPCReq(URI, channelName, "icx_getTransactionResult", paramsList)
+	if err != nil {
+		return nil, err
+	}
+
+	txResults := make(map[string]map[string]interface{})
+	for i, response := range responses {
+		result, ok := response["result"].(map[string]interface{})
+		if !ok {
+			logger.Errorf("Fail  to get tx status : %s", txHashes[i])
+			continue
+		}
+		txResults[txHashes[i]] = result
+	}
+
+	return txResults, nil
+}
+
 func getTxByHash(channelName string, URI string, txHash string) error {
 	return nil
 }
@@ -4,10 +4,9 @@ import (
 	"encoding/json"
 	"math/rand"
 	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
 	"motherbear/backend/isaacerror"
 	"motherbear/backend/logger"
-	"motherbear/backend/utility"
-	"runtime"
 	"strconv"
 	"sync"
 	"time"
@@ -22,7 +21,7 @@ type BlockHeaderData struct {
 	Result  BlockResultData `json:"result"`
 }
 
-// BlockResultData is
+// BlockResultData is the block decoded from the block of any version, in the keys of loopchain 0.1a.
 type BlockResultData struct {
 	Version                  string   `json:"version" `
 	PrevBlockHash            string   `json:"prev_block_hash" `
@@ -35,11 +34,12 @@ type BlockResultData struct {
 	Signature                string   `json:"signature" `
 }
 
-// TxData is
+// TxData is the Tx decoded in the block. Timestamp is in decimal, and Data is the object in JSON string.
 type TxData struct {
 	Version   string `json:"version" `
 	From      string `json:"from"`
 	To        string `json:"to"`
+	Value     string `json:"value,omitempty"`
 	StepLimit string `json:"stepLimit"`
 	Timestamp string `json:"timestamp" `
 	NID       string `json:"nid"`
@@ -61,11 +61,20 @@ const (
 var crawlingStatus status
 var scheduler *gocron.Scheduler
 
+var channelCrawlLocks = make(map[string]*sync.Mutex)
+var channelCrawlLocksLock sync.Mutex
+
+// The polling job checks the crawling interval of every channel in the tick.
+const pollingTickInSec = 1
+
+var lastPolledAt = make(map[string]time.Time)
+var lastPolledAtLock sync.Mutex
+
 // Init is initilizing polarbear module.
-func Init(dbType string, dbOption ...string) error {
+func Init(dbType string, dataSource string) error {
 
 	// Init DB file.
-	if InitDB(dbType, dbOption...) == nil {
+	if InitDB(dbType, dataSource) == nil {
 		return isaacerror.SysErrFailToInitDBForPolarbear
 	}
 
@@ -79,135 +88,238 @@ func BeginToCrawl() *gocron.Scheduler {
 		cronJobForEveryChannel(configuration.Conf())
 	}
 
-	// Set interval and begin crawling.
-	var interval uint64
-	interval = uint64(configuration.Conf().Blockchain.CrawlingInterval)
+	// Crawl the missing blocks in background. Jobs in scheduler don't run at the same time.
+	backfillJob := func() {
+		channelNames := []string{}
+		for _, c := range configuration.Conf().Channel {
+			channelNames = append(channelNames, c.Name)
+		}
+		cronJobForBackfill(channelNames)
+	}
+
+	backfillInterval := uint64(configuration.Conf().Blockchain.BackfillInterval)
+	if backfillInterval == 0 {
+		backfillInterval = constants.DefaultBackfillIntervalInSec
+	}
+
+	// Query the result of pending Txs again in background. Each Tx is tried again with the backoff.
+	reconcileJob := func() {
+		channelNames := []string{}
+		for _, c := range configuration.Conf().Channel {
+			channelNames = append(channelNames, c.Name)
+		}
+		cronJobForTxReconcile(channelNames)
+	}
+
+	reconcileInterval := uint64(configuration.Conf().Blockchain.ReconcileInterval)
+	if reconcileInterval == 0 {
+		reconcileInterval = constants.DefaultReconcileIntervalInSec
+	}
 
 	scheduler = gocron.NewScheduler()
-	scheduler.Every(interval).Seconds().Do(job)
+	scheduler.Every(pollingTickInSec).Seconds().Do(job)
+	scheduler.Every(backfillInterval).Seconds().Do(backfillJob)
+	scheduler.Every(reconcileInterval).Seconds().Do(reconcileJob)
+
+	// Prune the old blocks and symptoms in background only if any retention policy is configured.
+	if hasRetention() {
+		pruningInterval := uint64(configuration.Conf().Blockchain.PruningInterval)
+		if pruningInterval == 0 {
+			pruningInterval = constants.DefaultPruningIntervalInSec
+		}
+		pruningJob := func() {
+			channelNames := []string{}
+			for _, c := range configuration.Conf().Channel {
+				channelNames = append(channelNames, c.Name)
+			}
+			cronJobForPruning(channelNames)
+		}
+		scheduler.Every(pruningInterval).Seconds().Do(pruningJob)
+	}
 	scheduler.Start()
 
+	// The workers of each channel crawl the heights sent by polling, block stream, backfill and crawl jobs.
+	channelNames := []string{}
+	for _, c := range configuration.Conf().Channel {
+		channelNames = append(channelNames, c.Name)
+	}
+	startCrawlWorkerPools(channelNames)
+
+	// Polling keeps running to crawl the channel whose block stream is unavailable.
+	// The streams follow the configuration of channels in every tick of polling.
+	if configuration.Conf().Blockchain.Streaming {
+		BeginToStream(channelNames)
+	}
+
 	return scheduler
 }
 
 func StopToCrawl() {
 	logger.Info("Stop crawling block data from loopchain.")
 	scheduler.Clear()
+	StopToStream()
+	stopCrawlWorkerPools()
 }
 
-func unitCrawlAndStoreBlock(nodeIP string, channelName string, height int64) error {
+// lockChannelCrawl locks the crawling of channel. Returns the function to unlock it.
+// The lock is in this process only. The crawl job run by the command in another process is seen by the server
+// through DB at the next check of isCrawlSuspended, but the blocks being crawled by the server are not waited for.
+func lockChannelCrawl(channelName string) func() {
+	channelCrawlLocksLock.Lock()
+	lock, ok := channelCrawlLocks[channelName]
+	if !ok {
+		lock = &sync.Mutex{}
+		channelCrawlLocks[channelName] = lock
+	}
+	channelCrawlLocksLock.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func unitCrawlAndStoreBlock(nodes *nodePool, channelName string, height int64) error {
 
 	// Request block data from node.
 	var blockData map[string]interface{}
-	if err := getBlockByHeight(
-		&blockData,
-		nodeIP,
-		channelName,
-		height); err != nil {
-		logger.Fatalln(err)
+	nodeIP, err := nodes.getBlockByHeight(&blockData, height)
+	if err != nil {
+		logger.Errorf("%s", err)
+		recordCrawlError(channelName, err)
 		return err
 	}
 
+	return storeBlock(blockData, nodeIP, channelName, height)
+}
+
+// batchCrawlAndStoreBlock crawls the blocks at the heights in a batch request, and stores them.
+// The block failed in batch is crawled again alone. Returns the error by failed height.
+func batchCrawlAndStoreBlock(nodes *nodePool, channelName string, heights []int64) map[int64]error {
+	failures := make(map[int64]error)
+
+	responses, nodeIP, err := nodes.getBlocksByHeights(heights)
+	if err != nil {
+		logger.Errorf("Fail to crawl %d blocks in batch in %s. %s", len(heights), channelName, err)
+	}
+
+	for i, h := range heights {
+		if err == nil && responses[i]["result"] != nil {
+			if err := storeBlock(responses[i], nodeIP, channelName, h); err != nil {
+				failures[h] = err
+			}
+			continue
+		}
+
+		if err := unitCrawlAndStoreBlock(nodes, channelName, h); err != nil {
+			failures[h] = err
+		}
+	}
+
+	return failures
+}
+
+// storeBlock adds the block data responded from node into DB.
+func storeBlock(blockData map[string]interface{}, nodeIP string, channelName string, height int64) (err error) {
+	defer func() {
+		if err != nil {
+			recordCrawlError(channelName, err)
+		} else {
+			recordBlockStored(channelName, nodeIP)
+		}
+	}()
+
 	// Put log with block hash.
 	if blockData["result"] == nil {
 		logger.Errorf("Fail to get the block %d in %s", height, channelName)
 		return isaacerror.SysErrFailToGetBlockData
 	}
 
-	result := blockData["result"].(map[string]interface{})
-	logger.Infof("Begin to crawl %d block in %s. %s",
-		height, channelName,
-		utility.AddHexHD(result["block_hash"].(string)))
+	result, ok := blockData["result"].(map[string]interface{})
+	if !ok {
+		logger.Errorf("Fail to get the block %d in %s", height, channelName)
+		return isaacerror.SysErrFailToGetBlockData
+	}
+	parsed, err := parseBlock(result)
+	if err != nil {
+		// Keep the block failed to decode in quarantine, and keep crawling other blocks.
+		logger.Errorf("Fail to decode the block %d in %s. %s", height, channelName, err)
+		if err := quarantineBlock(channelName, height, nodeIP, result, err); err != nil {
+			logger.Errorf("Fail to quarantine the block %d in %s. %s", height, channelName, err)
+		}
+		return err
+	}
+	logger.Infof("Begin to crawl %d block in %s. %s", height, channelName, parsed.hash)
 
 	// Add block data into DB.
 	var blockRecord Block
-	if err := AddBlockRecordFromJSONResponse(
-		blockData,
-		nodeIP,
-		channelName,
-		&blockRecord); err != nil {
+	if err := addBlockRecord(parsed, channelName, txResultsFromNode(nodeIP, channelName), &blockRecord); err != nil {
 		return err
-
 	}
 
 	// Put log with hash.
-	logger.Infof("End to crawl %d block in %s. %s",
-		height, channelName,
-		utility.AddHexHD(result["block_hash"].(string)))
+	logger.Infof("End to crawl %d block in %s. %s", height, channelName, blockRecord.BlockHash)
 	return nil
 }
 
-func random(min, max int) int {
-	rand.Seed(time.Now().Unix())
-	return rand.Intn(max-min) + min
-}
-
-func crawlAndStoreBlock(numCPU int, nodeIPList []string, channelName string,
-	beginBlockheight int64, endBlockHeight int64) error {
+// crawlAndStoreBlock crawls the blocks between the heights with the worker pool of channel.
+func crawlAndStoreBlock(nodes *nodePool, channelName string, beginBlockheight int64, endBlockHeight int64) error {
 	logger.Infof("Crawl block from %d to %d in %s.", beginBlockheight, endBlockHeight, channelName)
 
-	stepToCrawl := int64(2*numCPU + 1)
-	logger.Infof("Step to crawl = %d", stepToCrawl)
+	heights := make([]int64, 0, endBlockHeight-beginBlockheight+1)
+	for h := beginBlockheight; h <= endBlockHeight; h++ {
+		heights = append(heights, h)
+	}
 
-	diffBlockHeight := endBlockHeight - beginBlockheight
+	// Failed heights are recorded in the crawl state, and will be crawled again by backfill.
+	failures := crawlHeightsInPool(nodes, channelName, heights)
 
-	// Do linear crawling if diffBloekcHeight is small because more efficient.
-	if diffBlockHeight < stepToCrawl {
-		for h := beginBlockheight; h <= endBlockHeight; h++ {
-			var  randomIndex int = 0
-			if   len(nodeIPList) != 1{
-				randomIndex = random(0, len(nodeIPList)-1)
-			}
-			err := unitCrawlAndStoreBlock(nodeIPList[randomIndex], channelName, h)
-			if err != nil {
-				return err
-			}
-		}
+	return recordCrawlResult(channelName, beginBlockheight, endBlockHeight, failures)
+}
 
-	} else {
-		// Do pararell crawling.
-		var countCrawledBlock int64 = 0
-		remains := endBlockHeight % stepToCrawl
-
-		for h := beginBlockheight; h <= endBlockHeight-remains; h += stepToCrawl {
-			// Pararellized code block
-			var wg sync.WaitGroup
-			for i := int64(0); i < stepToCrawl; i++ {
-				wg.Add(1)
-				countCrawledBlock++
-				go func(nodeIPList []string, channelName string, height int64) {
-					defer wg.Done()
-					randomIndex := random(0, len(nodeIPList)-1)
-					err := unitCrawlAndStoreBlock(nodeIPList[randomIndex], channelName, height)
-					if err != nil {
-						logger.Errorf("%s", err)
-					}
-				}(nodeIPList, channelName, h+i)
-			}
-			wg.Wait()
-		}
+// crawlHeightsInPool sends the heights into the queue of the worker pool of channel, and returns the error by failed
+// height after they are crawled.
+func crawlHeightsInPool(nodes *nodePool, channelName string, heights []int64) map[int64]error {
+	pool, err := getCrawlWorkerPool(channelName)
+	if err != nil {
+		return failHeights(heights, err)
+	}
+	concurrency := pool.getSize()
+	logger.Infof("Count of workers to crawl = %d", concurrency)
 
-		// If there are some remain block height, do linear crawling.
-		if remains > 0 {
-			begin := endBlockHeight - remains + 1
-			logger.Infof("Remains to crawl from %d to %d", begin, endBlockHeight)
-			for h := begin; h <= endBlockHeight; h++ {
-				randomIndex := random(0, len(nodeIPList)-1)
-				err := unitCrawlAndStoreBlock(nodeIPList[randomIndex], channelName, h)
-				if err != nil {
-					return err
-				}
-			}
-		}
+	// Request several blocks at once in catch-up, but keep every worker busy.
+	countOfBlockInBatch := (len(heights) + concurrency - 1) / concurrency
+	if countOfBlockInBatch > maxCountOfBlockInBatch {
+		countOfBlockInBatch = maxCountOfBlockInBatch
+	}
 
+	batch := newCrawlBatch()
+	for i := 0; i < len(heights); i += countOfBlockInBatch {
+		end := i + countOfBlockInBatch
+		if end > len(heights) {
+			end = len(heights)
+		}
+		if err := pool.push(nodes, batch, heights[i:end]); err != nil {
+			// The pool is stopped. The heights left fail too.
+			batch.fail(failHeights(heights[end:], err))
+			break
+		}
 	}
 
-	return nil
+	return batch.wait()
 }
 
-func crawlBlockchain(channelName string) error {
+// getChannelConf returns the configuration of channel. False if the channel is not in the configuration.
+func getChannelConf(channelName string) (configuration.Channels, bool) {
+	for _, c := range configuration.Conf().Channel {
+		if c.Name == channelName {
+			return c, true
+		}
+	}
+
+	return configuration.Channels{}, false
+}
 
-	// 	// Get node list.
+// getNodeIPList returns IP list of nodes in channel.
+func getNodeIPList(channelName string) []string {
 	nodeIPList := []string{}
 	for _, c := range configuration.Conf().Channel {
 		if c.Name == channelName {
@@ -217,19 +329,70 @@ func crawlBlockchain(channelName string) error {
 		}
 	}
 
-	// Check the block height of channel and start to crawl if it needs.
-	blockHeightInDB := GetCurrentBlockHeightInDB(channelName)
-	blockHeight, _ := getLastBlockHeight(nodeIPList[0], channelName)
+	return nodeIPList
+}
+
+func crawlBlockchain(channelName string) error {
+
+	// Get the nodes of channel, and the last block height of them.
+	nodes := getNodePool(channelName)
+	blockHeight, err := nodes.getLastBlockHeight()
+	if err != nil {
+		return err
+	}
+
+	return crawlBlockchainUpTo(nodes, channelName, blockHeight)
+}
+
+// crawlBlockchainUpTo crawls the blocks from the crawl cursor up to the height in channel.
+// The channel is crawled by one of polling, block stream and backfill at once.
+func crawlBlockchainUpTo(nodes *nodePool, channelName string, blockHeight int64) error {
+	recordChainHeight(channelName, blockHeight)
+
+	// The channel is not crawled while it is paused, or crawled by a crawl job.
+	if isCrawlSuspended(channelName) {
+		logger.Debugf("Crawling is suspended in %s", channelName)
+		return nil
+	}
+
+	unlock := lockChannelCrawl(channelName)
+	defer unlock()
+
+	// Check again, crawling may be paused or a crawl job may begin while waiting for the lock.
+	if isCrawlSuspended(channelName) {
+		logger.Debugf("Crawling is suspended in %s", channelName)
+		return nil
+	}
+
+	recordCrawling(channelName, true)
+	defer recordCrawling(channelName, false)
+
+	// Check the crawl cursor of channel and start to crawl if it needs.
+	crawlCursor := GetCrawlCursor(channelName)
+
+	// The blocks below the start height are not crawled in the new channel.
+	if startHeight := getCrawlStartHeight(channelName); startHeight > 0 && crawlCursor < startHeight-1 {
+		crawlCursor = startHeight - 1
+	}
 
 	//	If block height in local is lower then block height online, then  start to crawl.
-	numCPU := runtime.NumCPU()
+	if crawlCursor < blockHeight {
+		if err := crawlAndStoreBlock(nodes, channelName, crawlCursor+1, blockHeight); err != nil {
+			recordCrawlError(channelName, err)
+			return err
+		}
 
-	if blockHeightInDB < blockHeight {
-		return crawlAndStoreBlock(numCPU, nodeIPList, channelName, blockHeightInDB+1, blockHeight)
+		// Verify the new blocks are linked to the last block in DB, and each other.
+		if err := verifyAndRepairChain(nodes, channelName, crawlCursor, blockHeight); err != nil {
+			recordCrawlError(channelName, err)
+			return err
+		}
 	} else {
 		logger.Debugf("Don't need to crawl in %s", channelName)
-		return nil
 	}
+
+	recordCrawlSuccess(channelName)
+	return nil
 }
 
 func cronJobForEveryChannel(conf *configuration.Configuration) {
@@ -237,10 +400,27 @@ func cronJobForEveryChannel(conf *configuration.Configuration) {
 	if crawlingStatus == readyToStarCrawling {
 		crawlingStatus = crawling
 
+		// Follow the channels added, removed, enabled or disabled in the configuration.
+		streamingChannelNames := []string{}
+		if conf.Blockchain.Streaming {
+			for _, c := range conf.Channel {
+				if !c.CrawlDisabled {
+					streamingChannelNames = append(streamingChannelNames, c.Name)
+				}
+			}
+		}
+		syncBlockStreams(streamingChannelNames)
+
 		for _, c := range conf.Channel {
+			// The channel in streaming is crawled by the notifications of new block.
+			if c.CrawlDisabled || isStreaming(c.Name) || !isPollingDue(c, time.Now()) {
+				continue
+			}
+
+			// Keep crawling other channels. The channel will be crawled again in next time.
 			err := crawlBlockchain(c.Name)
 			if err != nil {
-				panic(err)
+				logger.Errorf("Fail to crawl blocks in %s. %s", c.Name, err)
 			}
 		}
 
@@ -251,6 +431,26 @@ func cronJobForEveryChannel(conf *configuration.Configuration) {
 
 }
 
+// isPollingDue checks the crawling interval of channel has passed since it was polled last, and records the time.
+// The interval of blockchain is used if the channel has no interval.
+func isPollingDue(c configuration.Channels, now time.Time) bool {
+	interval := c.CrawlingInterval
+	if interval <= 0 {
+		interval = configuration.Conf().Blockchain.CrawlingInterval
+	}
+
+	lastPolledAtLock.Lock()
+	defer lastPolledAtLock.Unlock()
+
+	// Tolerate the delay of tick.
+	last, ok := lastPolledAt[c.Name]
+	if ok && now.Sub(last) < time.Duration(interval)*time.Second-pollingTickInSec*time.Second/2 {
+		return false
+	}
+	lastPolledAt[c.Name] = now
+	return true
+}
+
 func generateRandString(n int) string {
 	var letterRunes = []rune("abcdef0123456789")
 	b := make([]rune, n)
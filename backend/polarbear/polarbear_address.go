@@ -0,0 +1,139 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+)
+
+// Direction of Txs of address.
+const (
+	AddressDirectionSent     = "sent"
+	AddressDirectionReceived = "received"
+)
+
+// AddressSummary is the activity summary of address in channel.
+type AddressSummary struct {
+	Address           string
+	FirstSeen         time.Time
+	LastSeen          time.Time
+	SentCount         int64
+	ReceivedCount     int64
+	CounterpartyCount int64
+}
+
+// addressCondition returns the condition of Txs sent or received by address in the direction.
+func addressCondition(direction string) (string, error) {
+	from := Database().Dialect().Quote("from")
+	to := Database().Dialect().Quote("to")
+
+	switch direction {
+	case AddressDirectionSent:
+		return from + " = ?", nil
+	case AddressDirectionReceived:
+		return to + " = ?", nil
+	case "":
+		return "(" + from + " = ? OR " + to + " = ?)", nil
+	}
+
+	return "", isaacerror.SysErrInvalidParameter
+}
+
+// QueryAddressSummaryInChannel queries the activity summary of address in channel.
+func QueryAddressSummaryInChannel(channelName string, address string, out *AddressSummary) error {
+
+	// Check arguments.
+	if channelName == "" || address == "" {
+		logger.Errorf("Arguments is wrong. address:%s, channelName:%s", address, channelName)
+		return isaacerror.SysErrFailToQueryAddress
+	}
+
+	*out = AddressSummary{Address: address}
+
+	if err := Database().Model(&Tx{}).Where(&Tx{Channel: channelName, From: address}).Count(
+		&out.SentCount).Error; err != nil {
+		return isaacerror.SysErrFailToQueryAddress
+	}
+	if err := Database().Model(&Tx{}).Where(&Tx{Channel: channelName, To: address}).Count(
+		&out.ReceivedCount).Error; err != nil {
+		return isaacerror.SysErrFailToQueryAddress
+	}
+	if out.SentCount == 0 && out.ReceivedCount == 0 {
+		return nil
+	}
+
+	// First and last seen.
+	condition, _ := addressCondition("")
+	var first, last Tx
+	if err := Database().Where("channel = ?", channelName).Where(condition, address, address).Order(
+		"timestamp asc").First(&first).Error; err != nil {
+		return isaacerror.SysErrFailToQueryAddress
+	}
+	if err := Database().Where("channel = ?", channelName).Where(condition, address, address).Order(
+		"timestamp desc").First(&last).Error; err != nil {
+		return isaacerror.SysErrFailToQueryAddress
+	}
+	out.FirstSeen = first.Timestamp
+	out.LastSeen = last.Timestamp
+
+	// Distinct counterparties in both directions.
+	from := Database().Dialect().Quote("from")
+	to := Database().Dialect().Quote("to")
+	tableName := Database().NewScope(&Tx{}).TableName()
+	query := "SELECT COUNT(*) FROM (" +
+		"SELECT " + to + " AS counterparty FROM " + tableName +
+		" WHERE channel = ? AND " + from + " = ? AND " + to + " <> ? AND deleted_at IS NULL" +
+		" UNION " +
+		"SELECT " + from + " AS counterparty FROM " + tableName +
+		" WHERE channel = ? AND " + to + " = ? AND " + from + " <> ? AND deleted_at IS NULL" +
+		") counterparties"
+	row := Database().Raw(query, channelName, address, address, channelName, address, address).Row()
+	if err := row.Scan(&out.CounterpartyCount); err != nil {
+		logger.Errorf("%s", err)
+		return isaacerror.SysErrFailToQueryAddress
+	}
+
+	return nil
+}
+
+// QueryTxsOfAddressInChannel queries Txs sent or received by address in channel, ordered by the latest.
+// If direction is "", Txs in both directions are queried.
+func QueryTxsOfAddressInChannel(
+	channelName string,
+	address string,
+	direction string,
+	limit int,
+	offset int,
+	out *[]Tx) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 || channelName == "" || address == "" {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, address:%s, channelName:%s",
+			limit, offset, address, channelName)
+		return -1, isaacerror.SysErrFailToQueryAddress
+	}
+
+	condition, err := addressCondition(direction)
+	if err != nil {
+		return -1, err
+	}
+
+	txTable := Database().Model(&Tx{}).Where("channel = ?", channelName)
+	if direction == "" {
+		txTable = txTable.Where(condition, address, address)
+	} else {
+		txTable = txTable.Where(condition, address)
+	}
+
+	var count int64
+	if err := txTable.Count(&count).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryAddress
+	}
+
+	if err := txTable.Preload("Result").Offset(offset).Limit(limit).Order(
+		"block_height desc").Order("timestamp desc").Find(out).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryAddress
+	}
+
+	return count, nil
+}
@@ -0,0 +1,315 @@
+package polarbear
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"motherbear/backend/logger"
+	util "motherbear/backend/utility"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parsedBlock is the block in common fields, parsed from the block of any version.
+type parsedBlock struct {
+	version   string
+	height    int64
+	hash      string // With 0x.
+	prevHash  string // With 0x. Genesis block has no previous hash.
+	timestamp time.Time
+	peerID    string
+	signature string
+	txs       []TxData
+}
+
+// rawBlock has the keys of the blocks in every version.
+type rawBlock struct {
+	Version   string     `json:"version"`
+	Height    *flexInt64 `json:"height"`
+	Signature string     `json:"signature"`
+
+	// loopchain 0.1a and goloop v3 API (2.0).
+	BlockHash                string     `json:"block_hash"`
+	PrevBlockHash            string     `json:"prev_block_hash"`
+	MerkleTreeRootHash       string     `json:"merkle_tree_root_hash"`
+	TimeStamp                *flexInt64 `json:"time_stamp"`
+	ConfirmedTransactionList []TxData   `json:"confirmed_transaction_list"`
+	PeerID                   string     `json:"peer_id"`
+
+	// loopchain 0.3 or later.
+	Hash             string     `json:"hash"`
+	PrevHash         string     `json:"prevHash"`
+	TransactionsHash string     `json:"transactionsHash"`
+	Timestamp        *flexInt64 `json:"timestamp"`
+	Transactions     []TxData   `json:"transactions"`
+	Leader           string     `json:"leader"`
+}
+
+// blockParser puts the fields of the block of a version into BlockResultData.
+type blockParser func(raw *rawBlock, block *BlockResultData)
+
+// blockParsers are the parsers by block version.
+// loopchain 0.1a and goloop v3 API (2.0) use the legacy keys, and the blocks from loopchain 0.3 use the new keys.
+var blockParsers = map[string]blockParser{
+	"0.1a": parseLegacyBlock,
+	"2.0":  parseLegacyBlock,
+	"0.3":  parseBlock03,
+	"0.4":  parseBlock03,
+	"0.5":  parseBlock03,
+}
+
+// parseBlock decodes the block of any version responded from node, and validates the fields of it.
+// The error has the field which can't be decoded.
+func parseBlock(result map[string]interface{}) (*parsedBlock, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockData BlockResultData
+	if err := json.Unmarshal(data, &blockData); err != nil {
+		return nil, err
+	}
+	if err := blockData.validate(); err != nil {
+		return nil, err
+	}
+
+	block := &parsedBlock{
+		version:   blockData.Version,
+		height:    blockData.Height,
+		hash:      util.AddHexHD(blockData.BlockHash),
+		timestamp: convUnixTimeStampToTime(blockData.Timestamp),
+		peerID:    blockData.PeerID,
+		signature: blockData.Signature,
+		txs:       blockData.ConfirmedTransactionList,
+	}
+	if blockData.PrevBlockHash != "" {
+		block.prevHash = util.AddHexHD(blockData.PrevBlockHash)
+	}
+
+	return block, nil
+}
+
+// UnmarshalJSON decodes the block of any version. The parser is picked by the version of block,
+// or by the keys if the version is unknown.
+func (b *BlockResultData) UnmarshalJSON(data []byte) error {
+	var raw rawBlock
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Height == nil {
+		return fmt.Errorf("no height in the block")
+	}
+
+	parser, ok := blockParsers[raw.Version]
+	if !ok {
+		if raw.Hash != "" {
+			parser = parseBlock03
+		} else {
+			parser = parseLegacyBlock
+		}
+		logger.Infof("Unknown block version %s. Parse it with the keys of block.", raw.Version)
+	}
+
+	*b = BlockResultData{
+		Version:   raw.Version,
+		Height:    int64(*raw.Height),
+		Signature: raw.Signature,
+	}
+	parser(&raw, b)
+
+	return nil
+}
+
+// parseLegacyBlock parses the block with the keys block_hash, prev_block_hash, time_stamp,
+// confirmed_transaction_list and peer_id.
+func parseLegacyBlock(raw *rawBlock, block *BlockResultData) {
+	block.BlockHash = raw.BlockHash
+	block.PrevBlockHash = raw.PrevBlockHash
+	block.MerkleTreeRootHash = raw.MerkleTreeRootHash
+	if raw.TimeStamp != nil {
+		block.Timestamp = int64(*raw.TimeStamp)
+	}
+	block.ConfirmedTransactionList = raw.ConfirmedTransactionList
+	block.PeerID = raw.PeerID
+}
+
+// parseBlock03 parses the block with the keys hash, prevHash, timestamp, transactions and leader.
+func parseBlock03(raw *rawBlock, block *BlockResultData) {
+	block.BlockHash = raw.Hash
+	block.PrevBlockHash = raw.PrevHash
+	block.MerkleTreeRootHash = raw.TransactionsHash
+	if raw.Timestamp != nil {
+		block.Timestamp = int64(*raw.Timestamp)
+	}
+	block.ConfirmedTransactionList = raw.Transactions
+	block.PeerID = raw.Leader
+}
+
+// validate checks the fields of block and the Txs in it.
+func (b *BlockResultData) validate() error {
+	if b.Height < 0 {
+		return fmt.Errorf("invalid height %d", b.Height)
+	}
+	if !isHexString(b.BlockHash) {
+		return fmt.Errorf("invalid block hash %q", b.BlockHash)
+	}
+
+	// Genesis block has no previous hash.
+	if b.PrevBlockHash != "" && !isHexString(b.PrevBlockHash) {
+		return fmt.Errorf("invalid previous block hash %q", b.PrevBlockHash)
+	}
+	if b.Timestamp < 0 {
+		return fmt.Errorf("invalid timestamp %d", b.Timestamp)
+	}
+
+	for i := range b.ConfirmedTransactionList {
+		// Genesis Tx has no hash, sender and receiver.
+		if b.Height == 0 {
+			continue
+		}
+		if err := b.ConfirmedTransactionList[i].validate(); err != nil {
+			return fmt.Errorf("invalid Tx %d in the block: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes the Tx of any version. Tx v2 uses tx_hash and decimal timestamp,
+// and Tx v3 uses txHash and hex timestamp. Data can be a string or an object.
+func (t *TxData) UnmarshalJSON(data []byte) error {
+	type txData TxData
+	aux := struct {
+		*txData
+		Timestamp    *flexInt64      `json:"timestamp"`
+		LegacyTxHash string          `json:"tx_hash"`
+		Data         json.RawMessage `json:"data"`
+	}{txData: (*txData)(t)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Timestamp != nil {
+		t.Timestamp = strconv.FormatInt(int64(*aux.Timestamp), 10)
+	}
+	if t.TxHash == "" {
+		t.TxHash = aux.LegacyTxHash
+	}
+
+	// Keep the object in JSON string. In some case no data in Tx.
+	if len(aux.Data) != 0 && string(aux.Data) != "null" {
+		if aux.Data[0] == '"' {
+			if err := json.Unmarshal(aux.Data, &t.Data); err != nil {
+				return err
+			}
+		} else {
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, aux.Data); err != nil {
+				return err
+			}
+			t.Data = compacted.String()
+		}
+	}
+
+	return nil
+}
+
+// validate checks the hash, addresses and value of Tx.
+func (t *TxData) validate() error {
+	if !isHexString(t.TxHash) {
+		return fmt.Errorf("invalid Tx hash %q", t.TxHash)
+	}
+
+	// Deploy Tx may have no receiver.
+	if t.From != "" && !isAddress(t.From) {
+		return fmt.Errorf("invalid sender %q", t.From)
+	}
+	if t.To != "" && !isAddress(t.To) {
+		return fmt.Errorf("invalid receiver %q", t.To)
+	}
+	if t.Value != "" && (!strings.HasPrefix(t.Value, "0x") || !isHexString(t.Value)) {
+		return fmt.Errorf("invalid value %q", t.Value)
+	}
+
+	return nil
+}
+
+// flexInt64 is the integer in number, hex string with 0x or decimal string.
+type flexInt64 int64
+
+func (n *flexInt64) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	base := 10
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if strings.HasPrefix(s, "0x") {
+			s = s[2:]
+			base = 16
+		}
+	}
+
+	v, err := strconv.ParseInt(s, base, 64)
+	if err != nil {
+		// Number in exponent form.
+		f, ferr := strconv.ParseFloat(s, 64)
+		if base != 10 || ferr != nil {
+			return fmt.Errorf("invalid integer %s", string(data))
+		}
+		v = int64(f)
+	}
+	*n = flexInt64(v)
+
+	return nil
+}
+
+// isHexString checks the string is not empty, and has only hex digits with or without 0x.
+func isHexString(s string) bool {
+	s = strings.TrimPrefix(s, "0x")
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// isAddress checks the string is the address of EOA(hx) or SCORE(cx).
+func isAddress(s string) bool {
+	if len(s) != 42 || !(strings.HasPrefix(s, "hx") || strings.HasPrefix(s, "cx")) {
+		return false
+	}
+	return isHexString(s[2:])
+}
+
+// buildTxFromData builds the Tx from the Tx data decoded in the block.
+func buildTxFromData(txData TxData, channelName string, block *parsedBlock) Tx {
+	timestamp, _ := strconv.ParseInt(txData.Timestamp, 10, 64)
+	tx := Tx{
+		Channel:     channelName,
+		BlockHeight: block.height,
+		Timestamp:   convUnixTimeStampToTime(timestamp),
+		Value:       parseHexBigInt(txData.Value).String(),
+		// Genesis Tx has no sender and receiver.
+		From: txData.From,
+		To:   txData.To,
+		// Data type is like "call", "deploy" or "message". No data type for transfer of ICX.
+		DataType: txData.DataType,
+		Data:     txData.Data,
+	}
+
+	if txData.TxHash != "" {
+		tx.TxHash = util.AddHexHD(txData.TxHash)
+	} else {
+		logger.Error("No key for TxHash!! Block hash: ", block.hash)
+	}
+
+	return tx
+}
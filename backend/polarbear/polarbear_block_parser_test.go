@@ -0,0 +1,197 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestParseBlock(t *testing.T) {
+	var legacyBlock map[string]interface{}
+	_ = json.Unmarshal([]byte(`{
+		"version": "0.1a",
+		"prev_block_hash": "48757af881f76c858890fb41934bee228ad50a71707154a482826c39b8560d4b",
+		"time_stamp": 1516498781094429,
+		"confirmed_transaction_list": [
+			{
+				"from": "hx5d91dee6102ead2aca60256cf33ebf9aab102c82", "to": "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b",
+				"value": "0x1", "timestamp": "1516498781094000", "tx_hash": "ab01"
+			}
+		],
+		"block_hash": "1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57",
+		"height": 3,
+		"peer_id": "e07212ee-fe4b-11e7-8c7b-acbc32865d5f",
+		"signature": "MEQCICT8mTIL6pRwMWsJjSBHcl4Q"
+	}`), &legacyBlock)
+
+	block, err := parseBlock(legacyBlock)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, block.version, "0.1a")
+	assert.Equal(t, block.height, int64(3))
+	assert.Equal(t, block.hash, "0x1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57")
+	assert.Equal(t, block.prevHash, "0x48757af881f76c858890fb41934bee228ad50a71707154a482826c39b8560d4b")
+	assert.Equal(t, block.timestamp, convUnixTimeStampToTime(1516498781094429))
+	assert.Equal(t, block.peerID, "e07212ee-fe4b-11e7-8c7b-acbc32865d5f")
+	assert.Equal(t, len(block.txs), 1)
+
+	// Tx v2 has the decimal timestamp and tx_hash.
+	tx := buildTxFromData(block.txs[0], "channel1", block)
+	assert.Equal(t, tx.TxHash, "0xab01")
+	assert.Equal(t, tx.BlockHeight, int64(3))
+	assert.Equal(t, tx.Timestamp, convUnixTimeStampToTime(1516498781094000))
+	assert.Equal(t, tx.Value, "1")
+
+	var newBlock map[string]interface{}
+	_ = json.Unmarshal([]byte(`{
+		"version": "0.4",
+		"prevHash": "0x48757af881f76c858890fb41934bee228ad50a71707154a482826c39b8560d4b",
+		"timestamp": "0x5633f6326421d",
+		"transactions": [
+			{
+				"version": "0x3", "from": "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+				"to": "cx54d95fee187faaea03cee908f50623c8381179d0", "timestamp": "0x5633f63264070",
+				"txHash": "0xab02", "dataType": "call", "data": {"method": "transfer"}
+			}
+		],
+		"hash": "0x1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57",
+		"height": "0x1a",
+		"leader": "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+		"signature": "MEQCICT8mTIL6pRwMWsJjSBHcl4Q"
+	}`), &newBlock)
+
+	block, err = parseBlock(newBlock)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, block.version, "0.4")
+	assert.Equal(t, block.height, int64(26))
+	assert.Equal(t, block.hash, "0x1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57")
+	assert.Equal(t, block.prevHash, "0x48757af881f76c858890fb41934bee228ad50a71707154a482826c39b8560d4b")
+	assert.Equal(t, block.timestamp, convUnixTimeStampToTime(1516498781094429))
+	assert.Equal(t, block.peerID, "hx5d91dee6102ead2aca60256cf33ebf9aab102c82")
+
+	tx = buildTxFromData(block.txs[0], "channel1", block)
+	assert.Equal(t, tx.TxHash, "0xab02")
+	assert.Equal(t, tx.To, "cx54d95fee187faaea03cee908f50623c8381179d0")
+	assert.Equal(t, tx.DataType, "call")
+	assert.Equal(t, tx.Data, `{"method":"transfer"}`)
+	assert.Equal(t, tx.Value, "0")
+
+	// Unknown version is parsed by the keys.
+	newBlock["version"] = "0.9"
+	block, err = parseBlock(newBlock)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, block.height, int64(26))
+
+	// Block without hash is not parsed, instead of panic.
+	delete(newBlock, "hash")
+	_, err = parseBlock(newBlock)
+	assert.NotEqual(t, err, nil)
+}
+
+func TestParseInvalidBlock(t *testing.T) {
+	newBlock := func() map[string]interface{} {
+		var block map[string]interface{}
+		_ = json.Unmarshal([]byte(`{
+			"version": "0.4",
+			"prevHash": "0x48757af881f76c858890fb41934bee228ad50a71707154a482826c39b8560d4b",
+			"timestamp": "0x5633f6326421d",
+			"transactions": [
+				{
+					"version": "0x3", "from": "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+					"timestamp": "0x5633f63264070", "txHash": "0xab02", "dataType": "deploy",
+					"data": {"contentType": "application/zip", "content": "0x504b"}
+				}
+			],
+			"hash": "0x1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57",
+			"height": "0x1a",
+			"leader": "hx5d91dee6102ead2aca60256cf33ebf9aab102c82"
+		}`), &block)
+		return block
+	}
+	txOf := func(block map[string]interface{}) map[string]interface{} {
+		return block["transactions"].([]interface{})[0].(map[string]interface{})
+	}
+
+	// Deploy Tx without receiver is valid.
+	block, err := parseBlock(newBlock())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, block.txs[0].To, "")
+	assert.Equal(t, block.txs[0].Data, `{"content":"0x504b","contentType":"application/zip"}`)
+
+	// Field in wrong type.
+	invalid := newBlock()
+	txOf(invalid)["to"] = 1
+	_, err = parseBlock(invalid)
+	assert.NotEqual(t, err, nil)
+
+	invalid = newBlock()
+	invalid["height"] = "0xzz"
+	_, err = parseBlock(invalid)
+	assert.NotEqual(t, err, nil)
+
+	// Field in wrong format.
+	invalid = newBlock()
+	txOf(invalid)["from"] = "hx01"
+	_, err = parseBlock(invalid)
+	assert.Equal(t, err.Error(), `invalid Tx 0 in the block: invalid sender "hx01"`)
+
+	invalid = newBlock()
+	txOf(invalid)["value"] = "100"
+	_, err = parseBlock(invalid)
+	assert.Equal(t, err.Error(), `invalid Tx 0 in the block: invalid value "100"`)
+
+	invalid = newBlock()
+	delete(txOf(invalid), "txHash")
+	_, err = parseBlock(invalid)
+	assert.Equal(t, err.Error(), `invalid Tx 0 in the block: invalid Tx hash ""`)
+
+	invalid = newBlock()
+	delete(invalid, "height")
+	_, err = parseBlock(invalid)
+	assert.Equal(t, err.Error(), "no height in the block")
+}
+
+// Test to crawl the channel upgraded from 0.1a to 0.4 in the middle.
+func TestCrawlUpgradedChannel(t *testing.T) {
+	dbpath := "test_block_parser.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := &fakeNode{failHeights: make(map[int64]bool), txsPerBlock: 1, upgradeAt: 6}
+	node.appendBlocks(10)
+	node.server = httptest.NewServer(http.HandlerFunc(node.serveJSONRPC))
+	defer node.close()
+
+	channelName := "channel_upgraded"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks []Block
+	assert.Equal(t, QueryBlockHashesInRange(channelName, 1, 10, &blocks), nil)
+	assert.Equal(t, len(blocks), 10)
+	for _, b := range blocks {
+		assert.Equal(t, b.BlockHash, node.blockHash(b.BlockHeight))
+		assert.Equal(t, b.PrevBlockHash, node.blockHash(b.BlockHeight-1))
+	}
+
+	// The chain is linked across the upgrade.
+	brokenHeight, err := findBrokenLinkage(channelName, 1, 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, brokenHeight, int64(-1))
+
+	var txs []Tx
+	count, _ := QueryTxsInChannelBySearch(channelName, 20, 0, TxSearch{BlockHeight: 8}, &txs)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, txs[0].Status, "Success")
+}
@@ -0,0 +1,300 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// The minimum and maximum duration to wait before reconnecting the block stream.
+const blockStreamMinBackoff = 1 * time.Second
+const blockStreamMaxBackoff = 60 * time.Second
+
+// The duration to wait for the next notification. The stream without new block is reconnected after it.
+const blockStreamReadTimeout = 60 * time.Second
+
+// blockStreamRequest is the request to subscribe the blocks from the height.
+type blockStreamRequest struct {
+	Height string `json:"height"`
+}
+
+// blockStreamResponse is the response of subscription. Code 0 is success.
+type blockStreamResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// blockNotification is the notification of new block.
+type blockNotification struct {
+	Hash   string    `json:"hash"`
+	Height flexInt64 `json:"height"`
+}
+
+// blockStream subscribes the new blocks of channel through the WebSocket of goloop node,
+// and crawls each notified block right away. While it is not connected, the channel is crawled by polling.
+type blockStream struct {
+	mu          sync.Mutex
+	channelName string
+	connected   bool
+	conn        *websocket.Conn
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+var blockStreams = make(map[string]*blockStream)
+var blockStreamsLock sync.Mutex
+
+// BeginToStream subscribes the new blocks of every channel.
+func BeginToStream(channelNames []string) {
+	blockStreamsLock.Lock()
+	defer blockStreamsLock.Unlock()
+
+	for _, channelName := range channelNames {
+		if _, ok := blockStreams[channelName]; ok {
+			continue
+		}
+		logger.Infof("Begin to stream the blocks in %s.", channelName)
+		blockStreams[channelName] = startBlockStream(channelName)
+	}
+}
+
+// syncBlockStreams subscribes the new blocks of the channels not streamed yet,
+// and closes the block streams of the channels not in the list.
+func syncBlockStreams(channelNames []string) {
+	wanted := make(map[string]bool, len(channelNames))
+	for _, channelName := range channelNames {
+		wanted[channelName] = true
+	}
+
+	var closing []*blockStream
+	blockStreamsLock.Lock()
+	for channelName, s := range blockStreams {
+		if !wanted[channelName] {
+			logger.Infof("Stop to stream the blocks in %s.", channelName)
+			closing = append(closing, s)
+			delete(blockStreams, channelName)
+		}
+	}
+	blockStreamsLock.Unlock()
+
+	// The stream may be crawling the notified block. Not to block the other channels while waiting for it.
+	for _, s := range closing {
+		s.close()
+	}
+
+	BeginToStream(channelNames)
+}
+
+// StopToStream closes the block streams of every channel.
+func StopToStream() {
+	blockStreamsLock.Lock()
+	closing := make([]*blockStream, 0, len(blockStreams))
+	for _, s := range blockStreams {
+		closing = append(closing, s)
+	}
+	blockStreams = make(map[string]*blockStream)
+	blockStreamsLock.Unlock()
+
+	// The streams may be catching up. Not to block isStreaming while waiting for them.
+	for _, s := range closing {
+		s.close()
+	}
+}
+
+// isStreaming checks the blocks of channel are notified through the block stream now.
+func isStreaming(channelName string) bool {
+	blockStreamsLock.Lock()
+	s, ok := blockStreams[channelName]
+	blockStreamsLock.Unlock()
+
+	return ok && s.isConnected()
+}
+
+func startBlockStream(channelName string) *blockStream {
+	s := &blockStream{
+		channelName: channelName,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go s.run()
+
+	return s
+}
+
+// close stops the stream and waits for it to end.
+func (s *blockStream) close() {
+	close(s.stop)
+
+	s.mu.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mu.Unlock()
+
+	<-s.done
+}
+
+func (s *blockStream) isConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connected
+}
+
+func (s *blockStream) setConn(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conn = conn
+	s.connected = conn != nil
+}
+
+func (s *blockStream) isStopped() bool {
+	select {
+	case <-s.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// run keeps subscribing until the stream is closed. It reconnects with the exponential backoff.
+func (s *blockStream) run() {
+	defer close(s.done)
+
+	backoff := blockStreamMinBackoff
+	for !s.isStopped() {
+		notified, err := s.subscribe()
+		s.setConn(nil)
+		if s.isStopped() {
+			return
+		}
+
+		// The stream which worked is reconnected soon.
+		if notified {
+			backoff = blockStreamMinBackoff
+		}
+		logger.Errorf("Block stream in %s is disconnected. Crawl blocks by polling until it is reconnected in %s. %s",
+			s.channelName, backoff, err)
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > blockStreamMaxBackoff {
+			backoff = blockStreamMaxBackoff
+		}
+	}
+}
+
+// subscribe connects to a node of channel, and crawls the notified blocks until the connection is broken.
+// Returns whether any block is notified.
+func (s *blockStream) subscribe() (bool, error) {
+	nodes := getNodePool(s.channelName)
+
+	// Catch up to the last block before subscribing the next blocks.
+	lastHeight, err := nodes.getLastBlockHeight()
+	if err != nil {
+		return false, err
+	}
+	if err := crawlBlockchainUpTo(nodes, s.channelName, lastHeight); err != nil {
+		logger.Errorf("Fail to crawl blocks in %s. %s", s.channelName, err)
+	}
+
+	var conn *websocket.Conn
+	nodeIP, err := nodes.call(-1, "", func(nodeIP string) error {
+		var err error
+		conn, err = dialBlockStream(nodeIP, s.channelName, lastHeight+1)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	s.setConn(conn)
+	if s.isStopped() {
+		return false, nil
+	}
+	logger.Infof("Block stream in %s is connected to %s.", s.channelName, nodeIP)
+
+	notified := false
+	for {
+		conn.SetReadDeadline(time.Now().Add(blockStreamReadTimeout))
+
+		var notification blockNotification
+		if err := websocket.JSON.Receive(conn, &notification); err != nil {
+			return notified, err
+		}
+		notified = true
+
+		height := int64(notification.Height)
+		logger.Debugf("Block %d is notified in %s. %s", height, s.channelName, notification.Hash)
+		nodes.setHeight(nodeIP, height)
+
+		if err := crawlBlockchainUpTo(nodes, s.channelName, height); err != nil {
+			logger.Errorf("Fail to crawl blocks in %s. %s", s.channelName, err)
+		}
+	}
+}
+
+// dialBlockStream connects to the block WebSocket of node, and requests to notify the blocks from the height.
+func dialBlockStream(nodeIP string, channelName string, height int64) (*websocket.Conn, error) {
+	config, err := websocket.NewConfig(blockStreamURL(nodeIP, channelName), nodeIP)
+	if err != nil {
+		return nil, err
+	}
+	config.Dialer = &net.Dialer{Timeout: nodeRequestTimeout}
+
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetDeadline(time.Now().Add(nodeRequestTimeout))
+	request := blockStreamRequest{Height: "0x" + strconv.FormatInt(height, 16)}
+	if err := websocket.JSON.Send(conn, request); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var response blockStreamResponse
+	if err := websocket.JSON.Receive(conn, &response); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if response.Code != 0 {
+		conn.Close()
+		logger.Errorf("Fail to subscribe the blocks of %s. %d %s", nodeIP, response.Code, response.Message)
+		return nil, isaacerror.SysErrFailToSubscribeBlocks
+	}
+	conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+// blockStreamURL returns the URL of block WebSocket of node, like ws://127.0.0.1:9000/api/v3/icon_dex/block.
+func blockStreamURL(nodeIP string, channelName string) string {
+	url := nodeIP
+	if strings.HasPrefix(url, "https://") {
+		url = "wss://" + strings.TrimPrefix(url, "https://")
+	} else if strings.HasPrefix(url, "http://") {
+		url = "ws://" + strings.TrimPrefix(url, "http://")
+	}
+
+	url += "/api/v3"
+	if channelName != "" && channelName != "default" {
+		url += "/" + channelName
+	}
+
+	return url + "/block"
+}
@@ -0,0 +1,235 @@
+package polarbear
+
+import (
+	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// fakeStreamNode is the pseudo goloop node which notifies the new blocks through WebSocket.
+type fakeStreamNode struct {
+	*fakeNode
+	mu          sync.Mutex
+	subscribed  int // Count of subscriptions.
+	generation  int // Subscriptions in the old generation are dropped.
+	channelName string
+}
+
+func newFakeStreamNode(channelName string, height int64) *fakeStreamNode {
+	node := &fakeStreamNode{
+		fakeNode:    &fakeNode{failHeights: make(map[int64]bool), txsPerBlock: 1},
+		channelName: channelName,
+	}
+	node.appendBlocks(height)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/v3/"+channelName+"/block", websocket.Handler(node.serveBlockStream))
+	mux.HandleFunc("/", node.serveJSONRPC)
+	node.server = httptest.NewServer(mux)
+
+	return node
+}
+
+// serveBlockStream notifies the blocks from the requested height, until the subscription is dropped.
+func (n *fakeStreamNode) serveBlockStream(conn *websocket.Conn) {
+	var request blockStreamRequest
+	if err := websocket.JSON.Receive(conn, &request); err != nil {
+		return
+	}
+	height, _ := strconv.ParseInt(request.Height[2:], 16, 64)
+	if err := websocket.JSON.Send(conn, blockStreamResponse{Code: 0}); err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	n.subscribed++
+	generation := n.generation
+	n.mu.Unlock()
+
+	for {
+		n.mu.Lock()
+		dropped := n.generation != generation
+		n.mu.Unlock()
+		if dropped {
+			return
+		}
+
+		n.fakeNode.mu.Lock()
+		var notifications []map[string]string
+		for ; height < int64(len(n.blocks)); height++ {
+			notifications = append(notifications, map[string]string{
+				"hash":   fakeBlockHash(n.blocks[height]),
+				"height": "0x" + strconv.FormatInt(height, 16),
+			})
+		}
+		n.fakeNode.mu.Unlock()
+
+		for _, notification := range notifications {
+			if err := websocket.JSON.Send(conn, notification); err != nil {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// dropSubscriptions closes every subscription.
+func (n *fakeStreamNode) dropSubscriptions() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.generation++
+}
+
+func (n *fakeStreamNode) countOfSubscriptions() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.subscribed
+}
+
+// waitFor waits until the condition is true in the timeout.
+func waitFor(t *testing.T, timeout time.Duration, condition func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout to wait for the condition.")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBlockStreamURL(t *testing.T) {
+	assert.Equal(t, blockStreamURL("http://127.0.0.1:9000", "icon_dex"), "ws://127.0.0.1:9000/api/v3/icon_dex/block")
+	assert.Equal(t, blockStreamURL("https://ctz.solidwallet.io", "default"), "wss://ctz.solidwallet.io/api/v3/block")
+}
+
+func TestBlockStream(t *testing.T) {
+	dbpath := "test_block_stream.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	channelName := "channel_stream"
+	node := newFakeStreamNode(channelName, 5)
+	defer node.close()
+
+	confPath := initFakeNodeConf(t, channelName, node.fakeNode)
+	defer os.Remove(confPath)
+	configuration.Conf().Blockchain.Streaming = true
+
+	BeginToStream([]string{channelName})
+	defer StopToStream()
+
+	// Blocks are caught up before subscribing.
+	waitFor(t, 5*time.Second, func() bool {
+		return isStreaming(channelName) && GetCurrentBlockHeightInDB(channelName) == 5
+	})
+
+	// The new blocks are crawled as soon as notified.
+	node.appendBlocks(8)
+	waitFor(t, 5*time.Second, func() bool { return GetCurrentBlockHeightInDB(channelName) == 8 })
+	assert.Equal(t, node.countOfSubscriptions(), 1)
+
+	// Polling skips the channel in streaming.
+	crawlingStatus = readyToStarCrawling
+	requests := node.countOfRequests()
+	cronJobForEveryChannel(configuration.Conf())
+	assert.Equal(t, node.countOfRequests(), requests)
+
+	// The stream is reconnected after dropped.
+	node.dropSubscriptions()
+	waitFor(t, 5*time.Second, func() bool { return !isStreaming(channelName) })
+	node.appendBlocks(10)
+	waitFor(t, 5*time.Second, func() bool {
+		return isStreaming(channelName) && GetCurrentBlockHeightInDB(channelName) == 10
+	})
+	assert.Equal(t, node.countOfSubscriptions(), 2)
+
+	var blocks []Block
+	assert.Equal(t, QueryBlockHashesInRange(channelName, 1, 10, &blocks), nil)
+	assert.Equal(t, len(blocks), 10)
+
+	// The stream is closed when crawling in the channel is disabled, and subscribed again when enabled.
+	configuration.Conf().Channel[0].CrawlDisabled = true
+	crawlingStatus = readyToStarCrawling
+	cronJobForEveryChannel(configuration.Conf())
+	blockStreamsLock.Lock()
+	_, ok := blockStreams[channelName]
+	blockStreamsLock.Unlock()
+	assert.Equal(t, ok, false)
+
+	configuration.Conf().Channel[0].CrawlDisabled = false
+	cronJobForEveryChannel(configuration.Conf())
+	waitFor(t, 5*time.Second, func() bool { return isStreaming(channelName) })
+	assert.Equal(t, node.countOfSubscriptions(), 3)
+}
+
+// Test the channel is crawled by polling if the node has no block stream.
+func TestBlockStreamFallbackToPolling(t *testing.T) {
+	dbpath := "test_block_stream_fallback.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(5)
+	defer node.close()
+
+	channelName := "channel_stream_fallback"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+	configuration.Conf().Blockchain.Streaming = true
+
+	BeginToStream([]string{channelName})
+	defer StopToStream()
+
+	// The stream catches up once before it fails to subscribe.
+	waitFor(t, 5*time.Second, func() bool { return GetCurrentBlockHeightInDB(channelName) == 5 })
+	assert.Equal(t, isStreaming(channelName), false)
+
+	node.appendBlocks(8)
+	crawlingStatus = readyToStarCrawling
+	cronJobForEveryChannel(configuration.Conf())
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(8))
+}
+
+// Test the stream catching up doesn't block the others while it is closed.
+func TestStopToStreamWhileCatchingUp(t *testing.T) {
+	channelName := "channel_stream_catching_up"
+	s := &blockStream{channelName: channelName, stop: make(chan struct{}), done: make(chan struct{})}
+	blockStreamsLock.Lock()
+	blockStreams[channelName] = s
+	blockStreamsLock.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		StopToStream()
+		close(stopped)
+	}()
+	<-s.stop
+
+	checked := make(chan bool)
+	go func() { checked <- isStreaming(channelName) }()
+	select {
+	case streaming := <-checked:
+		assert.Equal(t, streaming, false)
+	case <-time.After(time.Second):
+		t.Fatal("isStreaming is blocked while the stream is closed.")
+	}
+
+	// The stream ends after its catch-up.
+	close(s.done)
+	<-stopped
+}
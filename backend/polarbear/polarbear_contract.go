@@ -0,0 +1,278 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// The address to install a new SCORE.
+const scoreInstallAddress = "cx0000000000000000000000000000000000000000"
+
+// The address of governance SCORE which audits the deployment of SCORE.
+const governanceAddress = "cx0000000000000000000000000000000000000001"
+
+// Action in the history of contract.
+const (
+	ContractActionDeploy = "deploy"
+	ContractActionUpdate = "update"
+	ContractActionAccept = "accept"
+	ContractActionReject = "reject"
+)
+
+// Contract is the SCORE deployed in channel.
+// The deploy information is empty if the contract was deployed before the crawled blocks.
+type Contract struct {
+	gorm.Model
+	Channel             string `gorm:"type:VARCHAR(64);not null;index"`
+	Address             string `gorm:"type:VARCHAR(128);not null;index"`
+	Deployer            string `gorm:"type:VARCHAR(128);index"`
+	DeployTxHash        string `gorm:"type:VARCHAR(512)"`
+	DeployHeight        int64  `gorm:"type:BIGINT;index"`
+	DeployTimestamp     time.Time
+	ContentType         string `gorm:"type:VARCHAR(64)"`
+	LastUpdateTxHash    string `gorm:"type:VARCHAR(512)"`
+	LastUpdateHeight    int64  `gorm:"type:BIGINT;index"`
+	LastUpdateTimestamp time.Time
+}
+
+// ContractHistory is the deployment, update or audit of contract.
+type ContractHistory struct {
+	gorm.Model
+	Channel     string `gorm:"type:VARCHAR(64);not null;index"`
+	Address     string `gorm:"type:VARCHAR(128);not null;index"`
+	TxHash      string `gorm:"type:VARCHAR(512);not null;index"`
+	BlockHeight int64  `gorm:"type:BIGINT;not null;index"`
+	Timestamp   time.Time
+	Action      string `gorm:"type:VARCHAR(20);not null"`
+	From        string `gorm:"type:VARCHAR(128);not null"`
+	ContentType string `gorm:"type:VARCHAR(64)"`
+	RefTxHash   string `gorm:"type:VARCHAR(512)"` // Tx hash of the deployment which is audited.
+}
+
+// contractTxData is the data of Tx to deploy or audit SCORE.
+type contractTxData struct {
+	ContentType string                 `json:"contentType"`
+	Method      string                 `json:"method"`
+	Params      map[string]interface{} `json:"params"`
+}
+
+// apply updates the contract with the history.
+func (c *Contract) apply(history *ContractHistory) {
+	switch history.Action {
+	case ContractActionDeploy:
+		c.Deployer = history.From
+		c.DeployTxHash = history.TxHash
+		c.DeployHeight = history.BlockHeight
+		c.DeployTimestamp = history.Timestamp
+		c.ContentType = history.ContentType
+	case ContractActionUpdate:
+		c.LastUpdateTxHash = history.TxHash
+		c.LastUpdateHeight = history.BlockHeight
+		c.LastUpdateTimestamp = history.Timestamp
+		c.ContentType = history.ContentType
+	}
+}
+
+// buildContractHistoryFromTx builds the history of contract from the successful Tx to deploy or audit SCORE.
+// Returns false if the Tx is not for contract.
+func buildContractHistoryFromTx(db *gorm.DB, tx *Tx, out *ContractHistory) (bool, error) {
+	if tx.Status != TxStatusSuccess {
+		return false, nil
+	}
+
+	var data contractTxData
+	_ = json.Unmarshal([]byte(tx.Data), &data)
+
+	*out = ContractHistory{
+		Channel:     tx.Channel,
+		TxHash:      tx.TxHash,
+		BlockHeight: tx.BlockHeight,
+		Timestamp:   tx.Timestamp,
+		From:        tx.From,
+	}
+
+	switch {
+	case tx.DataType == "deploy" && tx.Result.ScoreAddress != "":
+		out.Address = tx.Result.ScoreAddress
+		out.ContentType = data.ContentType
+		out.Action = ContractActionUpdate
+		if tx.To == scoreInstallAddress {
+			out.Action = ContractActionDeploy
+		}
+		return true, nil
+
+	case tx.DataType == "call" && tx.To == governanceAddress &&
+		(data.Method == "acceptScore" || data.Method == "rejectScore"):
+		out.RefTxHash, _ = data.Params["txHash"].(string)
+		out.Action = ContractActionAccept
+		if data.Method == "rejectScore" {
+			out.Action = ContractActionReject
+		}
+
+		// Find the contract by the deployment which is audited.
+		var deployment ContractHistory
+		err := db.Where("channel = ? AND tx_hash = ?", tx.Channel, out.RefTxHash).First(&deployment).Error
+		if gorm.IsRecordNotFoundError(err) {
+			logger.Infof("No deployment of %s audited by %s in %s", out.RefTxHash, tx.TxHash, tx.Channel)
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		out.Address = deployment.Address
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// registerContractsInBlock adds the contracts deployed in the block, and the history of contracts.
+func registerContractsInBlock(db *gorm.DB, block *Block) error {
+	for i := range block.Txs {
+		var history ContractHistory
+		ok, err := buildContractHistoryFromTx(db, &block.Txs[i], &history)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		if err := db.Create(&history).Error; err != nil {
+			return err
+		}
+		logger.Infof("Contract %s is %s by %s in %s", history.Address, history.Action, history.TxHash, history.Channel)
+
+		var contract Contract
+		if err := db.Where(&Contract{Channel: history.Channel, Address: history.Address}).FirstOrInit(
+			&contract).Error; err != nil {
+			return err
+		}
+		contract.apply(&history)
+		if err := db.Save(&contract).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteContractsInRange deletes the history of contracts between the heights in channel.
+// The contracts are rebuilt with the remaining history, and deleted if no history remains.
+func deleteContractsInRange(db *gorm.DB, channelName string, beginHeight int64, endHeight int64) error {
+	if err := db.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&ContractHistory{}).Error; err != nil {
+		return err
+	}
+
+	var contracts []Contract
+	if err := db.Where("channel = ? AND (deploy_height >= ? OR last_update_height >= ?)",
+		channelName, beginHeight, beginHeight).Find(&contracts).Error; err != nil {
+		return err
+	}
+
+	for _, c := range contracts {
+		var histories []ContractHistory
+		if err := db.Where("channel = ? AND address = ?", channelName, c.Address).Order(
+			"block_height asc").Order("id asc").Find(&histories).Error; err != nil {
+			return err
+		}
+
+		if len(histories) == 0 {
+			if err := db.Unscoped().Delete(&c).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		contract := Contract{Model: c.Model, Channel: c.Channel, Address: c.Address}
+		for i := range histories {
+			contract.apply(&histories[i])
+		}
+		if err := db.Save(&contract).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// QueryContractsInChannel queries the contracts in channel, ordered by the latest deployment.
+func QueryContractsInChannel(channelName string, limit int, offset int, out *[]Contract) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 || channelName == "" {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, channelName:%s", limit, offset, channelName)
+		return -1, isaacerror.SysErrFailToQueryContracts
+	}
+
+	table := Database().Model(&Contract{}).Where("channel = ?", channelName)
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryContracts
+	}
+
+	if err := table.Order("deploy_height desc").Order("id desc").Offset(
+		offset).Limit(limit).Find(out).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryContracts
+	}
+
+	return count, nil
+}
+
+// QueryContractInChannelByAddress queries the contract with its history in channel.
+func QueryContractInChannelByAddress(
+	channelName string,
+	address string,
+	out *Contract,
+	history *[]ContractHistory) error {
+
+	// Check arguments.
+	if channelName == "" || address == "" {
+		logger.Errorf("Arguments is wrong. address:%s, channelName:%s", address, channelName)
+		return isaacerror.SysErrFailToQueryContracts
+	}
+
+	if err := Database().Where(&Contract{Channel: channelName, Address: address}).First(out).Error; err != nil {
+		return isaacerror.SysErrFailToQueryContracts
+	}
+
+	if err := Database().Where("channel = ? AND address = ?", channelName, address).Order(
+		"block_height asc").Order("id asc").Find(history).Error; err != nil {
+		return isaacerror.SysErrFailToQueryContracts
+	}
+
+	return nil
+}
+
+// QueryCallCountsOfContracts queries the count of calls to each contract in channel.
+func QueryCallCountsOfContracts(channelName string, addresses []string) (map[string]int64, error) {
+	callCounts := make(map[string]int64)
+	if len(addresses) == 0 {
+		return callCounts, nil
+	}
+
+	to := Database().Dialect().Quote("to")
+	rows, err := Database().Model(&Tx{}).Select(to+", COUNT(*)").Where(
+		"channel = ? AND data_type = ? AND "+to+" IN (?)", channelName, "call", addresses).Group(to).Rows()
+	if err != nil {
+		logger.Errorf("%s", err)
+		return nil, isaacerror.SysErrFailToQueryContracts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var count int64
+		if err := rows.Scan(&address, &count); err != nil {
+			return nil, isaacerror.SysErrFailToQueryContracts
+		}
+		callCounts[address] = count
+	}
+
+	return callCounts, nil
+}
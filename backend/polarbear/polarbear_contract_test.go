@@ -0,0 +1,107 @@
+package polarbear
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+const testDeployer = "hx5d91dee6102ead2aca60256cf33ebf9aab102c82"
+const testContract = "cx54d95fee187faaea03cee908f50623c8381179d0"
+
+// saveTestBlockWithTxs saves the block of Txs, and registers the contracts in it.
+func saveTestBlockWithTxs(t *testing.T, channelName string, height int64, txs ...Tx) {
+	for i := range txs {
+		txs[i].Channel = channelName
+		txs[i].BlockHeight = height
+		txs[i].Timestamp = time.Unix(height, 0)
+		if txs[i].Status == "" {
+			txs[i].Status = "Success"
+		}
+	}
+
+	block := Block{
+		Channel:     channelName,
+		BlockHeight: height,
+		BlockHash:   "0x" + strconv.FormatInt(height*1000, 16),
+		Timestamp:   time.Unix(height, 0),
+		Txs:         txs,
+	}
+	if err := Database().Save(&block).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := registerContractsInBlock(Database(), &block); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterContractsInBlock(t *testing.T) {
+	dbpath := "test_contract.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	channelName := "channel_contract"
+
+	// Deploy, audit, call, update and failed update of the contract.
+	saveTestBlockWithTxs(t, channelName, 1, Tx{
+		TxHash: "0xd1", From: testDeployer, To: scoreInstallAddress, DataType: "deploy",
+		Data:   `{"contentType":"application/zip","content":"0x1234"}`,
+		Result: TxResult{ScoreAddress: testContract},
+	})
+	saveTestBlockWithTxs(t, channelName, 2, Tx{
+		TxHash: "0xa1", From: "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b", To: governanceAddress, DataType: "call",
+		Data: `{"method":"acceptScore","params":{"txHash":"0xd1"}}`,
+	})
+	saveTestBlockWithTxs(t, channelName, 3,
+		Tx{TxHash: "0xc1", From: testDeployer, To: testContract, DataType: "call", Data: `{"method":"transfer"}`},
+		Tx{TxHash: "0xc2", From: testDeployer, To: testContract, DataType: "call", Data: `{"method":"transfer"}`},
+	)
+	saveTestBlockWithTxs(t, channelName, 4, Tx{
+		TxHash: "0xd2", From: testDeployer, To: testContract, DataType: "deploy",
+		Data:   `{"contentType":"application/java","content":"0x5678"}`,
+		Result: TxResult{ScoreAddress: testContract},
+	})
+	saveTestBlockWithTxs(t, channelName, 5, Tx{
+		TxHash: "0xd3", From: testDeployer, To: testContract, DataType: "deploy", Status: "Failure",
+		Data:   `{"contentType":"application/zip","content":"0x9abc"}`,
+		Result: TxResult{ScoreAddress: testContract},
+	})
+
+	var contracts []Contract
+	count, err := QueryContractsInChannel(channelName, 10, 0, &contracts)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+
+	var contract Contract
+	var history []ContractHistory
+	assert.Equal(t, QueryContractInChannelByAddress(channelName, testContract, &contract, &history), nil)
+	assert.Equal(t, contract.Deployer, testDeployer)
+	assert.Equal(t, contract.DeployTxHash, "0xd1")
+	assert.Equal(t, contract.DeployHeight, int64(1))
+	assert.Equal(t, contract.LastUpdateTxHash, "0xd2")
+	assert.Equal(t, contract.LastUpdateHeight, int64(4))
+	assert.Equal(t, contract.ContentType, "application/java")
+	assert.Equal(t, len(history), 3)
+	assert.Equal(t, history[0].Action, ContractActionDeploy)
+	assert.Equal(t, history[1].Action, ContractActionAccept)
+	assert.Equal(t, history[1].RefTxHash, "0xd1")
+	assert.Equal(t, history[2].Action, ContractActionUpdate)
+
+	callCounts, err := QueryCallCountsOfContracts(channelName, []string{testContract})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, callCounts[testContract], int64(2))
+
+	// The update is reverted with the blocks.
+	assert.Equal(t, DeleteBlocksFromHeight(channelName, 4), nil)
+	assert.Equal(t, QueryContractInChannelByAddress(channelName, testContract, &contract, &history), nil)
+	assert.Equal(t, contract.LastUpdateHeight, int64(0))
+	assert.Equal(t, contract.ContentType, "application/zip")
+	assert.Equal(t, len(history), 2)
+
+	// The contract is deleted with the block which deployed it.
+	assert.Equal(t, DeleteBlocksFromHeight(channelName, 1), nil)
+	count, _ = QueryContractsInChannel(channelName, 10, 0, &contracts)
+	assert.Equal(t, count, int64(0))
+}
@@ -0,0 +1,407 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Kind of crawl job.
+const (
+	CrawlJobRecrawl = "Recrawl"
+	CrawlJobRebuild = "Rebuild"
+)
+
+// Status of crawl job.
+const (
+	CrawlJobRunning   = "Running"
+	CrawlJobCanceling = "Canceling"
+	CrawlJobDone      = "Done"
+	CrawlJobFailed    = "Failed"
+	CrawlJobCanceled  = "Canceled"
+)
+
+// The count of blocks crawled in a step of crawl job. The cancellation is checked between the steps.
+const crawlJobBatchSize = 100
+
+// The count of blocks purged in a transaction by rebuild. The job is touched between the batches.
+var crawlJobPurgeBatchSize int64 = 1000
+
+// The running crawl job not updated in the duration is regarded as interrupted, like its process is killed.
+const crawlJobStaleTimeout = 10 * time.Minute
+
+// CrawlPause is the channel whose crawling is paused. Polling, block stream and backfill skip the channel.
+type CrawlPause struct {
+	gorm.Model
+	Channel   string `gorm:"type:VARCHAR(64);not null;index"`
+	Timestamp time.Time
+}
+
+// CrawlJob is the job to crawl the blocks again in channel, run by the server or the command.
+// Recrawl deletes the blocks between the heights and crawls them again. Rebuild purges the blocks not pruned
+// in channel and crawls them up to the last block height of the chain. The channel is not crawled by
+// polling, block stream and backfill while the job is running.
+type CrawlJob struct {
+	gorm.Model
+	Channel       string `gorm:"type:VARCHAR(64);not null;index"`
+	Kind          string `gorm:"type:VARCHAR(20);not null"` // Recrawl, Rebuild
+	BeginHeight   int64  `gorm:"type:BIGINT"`
+	EndHeight     int64  `gorm:"type:BIGINT"`
+	Status        string `gorm:"type:VARCHAR(20);not null;index"` // Running, Canceling, Done, Failed, Canceled
+	CountOfBlocks int64  // Blocks to crawl.
+	CountOfDone   int64  // Blocks crawled, including the failed ones.
+	CountOfFailed int64  // Blocks failed to be crawled. They are crawled again by backfill.
+	LastError     string `gorm:"type:VARCHAR(512)"`
+	FinishedAt    *time.Time
+}
+
+// crawlJobsLock serializes the creation of crawl jobs, not to run two jobs in a channel.
+var crawlJobsLock sync.Mutex
+
+// PauseCrawling pauses crawling in channel. The crawling in progress is not stopped.
+func PauseCrawling(channelName string) error {
+	paused, err := IsCrawlingPaused(channelName)
+	if err != nil || paused {
+		return err
+	}
+
+	pause := CrawlPause{Channel: channelName, Timestamp: time.Now()}
+	if err := Database().Create(&pause).Error; err != nil {
+		logger.Error(err.Error())
+		return isaacerror.SysErrFailToControlCrawler
+	}
+	logger.Infof("Crawling is paused in %s.", channelName)
+
+	return nil
+}
+
+// ResumeCrawling resumes crawling in channel paused.
+func ResumeCrawling(channelName string) error {
+	if err := Database().Unscoped().Where("channel = ?", channelName).Delete(&CrawlPause{}).Error; err != nil {
+		logger.Error(err.Error())
+		return isaacerror.SysErrFailToControlCrawler
+	}
+	logger.Infof("Crawling is resumed in %s.", channelName)
+
+	return nil
+}
+
+// IsCrawlingPaused returns true if crawling in channel is paused.
+func IsCrawlingPaused(channelName string) (bool, error) {
+	var count int64
+	if err := Database().Model(&CrawlPause{}).Where("channel = ?", channelName).Count(&count).Error; err != nil {
+		logger.Error(err.Error())
+		return false, isaacerror.SysErrFailToControlCrawler
+	}
+
+	return count > 0, nil
+}
+
+// isCrawlSuspended returns true if crawling in channel is disabled or paused, or a crawl job is running in channel.
+func isCrawlSuspended(channelName string) bool {
+	if c, ok := getChannelConf(channelName); ok && c.CrawlDisabled {
+		return true
+	}
+
+	paused, err := IsCrawlingPaused(channelName)
+	if err != nil || paused {
+		return paused
+	}
+
+	var job CrawlJob
+	return findRunningCrawlJob(channelName, &job) == nil
+}
+
+// findRunningCrawlJob finds the crawl job running in channel. The job interrupted is marked as failed.
+// Returns gorm.ErrRecordNotFound if no job is running.
+func findRunningCrawlJob(channelName string, out *CrawlJob) error {
+	var jobs []CrawlJob
+	if err := Database().Where("channel = ? AND status IN (?)",
+		channelName, []string{CrawlJobRunning, CrawlJobCanceling}).Find(&jobs).Error; err != nil {
+		return err
+	}
+
+	for i := range jobs {
+		if time.Since(jobs[i].UpdatedAt) < crawlJobStaleTimeout {
+			*out = jobs[i]
+			return nil
+		}
+
+		logger.Errorf("Crawl job %d in %s is interrupted.", jobs[i].ID, channelName)
+		_ = finishCrawlJob(&jobs[i], CrawlJobFailed, isaacerror.SysErrCrawlJobInterrupted)
+	}
+
+	return gorm.ErrRecordNotFound
+}
+
+// CreateCrawlJob creates the crawl job in channel, to be run by RunCrawlJob.
+// The heights are for Recrawl only. Rebuild finds them when it begins.
+func CreateCrawlJob(channelName string, kind string, beginHeight int64, endHeight int64, out *CrawlJob) error {
+
+	// Check arguments.
+	if channelName == "" || (kind != CrawlJobRecrawl && kind != CrawlJobRebuild) ||
+		(kind == CrawlJobRecrawl && (beginHeight <= 0 || beginHeight > endHeight)) {
+		logger.Errorf("Arguments is wrong. kind:%s, begin:%d, end:%d, channelName:%s",
+			kind, beginHeight, endHeight, channelName)
+		return isaacerror.SysErrInvalidCrawlJob
+	}
+
+	crawlJobsLock.Lock()
+	defer crawlJobsLock.Unlock()
+
+	var running CrawlJob
+	err := findRunningCrawlJob(channelName, &running)
+	if err == nil {
+		return isaacerror.SysErrCrawlJobRunning
+	} else if !gorm.IsRecordNotFoundError(err) {
+		logger.Error(err.Error())
+		return isaacerror.SysErrFailToQueryCrawlJobs
+	}
+
+	job := CrawlJob{
+		Channel: channelName,
+		Kind:    kind,
+		Status:  CrawlJobRunning,
+	}
+	if kind == CrawlJobRecrawl {
+		job.BeginHeight = beginHeight
+		job.EndHeight = endHeight
+		job.CountOfBlocks = endHeight - beginHeight + 1
+	}
+	if err := Database().Create(&job).Error; err != nil {
+		logger.Error(err.Error())
+		return isaacerror.SysErrFailToQueryCrawlJobs
+	}
+	logger.Infof("Crawl job %d is created. %s from %d to %d in %s",
+		job.ID, kind, job.BeginHeight, job.EndHeight, channelName)
+
+	*out = job
+	return nil
+}
+
+// RunCrawlJob runs the crawl job created until it is done, failed or canceled.
+// progress is called with the job after each step if it is not nil.
+func RunCrawlJob(job *CrawlJob, progress func(job *CrawlJob)) error {
+	nodes := getNodePool(job.Channel)
+
+	recordCrawling(job.Channel, true)
+	defer recordCrawling(job.Channel, false)
+
+	if job.Kind == CrawlJobRebuild {
+		if err := purgeChannelForRebuild(nodes, job); err != nil {
+			return finishCrawlJob(job, CrawlJobFailed, err)
+		}
+		if progress != nil {
+			progress(job)
+		}
+	}
+
+	for h := job.BeginHeight + job.CountOfDone; h <= job.EndHeight; h += crawlJobBatchSize {
+		var current CrawlJob
+		if err := Database().Select("status").Where("id = ?", job.ID).First(&current).Error; err != nil {
+			return finishCrawlJob(job, CrawlJobFailed, err)
+		}
+		if current.Status == CrawlJobCanceling {
+			return finishCrawlJob(job, CrawlJobCanceled, nil)
+		}
+
+		end := h + crawlJobBatchSize - 1
+		if end > job.EndHeight {
+			end = job.EndHeight
+		}
+		countOfFailed, err := recrawlBlocks(nodes, job.Channel, h, end)
+		if err != nil {
+			return finishCrawlJob(job, CrawlJobFailed, err)
+		}
+
+		// Update the progress only, not to overwrite the cancellation by others.
+		job.CountOfDone += end - h + 1
+		job.CountOfFailed += countOfFailed
+		if err := Database().Model(job).Updates(map[string]interface{}{
+			"count_of_done":   job.CountOfDone,
+			"count_of_failed": job.CountOfFailed,
+		}).Error; err != nil {
+			return finishCrawlJob(job, CrawlJobFailed, err)
+		}
+		if progress != nil {
+			progress(job)
+		}
+	}
+
+	return finishCrawlJob(job, CrawlJobDone, nil)
+}
+
+// purgeChannelForRebuild deletes the blocks not pruned in channel with the crawl state of them,
+// and sets the heights of job from the lowest height to crawl to the last block height of the chain.
+func purgeChannelForRebuild(nodes *nodePool, job *CrawlJob) error {
+	lastHeight, err := nodes.getLastBlockHeight()
+	if err != nil {
+		return err
+	}
+	prunedHeight, err := getPrunedHeight(job.Channel)
+	if err != nil {
+		return err
+	}
+	floorHeight, err := getCrawlFloorHeight(job.Channel)
+	if err != nil {
+		return err
+	}
+
+	unlock := lockChannelCrawl(job.Channel)
+	defer unlock()
+
+	topHeight := CurrentStore().GetCurrentBlockHeight(job.Channel)
+	if topHeight < 0 {
+		return isaacerror.SysErrFailToQueryBlocksInChannel
+	}
+
+	// Purge in batches from the lowest height, and touch the job after each batch.
+	// Then the job purging a large channel is not regarded as interrupted.
+	logger.Infof("Purge the blocks from %d to %d in %s to rebuild.", prunedHeight+1, topHeight, job.Channel)
+	for h := prunedHeight + 1; h <= topHeight; h += crawlJobPurgeBatchSize {
+		if _, err := CurrentStore().DeleteBlocksInRange(job.Channel, h, h+crawlJobPurgeBatchSize-1); err != nil {
+			return err
+		}
+		if err := Database().Model(job).Update("updated_at", time.Now()).Error; err != nil {
+			return err
+		}
+	}
+	for _, model := range []interface{}{&CrawlRange{}, &QuarantinedBlock{}} {
+		if err := Database().Unscoped().Where("channel = ?", job.Channel).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	job.BeginHeight = floorHeight + 1
+	job.EndHeight = lastHeight
+	job.CountOfBlocks = 0
+	if lastHeight > floorHeight {
+		job.CountOfBlocks = lastHeight - floorHeight
+	}
+	return Database().Model(job).Updates(map[string]interface{}{
+		"begin_height":    job.BeginHeight,
+		"end_height":      job.EndHeight,
+		"count_of_blocks": job.CountOfBlocks,
+	}).Error
+}
+
+// recrawlBlocks deletes the blocks between the heights in channel and crawls them again.
+// Returns the count of blocks failed to be crawled.
+func recrawlBlocks(nodes *nodePool, channelName string, beginHeight int64, endHeight int64) (int64, error) {
+	unlock := lockChannelCrawl(channelName)
+	defer unlock()
+
+	if _, err := CurrentStore().DeleteBlocksInRange(channelName, beginHeight, endHeight); err != nil {
+		return 0, err
+	}
+	if err := crawlAndStoreBlock(nodes, channelName, beginHeight, endHeight); err != nil {
+		return 0, err
+	}
+
+	missing, err := FindMissingBlockHeights(channelName, beginHeight, endHeight)
+	if err != nil {
+		return 0, err
+	}
+	var countOfFailed int64
+	for _, r := range missing {
+		countOfFailed += r.End - r.Begin + 1
+	}
+
+	return countOfFailed, nil
+}
+
+// finishCrawlJob records the crawl job finished in the status. Returns the error.
+func finishCrawlJob(job *CrawlJob, status string, err error) error {
+	now := time.Now()
+	job.Status = status
+	job.FinishedAt = &now
+	if err != nil {
+		job.LastError = err.Error()
+		if len(job.LastError) > 512 {
+			job.LastError = job.LastError[:512]
+		}
+		logger.Errorf("Crawl job %d in %s is failed. %s", job.ID, job.Channel, err)
+	} else {
+		logger.Infof("Crawl job %d in %s is %s. %d of %d blocks are crawled.",
+			job.ID, job.Channel, status, job.CountOfDone, job.CountOfBlocks)
+	}
+
+	if dbErr := Database().Model(job).Updates(map[string]interface{}{
+		"status":      job.Status,
+		"finished_at": job.FinishedAt,
+		"last_error":  job.LastError,
+	}).Error; dbErr != nil {
+		logger.Error(dbErr.Error())
+	}
+
+	return err
+}
+
+// CancelCrawlJob requests the running crawl job to stop. The job stops after the step in progress.
+func CancelCrawlJob(id uint, out *CrawlJob) error {
+	if err := QueryCrawlJob(id, out); err != nil {
+		return err
+	}
+	if out.Status != CrawlJobRunning {
+		return isaacerror.SysErrCrawlJobNotRunning
+	}
+
+	// The job interrupted is not stopped by itself.
+	if time.Since(out.UpdatedAt) >= crawlJobStaleTimeout {
+		_ = finishCrawlJob(out, CrawlJobCanceled, nil)
+		return nil
+	}
+
+	if err := Database().Model(out).Where("status = ?", CrawlJobRunning).Update(
+		"status", CrawlJobCanceling).Error; err != nil {
+		logger.Error(err.Error())
+		return isaacerror.SysErrFailToQueryCrawlJobs
+	}
+	logger.Infof("Crawl job %d in %s is canceled.", out.ID, out.Channel)
+
+	return nil
+}
+
+// QueryCrawlJob queries the crawl job of the ID.
+func QueryCrawlJob(id uint, out *CrawlJob) error {
+	err := Database().Where("id = ?", id).First(out).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return isaacerror.SysErrNoCrawlJob
+	} else if err != nil {
+		logger.Error(err.Error())
+		return isaacerror.SysErrFailToQueryCrawlJobs
+	}
+
+	return nil
+}
+
+// QueryCrawlJobs queries the crawl jobs in channel ordered by the latest. All channels if channel is empty.
+// Returns the count of jobs.
+func QueryCrawlJobs(channelName string, limit int, offset int, out *[]CrawlJob) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, channelName:%s", limit, offset, channelName)
+		return -1, isaacerror.SysErrFailToQueryCrawlJobs
+	}
+
+	table := Database().Model(&CrawlJob{})
+	if channelName != "" {
+		table = table.Where("channel = ?", channelName)
+	}
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		logger.Error(err.Error())
+		return -1, isaacerror.SysErrFailToQueryCrawlJobs
+	}
+	if err := table.Order("id desc").Limit(limit).Offset(offset).Find(out).Error; err != nil {
+		logger.Error(err.Error())
+		return -1, isaacerror.SysErrFailToQueryCrawlJobs
+	}
+
+	return count, nil
+}
@@ -0,0 +1,204 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestPauseCrawling(t *testing.T) {
+	dbpath := "test_crawl_pause.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_pause"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	// Paused twice is same with once.
+	assert.Equal(t, PauseCrawling(channelName), nil)
+	assert.Equal(t, PauseCrawling(channelName), nil)
+	paused, err := IsCrawlingPaused(channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, paused, true)
+
+	// Nothing is crawled while paused.
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(0))
+
+	var status CrawlerStatus
+	assert.Equal(t, QueryCrawlerStatus(channelName, &status), nil)
+	assert.Equal(t, status.Paused, true)
+	assert.Equal(t, status.LagInBlocks, int64(10))
+
+	// Nothing is crawled if paused while waiting for the lock of crawling.
+	assert.Equal(t, ResumeCrawling(channelName), nil)
+	unlock := lockChannelCrawl(channelName)
+	requests := node.countOfRequests()
+	done := make(chan error)
+	go func() { done <- crawlBlockchain(channelName) }()
+	waitFor(t, 5*time.Second, func() bool { return node.countOfRequests() > requests })
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, PauseCrawling(channelName), nil)
+	unlock()
+	assert.Equal(t, <-done, nil)
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(0))
+
+	assert.Equal(t, ResumeCrawling(channelName), nil)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(10))
+}
+
+func TestRecrawlJob(t *testing.T) {
+	dbpath := "test_crawl_job.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_job"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// The block stored with bad data.
+	Database().Model(&Block{}).Where("channel = ? AND block_height = ?", channelName, 5).Update(
+		"block_hash", "0xbad")
+
+	var job CrawlJob
+	assert.Equal(t, CreateCrawlJob(channelName, CrawlJobRecrawl, 7, 3, &job), isaacerror.SysErrInvalidCrawlJob)
+	assert.Equal(t, CreateCrawlJob(channelName, "Unknown", 3, 7, &job), isaacerror.SysErrInvalidCrawlJob)
+	assert.Equal(t, CreateCrawlJob(channelName, CrawlJobRecrawl, 3, 7, &job), nil)
+	assert.Equal(t, job.Status, CrawlJobRunning)
+	assert.Equal(t, job.CountOfBlocks, int64(5))
+
+	// A job at once in channel, and the channel is not crawled by others while it is running.
+	var another CrawlJob
+	assert.Equal(t, CreateCrawlJob(channelName, CrawlJobRebuild, 0, 0, &another), isaacerror.SysErrCrawlJobRunning)
+	assert.Equal(t, isCrawlSuspended(channelName), true)
+
+	progresses := 0
+	assert.Equal(t, RunCrawlJob(&job, func(*CrawlJob) { progresses++ }), nil)
+	assert.Equal(t, progresses, 1)
+	assert.Equal(t, isCrawlSuspended(channelName), false)
+
+	assert.Equal(t, QueryCrawlJob(job.ID, &job), nil)
+	assert.Equal(t, job.Status, CrawlJobDone)
+	assert.Equal(t, job.CountOfDone, int64(5))
+	assert.Equal(t, job.CountOfFailed, int64(0))
+	assert.NotEqual(t, job.FinishedAt, nil)
+
+	var block Block
+	assert.Equal(t, QueryBlockByHeightInChannel(channelName, 5, &block), nil)
+	assert.Equal(t, block.BlockHash, fakeBlockHash(node.blocks[5]))
+	mismatch, _ := findBrokenLinkage(channelName, 1, 10)
+	assert.Equal(t, mismatch, int64(-1))
+
+	// The token balances are not summed twice.
+	var balances []TokenBalance
+	_, _ = QueryTokenHoldersInChannel(channelName, "cx0000000000000000000000000000000000000001", 10, 0, &balances)
+	assert.Equal(t, balances[0].Balance, "10")
+
+	// Canceled before it begins.
+	assert.Equal(t, CreateCrawlJob(channelName, CrawlJobRecrawl, 1, 10, &job), nil)
+	assert.Equal(t, CancelCrawlJob(job.ID, &job), nil)
+	assert.Equal(t, RunCrawlJob(&job, nil), nil)
+	assert.Equal(t, QueryCrawlJob(job.ID, &job), nil)
+	assert.Equal(t, job.Status, CrawlJobCanceled)
+	assert.Equal(t, job.CountOfDone, int64(0))
+	assert.Equal(t, CancelCrawlJob(job.ID, &job), isaacerror.SysErrCrawlJobNotRunning)
+	assert.Equal(t, CancelCrawlJob(job.ID+1, &job), isaacerror.SysErrNoCrawlJob)
+
+	var jobs []CrawlJob
+	count, err := QueryCrawlJobs(channelName, 10, 0, &jobs)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(2))
+	assert.Equal(t, jobs[0].Status, CrawlJobCanceled)
+	count, _ = QueryCrawlJobs("channel_other", 10, 0, &jobs)
+	assert.Equal(t, count, int64(0))
+}
+
+func TestRebuildJob(t *testing.T) {
+	dbpath := "test_rebuild_job.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_rebuild"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	node.setFailHeight(4, true)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	node.setFailHeight(4, false)
+	node.appendBlocks(15)
+
+	var job CrawlJob
+	assert.Equal(t, CreateCrawlJob(channelName, CrawlJobRebuild, 0, 0, &job), nil)
+
+	// Every batch deleted takes as long as the stale timeout. The job is not regarded as interrupted by it.
+	crawlJobPurgeBatchSize = 4
+	defer func() { crawlJobPurgeBatchSize = 1000 }()
+	store := &slowDeleteStore{Store: CurrentStore(), deleted: func() {
+		var running CrawlJob
+		assert.Equal(t, findRunningCrawlJob(channelName, &running), nil)
+		Database().Model(&job).UpdateColumn("updated_at", time.Now().Add(-crawlJobStaleTimeout))
+	}}
+	SetStore(store)
+	defer SetStore(store.Store)
+
+	assert.Equal(t, RunCrawlJob(&job, nil), nil)
+	assert.Equal(t, store.countOfDeletes, 4) // 3 batches of purge and a step of crawl.
+	assert.Equal(t, job.Status, CrawlJobDone)
+	assert.Equal(t, job.BeginHeight, int64(1))
+	assert.Equal(t, job.EndHeight, int64(15))
+	assert.Equal(t, job.CountOfDone, int64(15))
+
+	missing, _ := FindMissingBlockHeights(channelName, 1, 15)
+	assert.Equal(t, len(missing), 0)
+	assert.Equal(t, GetCrawlCursor(channelName), int64(15))
+
+	var ranges []CrawlRange
+	_ = QueryCrawlRanges(channelName, CrawlRangeFailed, &ranges)
+	assert.Equal(t, len(ranges), 0)
+}
+
+// slowDeleteStore calls deleted after deleting blocks in range, as if it takes long.
+type slowDeleteStore struct {
+	Store
+	deleted        func()
+	countOfDeletes int
+}
+
+func (s *slowDeleteStore) DeleteBlocksInRange(channelName string, beginHeight int64, endHeight int64) (int64, error) {
+	s.countOfDeletes++
+	count, err := s.Store.DeleteBlocksInRange(channelName, beginHeight, endHeight)
+	s.deleted()
+	return count, err
+}
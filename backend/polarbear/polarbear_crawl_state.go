@@ -0,0 +1,322 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Status of the crawled range.
+const (
+	CrawlRangeDone   = "Done"
+	CrawlRangeFailed = "Failed"
+)
+
+// The count of trial to crawl a missing block in a backfill.
+const countOfBackfillTrial = 3
+
+// The maximum count of missing blocks to crawl in a backfill of channel.
+const maxBackfillBlockCount = 1000
+
+// CrawlRange is the range of block heights that polarbear has crawled in channel.
+// Ranges in a channel don't overlap each other. The adjacent done ranges are merged into one.
+type CrawlRange struct {
+	gorm.Model
+	Channel      string `gorm:"type:VARCHAR(64);not null;index"`
+	BeginHeight  int64  `gorm:"type:BIGINT;not null;index"`
+	EndHeight    int64  `gorm:"type:BIGINT;not null;index"`
+	Status       string `gorm:"type:VARCHAR(20);not null;index"` // Done, Failed
+	CountOfTrial int
+	LastError    string `gorm:"type:VARCHAR(512)"`
+	Timestamp    time.Time
+}
+
+// HeightRange is the range of block heights from Begin to End, including both.
+type HeightRange struct {
+	Begin int64
+	End   int64
+}
+
+// SetCrawlRangeStatus records the status of the range between the heights in channel.
+// The overlapped part of other ranges is replaced with the new one.
+func SetCrawlRangeStatus(channelName string, beginHeight int64, endHeight int64, status string, errMsg string) error {
+
+	// Check arguments.
+	if beginHeight > endHeight || channelName == "" {
+		logger.Errorf("Arguments is wrong. begin:%d, end:%d, channelName:%s", beginHeight, endHeight, channelName)
+		return isaacerror.SysErrFailToUpdateCrawlState
+	}
+
+	if len(errMsg) > 512 {
+		errMsg = errMsg[:512]
+	}
+
+	tx := Database().Begin()
+
+	// Query ranges overlapped or adjacent with the new range.
+	var ranges []CrawlRange
+	if err := tx.Where("channel = ? AND end_height >= ? AND begin_height <= ?",
+		channelName, beginHeight-1, endHeight+1).Find(&ranges).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	newRange := CrawlRange{
+		Channel:      channelName,
+		BeginHeight:  beginHeight,
+		EndHeight:    endHeight,
+		Status:       status,
+		CountOfTrial: 1,
+		LastError:    errMsg,
+		Timestamp:    time.Now(),
+	}
+
+	var remains []CrawlRange
+	for _, r := range ranges {
+		overlapped := r.EndHeight >= beginHeight && r.BeginHeight <= endHeight
+
+		if r.Status == status && status == CrawlRangeDone {
+			// Merge the done ranges.
+			if r.BeginHeight < newRange.BeginHeight {
+				newRange.BeginHeight = r.BeginHeight
+			}
+			if r.EndHeight > newRange.EndHeight {
+				newRange.EndHeight = r.EndHeight
+			}
+		} else if !overlapped {
+			continue
+		} else {
+			// Keep the count of trial for the failed range tried again.
+			if r.Status == CrawlRangeFailed && r.CountOfTrial >= newRange.CountOfTrial {
+				newRange.CountOfTrial = r.CountOfTrial + 1
+			}
+
+			// Keep the parts of range not overlapped.
+			if r.BeginHeight < beginHeight {
+				head := r
+				head.Model = gorm.Model{}
+				head.EndHeight = beginHeight - 1
+				remains = append(remains, head)
+			}
+			if r.EndHeight > endHeight {
+				tail := r
+				tail.Model = gorm.Model{}
+				tail.BeginHeight = endHeight + 1
+				remains = append(remains, tail)
+			}
+		}
+
+		if err := tx.Unscoped().Delete(&r).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	remains = append(remains, newRange)
+	for i := range remains {
+		if err := tx.Create(&remains[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// QueryCrawlRanges queries the crawled ranges in channel ordered by height.
+func QueryCrawlRanges(channelName string, status string, out *[]CrawlRange) error {
+	table := Database().Where("channel = ?", channelName)
+	if status != "" {
+		table = table.Where("status = ?", status)
+	}
+
+	if err := table.Order("begin_height asc").Find(out).Error; err != nil {
+		return isaacerror.SysErrFailToQueryCrawlState
+	}
+
+	return nil
+}
+
+// GetCrawlCursor returns the height that polarbear has crawled up to in channel.
+// If there is no crawled range, returns the current block height in DB.
+func GetCrawlCursor(channelName string) int64 {
+	var crawlRange CrawlRange
+	if err := Database().Where("channel = ?", channelName).Order(
+		"end_height desc").First(&crawlRange).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return GetCurrentBlockHeightInDB(channelName)
+		}
+		logger.Errorf("%s", err)
+		return -1
+	}
+
+	return crawlRange.EndHeight
+}
+
+// recordCrawlResult records the crawled range between the heights except failed heights as done,
+// and each failed height as failed.
+func recordCrawlResult(channelName string, beginHeight int64, endHeight int64, failures map[int64]error) error {
+	failedHeights := make([]int64, 0, len(failures))
+	for h := range failures {
+		failedHeights = append(failedHeights, h)
+	}
+	sort.Slice(failedHeights, func(i, j int) bool { return failedHeights[i] < failedHeights[j] })
+
+	begin := beginHeight
+	for _, h := range failedHeights {
+		if begin < h {
+			if err := SetCrawlRangeStatus(channelName, begin, h-1, CrawlRangeDone, ""); err != nil {
+				return err
+			}
+		}
+		if err := SetCrawlRangeStatus(channelName, h, h, CrawlRangeFailed, failures[h].Error()); err != nil {
+			return err
+		}
+		begin = h + 1
+	}
+	if begin <= endHeight {
+		return SetCrawlRangeStatus(channelName, begin, endHeight, CrawlRangeDone, "")
+	}
+
+	return nil
+}
+
+// FindMissingBlockHeights finds the ranges of block heights not stored between the heights in channel.
+func FindMissingBlockHeights(channelName string, beginHeight int64, endHeight int64) ([]HeightRange, error) {
+	missing := make([]HeightRange, 0)
+	if beginHeight > endHeight {
+		return missing, nil
+	}
+
+	return CurrentStore().FindMissingBlockHeights(channelName, beginHeight, endHeight)
+}
+
+// getCrawlStartHeight returns the lowest block height to crawl in channel. 0 if it is not configured.
+func getCrawlStartHeight(channelName string) int64 {
+	c, _ := getChannelConf(channelName)
+	return c.StartHeight
+}
+
+// getCrawlFloorHeight returns the height that the blocks up to it are not crawled in channel.
+// The blocks pruned or below the start height are not missing.
+func getCrawlFloorHeight(channelName string) (int64, error) {
+	floorHeight, err := getPrunedHeight(channelName)
+	if err != nil {
+		return -1, err
+	}
+	if startHeight := getCrawlStartHeight(channelName); startHeight-1 > floorHeight {
+		floorHeight = startHeight - 1
+	}
+
+	return floorHeight, nil
+}
+
+// backfillBlocks crawls the missing blocks with the worker pool of channel, and the failed blocks again with retries.
+// Returns the error by height still failed.
+func backfillBlocks(nodes *nodePool, channelName string, heights []int64) map[int64]error {
+	failures := make(map[int64]error)
+	for i := 0; i < countOfBackfillTrial && len(heights) != 0; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(i) * durationInMiliSec * time.Millisecond)
+		}
+
+		failures = crawlHeightsInPool(nodes, channelName, heights)
+		heights = make([]int64, 0, len(failures))
+		for h := range failures {
+			heights = append(heights, h)
+		}
+		sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	}
+
+	return failures
+}
+
+// backfillChannel crawls the missing blocks up to the crawl cursor in channel.
+// Returns the count of blocks still missing.
+func backfillChannel(nodes *nodePool, channelName string) (int64, error) {
+	if isCrawlSuspended(channelName) {
+		return 0, nil
+	}
+
+	unlock := lockChannelCrawl(channelName)
+	defer unlock()
+
+	// Check again, crawling may be paused or a crawl job may begin while waiting for the lock.
+	if isCrawlSuspended(channelName) {
+		return 0, nil
+	}
+
+	cursor := GetCrawlCursor(channelName)
+	if cursor <= 0 {
+		return 0, nil
+	}
+
+	floorHeight, err := getCrawlFloorHeight(channelName)
+	if err != nil {
+		return -1, err
+	}
+
+	missing, err := FindMissingBlockHeights(channelName, floorHeight+1, cursor)
+	if err != nil {
+		return -1, err
+	}
+
+	var countOfMissing, countOfTried int64
+	for _, r := range missing {
+		countOfMissing += r.End - r.Begin + 1
+	}
+	if countOfMissing == 0 {
+		logger.Debugf("Blocks in %s are complete up to %d.", channelName, cursor)
+		return 0, nil
+	}
+	logger.Infof("Backfill %d missing blocks in %s.", countOfMissing, channelName)
+
+	// The quarantined blocks are crawled again only when the operator retries them.
+	quarantined, err := queryPendingQuarantinedHeights(channelName)
+	if err != nil {
+		return -1, err
+	}
+
+	heights := make([]int64, 0)
+	for _, r := range missing {
+		for h := r.Begin; h <= r.End && countOfTried < maxBackfillBlockCount; h++ {
+			if quarantined[h] {
+				continue
+			}
+			countOfTried++
+			heights = append(heights, h)
+		}
+	}
+
+	failures := backfillBlocks(nodes, channelName, heights)
+	for _, h := range heights {
+		if err, failed := failures[h]; failed {
+			logger.Errorf("Fail to backfill %d block in %s. %s", h, channelName, err)
+			if err := SetCrawlRangeStatus(channelName, h, h, CrawlRangeFailed, err.Error()); err != nil {
+				return -1, err
+			}
+			continue
+		}
+
+		if err := SetCrawlRangeStatus(channelName, h, h, CrawlRangeDone, ""); err != nil {
+			return -1, err
+		}
+		countOfMissing--
+	}
+
+	return countOfMissing, nil
+}
+
+func cronJobForBackfill(channelNames []string) {
+	for _, channelName := range channelNames {
+		countOfMissing, err := backfillChannel(getNodePool(channelName), channelName)
+		if err != nil {
+			logger.Errorf("Fail to backfill in %s. %s", channelName, err)
+		} else if countOfMissing > 0 {
+			logger.Infof("%d blocks are still missing in %s.", countOfMissing, channelName)
+		}
+	}
+}
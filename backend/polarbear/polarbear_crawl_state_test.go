@@ -0,0 +1,200 @@
+package polarbear
+
+import (
+	"errors"
+	conf "motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestSetCrawlRangeStatus(t *testing.T) {
+	dbpath := "test_crawl_range.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	channelName := "channel1"
+
+	// The adjacent done ranges are merged.
+	assert.Equal(t, SetCrawlRangeStatus(channelName, 1, 10, CrawlRangeDone, ""), nil)
+	assert.Equal(t, SetCrawlRangeStatus(channelName, 11, 20, CrawlRangeDone, ""), nil)
+
+	var ranges []CrawlRange
+	_ = QueryCrawlRanges(channelName, "", &ranges)
+	assert.Equal(t, len(ranges), 1)
+	assert.Equal(t, ranges[0].BeginHeight, int64(1))
+	assert.Equal(t, ranges[0].EndHeight, int64(20))
+	assert.Equal(t, GetCrawlCursor(channelName), int64(20))
+
+	// The failed height splits the done range.
+	assert.Equal(t, SetCrawlRangeStatus(channelName, 15, 15, CrawlRangeFailed, "fail"), nil)
+	ranges = nil
+	_ = QueryCrawlRanges(channelName, "", &ranges)
+	assert.Equal(t, len(ranges), 3)
+	assert.Equal(t, ranges[0].EndHeight, int64(14))
+	assert.Equal(t, ranges[1].Status, CrawlRangeFailed)
+	assert.Equal(t, ranges[1].BeginHeight, int64(15))
+	assert.Equal(t, ranges[2].BeginHeight, int64(16))
+
+	// The count of trial increases if it fails again.
+	assert.Equal(t, SetCrawlRangeStatus(channelName, 15, 15, CrawlRangeFailed, "fail"), nil)
+	ranges = nil
+	_ = QueryCrawlRanges(channelName, CrawlRangeFailed, &ranges)
+	assert.Equal(t, len(ranges), 1)
+	assert.Equal(t, ranges[0].CountOfTrial, 2)
+
+	// Done range of the failed height is merged into one again.
+	assert.Equal(t, SetCrawlRangeStatus(channelName, 15, 15, CrawlRangeDone, ""), nil)
+	ranges = nil
+	_ = QueryCrawlRanges(channelName, "", &ranges)
+	assert.Equal(t, len(ranges), 1)
+	assert.Equal(t, ranges[0].BeginHeight, int64(1))
+	assert.Equal(t, ranges[0].EndHeight, int64(20))
+
+	// Wrong arguments.
+	assert.NotEqual(t, SetCrawlRangeStatus(channelName, 10, 1, CrawlRangeDone, ""), nil)
+	assert.NotEqual(t, SetCrawlRangeStatus("", 1, 10, CrawlRangeDone, ""), nil)
+}
+
+func TestRecordCrawlResult(t *testing.T) {
+	dbpath := "test_crawl_result.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	channelName := "channel1"
+	failures := map[int64]error{
+		3: errors.New("fail"),
+		4: errors.New("fail"),
+		9: errors.New("fail"),
+	}
+	assert.Equal(t, recordCrawlResult(channelName, 1, 10, failures), nil)
+
+	var ranges []CrawlRange
+	_ = QueryCrawlRanges(channelName, CrawlRangeFailed, &ranges)
+	assert.Equal(t, len(ranges), 3)
+
+	ranges = nil
+	_ = QueryCrawlRanges(channelName, CrawlRangeDone, &ranges)
+	assert.Equal(t, len(ranges), 3)
+	assert.Equal(t, ranges[0].EndHeight, int64(2))
+	assert.Equal(t, ranges[1].BeginHeight, int64(5))
+	assert.Equal(t, ranges[1].EndHeight, int64(8))
+	assert.Equal(t, ranges[2].BeginHeight, int64(10))
+
+	// The cursor is the end of crawled ranges, even if the last height failed.
+	assert.Equal(t, GetCrawlCursor(channelName), int64(10))
+}
+
+func TestBackfillMissingBlocks(t *testing.T) {
+	dbpath := "test_backfill.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(30)
+	defer node.close()
+
+	channelName := "channel1"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	// Crawling doesn't stop at the failed heights.
+	node.setFailHeight(5, true)
+	node.setFailHeight(17, true)
+	node.setFailHeight(18, true)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCrawlCursor(channelName), int64(30))
+
+	missing, err := FindMissingBlockHeights(channelName, 1, 30)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, missing, []HeightRange{{Begin: 5, End: 5}, {Begin: 17, End: 18}})
+
+	var ranges []CrawlRange
+	_ = QueryCrawlRanges(channelName, CrawlRangeFailed, &ranges)
+	assert.Equal(t, len(ranges), 3)
+
+	// The block deleted out of band is also found.
+	Database().Unscoped().Where("channel = ? AND block_height = ?", channelName, 25).Delete(&Block{})
+	missing, _ = FindMissingBlockHeights(channelName, 1, 30)
+	assert.Equal(t, len(missing), 3)
+
+	// Backfill the missing blocks. The height 18 still fails.
+	node.setFailHeight(5, false)
+	node.setFailHeight(17, false)
+	countOfMissing, err := backfillChannel(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfMissing, int64(1))
+
+	missing, _ = FindMissingBlockHeights(channelName, 1, 30)
+	assert.Equal(t, missing, []HeightRange{{Begin: 18, End: 18}})
+
+	// All blocks are filled after the node recovers.
+	node.setFailHeight(18, false)
+	countOfMissing, err = backfillChannel(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfMissing, int64(0))
+
+	ranges = nil
+	_ = QueryCrawlRanges(channelName, "", &ranges)
+	assert.Equal(t, len(ranges), 1)
+	assert.Equal(t, ranges[0].Status, CrawlRangeDone)
+	assert.Equal(t, ranges[0].EndHeight, int64(30))
+
+	for h := int64(1); h <= 30; h++ {
+		var blocks []Block
+		_ = QueryBlockHashesInRange(channelName, h, h, &blocks)
+		assert.Equal(t, len(blocks), 1)
+		assert.Equal(t, blocks[0].BlockHash, node.blockHash(h))
+	}
+}
+
+func TestCrawlStartHeight(t *testing.T) {
+	dbpath := "test_crawl_start_height.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_start_height"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	// Nothing is crawled in the channel disabled.
+	conf.Conf().Channel[0].CrawlDisabled = true
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(0))
+
+	// The new channel is crawled from the start height.
+	conf.Conf().Channel[0].CrawlDisabled = false
+	conf.Conf().Channel[0].StartHeight = 6
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(10))
+	assert.Equal(t, GetCrawlCursor(channelName), int64(10))
+
+	// The blocks below the start height are not missing.
+	countOfMissing, err := backfillChannel(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfMissing, int64(0))
+
+	missing, _ := FindMissingBlockHeights(channelName, 1, 10)
+	assert.Equal(t, missing, []HeightRange{{Begin: 1, End: 5}})
+
+	var job CrawlJob
+	assert.Equal(t, CreateCrawlJob(channelName, CrawlJobRebuild, 0, 0, &job), nil)
+	assert.Equal(t, RunCrawlJob(&job, nil), nil)
+	assert.Equal(t, job.BeginHeight, int64(6))
+	assert.Equal(t, job.CountOfDone, int64(5))
+}
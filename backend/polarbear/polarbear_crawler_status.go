@@ -0,0 +1,172 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"sync"
+	"time"
+)
+
+// crawlerRateWindowInSec is the window to measure the blocks stored per second.
+const crawlerRateWindowInSec = 60
+
+// CrawlerStatus is the status of crawling in channel.
+type CrawlerStatus struct {
+	Channel         string
+	DBHeight        int64   // Highest block height in DB.
+	ChainHeight     int64   // Last block height of the chain seen by the crawler. -1 if not seen yet.
+	LagInBlocks     int64   // Blocks of the chain not in DB yet.
+	LagInSeconds    int64   // From the block at DB height to the time when the chain height is seen.
+	BlocksPerSecond float64 // Blocks stored per second in the last minute.
+	Crawling        bool
+	Streaming       bool
+	Paused          bool
+	PendingTxs      int64     // Txs whose result is not fetched yet.
+	LastSuccess     time.Time // Last time to store a block, or to see DB is up to date.
+	LastError       string
+	LastErrorTime   time.Time
+	CountOfError    int64  // Errors since the server started.
+	NodeIP          string // Node of the last block stored.
+}
+
+// crawlerProgress is the progress of crawling in channel, recorded by the crawler.
+type crawlerProgress struct {
+	chainHeight   int64
+	chainHeightAt time.Time
+	crawling      bool
+	lastSuccess   time.Time
+	lastError     string
+	lastErrorTime time.Time
+	countOfError  int64
+	nodeIP        string
+
+	// Count of blocks stored in each second of the window, and the second of the count.
+	storedSeconds [crawlerRateWindowInSec]int64
+	storedCounts  [crawlerRateWindowInSec]int64
+}
+
+var crawlerProgresses = make(map[string]*crawlerProgress)
+var crawlerProgressesLock sync.Mutex
+
+// updateCrawlerProgress updates the progress of crawling in channel.
+func updateCrawlerProgress(channelName string, update func(progress *crawlerProgress)) {
+	crawlerProgressesLock.Lock()
+	defer crawlerProgressesLock.Unlock()
+
+	progress, ok := crawlerProgresses[channelName]
+	if !ok {
+		progress = &crawlerProgress{chainHeight: -1}
+		crawlerProgresses[channelName] = progress
+	}
+	update(progress)
+}
+
+// recordChainHeight records the last block height of the chain seen by the crawler.
+func recordChainHeight(channelName string, height int64) {
+	updateCrawlerProgress(channelName, func(progress *crawlerProgress) {
+		if height >= progress.chainHeight {
+			progress.chainHeight = height
+			progress.chainHeightAt = time.Now()
+		}
+	})
+}
+
+// recordCrawling records the crawler begins or ends to crawl the channel.
+func recordCrawling(channelName string, crawling bool) {
+	updateCrawlerProgress(channelName, func(progress *crawlerProgress) {
+		progress.crawling = crawling
+	})
+}
+
+// recordCrawlSuccess records the crawler has DB up to date.
+func recordCrawlSuccess(channelName string) {
+	updateCrawlerProgress(channelName, func(progress *crawlerProgress) {
+		progress.lastSuccess = time.Now()
+	})
+}
+
+// recordBlockStored records the block is stored from the node.
+func recordBlockStored(channelName string, nodeIP string) {
+	now := time.Now()
+	updateCrawlerProgress(channelName, func(progress *crawlerProgress) {
+		progress.lastSuccess = now
+		progress.nodeIP = nodeIP
+
+		second := now.Unix()
+		i := second % crawlerRateWindowInSec
+		if progress.storedSeconds[i] != second {
+			progress.storedSeconds[i] = second
+			progress.storedCounts[i] = 0
+		}
+		progress.storedCounts[i]++
+	})
+}
+
+// recordCrawlError records the error of crawling in channel.
+func recordCrawlError(channelName string, err error) {
+	updateCrawlerProgress(channelName, func(progress *crawlerProgress) {
+		progress.lastError = err.Error()
+		progress.lastErrorTime = time.Now()
+		progress.countOfError++
+	})
+}
+
+// QueryCrawlerStatus queries the status of crawling in channel.
+func QueryCrawlerStatus(channelName string, out *CrawlerStatus) error {
+	dbHeight := GetCurrentBlockHeightInDB(channelName)
+	if dbHeight < 0 {
+		return isaacerror.SysErrFailToQueryCrawlerStatus
+	}
+
+	now := time.Now()
+	status := CrawlerStatus{Channel: channelName, DBHeight: dbHeight, ChainHeight: -1}
+	var chainHeightAt time.Time
+
+	crawlerProgressesLock.Lock()
+	if progress, ok := crawlerProgresses[channelName]; ok {
+		status.ChainHeight = progress.chainHeight
+		status.Crawling = progress.crawling
+		status.LastSuccess = progress.lastSuccess
+		status.LastError = progress.lastError
+		status.LastErrorTime = progress.lastErrorTime
+		status.CountOfError = progress.countOfError
+		status.NodeIP = progress.nodeIP
+		chainHeightAt = progress.chainHeightAt
+
+		var stored int64
+		for i := range progress.storedSeconds {
+			if now.Unix()-progress.storedSeconds[i] < crawlerRateWindowInSec {
+				stored += progress.storedCounts[i]
+			}
+		}
+		status.BlocksPerSecond = float64(stored) / crawlerRateWindowInSec
+	}
+	crawlerProgressesLock.Unlock()
+
+	status.Streaming = isStreaming(channelName)
+	paused, err := IsCrawlingPaused(channelName)
+	if err != nil {
+		return err
+	}
+	status.Paused = paused
+
+	pendingTxs, err := CountPendingTxs(channelName)
+	if err != nil {
+		return err
+	}
+	status.PendingTxs = pendingTxs
+
+	if status.ChainHeight > dbHeight {
+		status.LagInBlocks = status.ChainHeight - dbHeight
+
+		// The chain height is seen when its block is made, roughly.
+		var block Block
+		if dbHeight > 0 && CurrentStore().QueryBlockByHeight(channelName, dbHeight, &block) == nil {
+			if lag := chainHeightAt.Sub(block.Timestamp); lag > 0 {
+				status.LagInSeconds = int64(lag / time.Second)
+			}
+		}
+	}
+
+	*out = status
+	return nil
+}
@@ -0,0 +1,63 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestQueryCrawlerStatus(t *testing.T) {
+	dbpath := "test_crawler_status.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel1"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	crawlerProgressesLock.Lock()
+	delete(crawlerProgresses, channelName)
+	crawlerProgressesLock.Unlock()
+
+	// Nothing seen before crawling.
+	var status CrawlerStatus
+	assert.Equal(t, QueryCrawlerStatus(channelName, &status), nil)
+	assert.Equal(t, status.DBHeight, int64(0))
+	assert.Equal(t, status.ChainHeight, int64(-1))
+	assert.Equal(t, status.LagInBlocks, int64(0))
+	assert.Equal(t, status.LastSuccess.IsZero(), true)
+
+	// The block failed to be crawled lags behind the chain.
+	node.setFailHeight(10, true)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, QueryCrawlerStatus(channelName, &status), nil)
+	assert.Equal(t, status.DBHeight, int64(9))
+	assert.Equal(t, status.ChainHeight, int64(10))
+	assert.Equal(t, status.LagInBlocks, int64(1))
+	assert.Equal(t, status.Crawling, false)
+	assert.Equal(t, status.NodeIP, node.server.URL)
+	assert.Equal(t, status.BlocksPerSecond > 0, true)
+	assert.Equal(t, status.LastSuccess.IsZero(), false)
+	assert.Equal(t, status.LastError != "", true)
+	assert.Equal(t, status.CountOfError > 0, true)
+
+	// Up to date after the node recovers.
+	node.setFailHeight(10, false)
+	if _, err := backfillChannel(getNodePool(channelName), channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, QueryCrawlerStatus(channelName, &status), nil)
+	assert.Equal(t, status.DBHeight, int64(10))
+	assert.Equal(t, status.LagInBlocks, int64(0))
+	assert.Equal(t, status.LagInSeconds, int64(0))
+}
@@ -1,33 +1,44 @@
 package polarbear
 
 import (
-	"encoding/json"
+	"math/big"
 	"motherbear/backend/constants"
+	"motherbear/backend/db"
 	"motherbear/backend/isaacerror"
 	"motherbear/backend/logger"
-	util "motherbear/backend/utility"
-	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 )
 
 // Block is crawled block data.
 type Block struct {
 	gorm.Model
-	Channel     string `gorm:"type:VARCHAR(64);not null;index"`
-	BlockHeight int64  `gorm:"type:BIGINT;not null;index"`
-	PeerID      string `gorm:"type:VARCHAR(512);not null"`
-	Signature   string `gorm:"type:VARCHAR(512);not null"`
-	Timestamp   time.Time
-	BlockHash   string `gorm:"type:VARCHAR(512);not null;index"`
-	Txs         []Tx   `gorm:"many2many:block_tx;"`
+	Channel       string `gorm:"type:VARCHAR(64);not null;index"`
+	BlockHeight   int64  `gorm:"type:BIGINT;not null;index"`
+	PeerID        string `gorm:"type:VARCHAR(512);not null"`
+	Signature     string `gorm:"type:VARCHAR(512);not null"`
+	Timestamp     time.Time
+	BlockHash     string `gorm:"type:VARCHAR(512);not null;index"`
+	PrevBlockHash string `gorm:"type:VARCHAR(512)"`
+	Txs           []Tx   `gorm:"many2many:block_tx;"`
 }
 
+// Status of Tx.
+const (
+	TxStatusSuccess = "Success"
+	TxStatusFailure = "Failure"
+
+	// TxStatusPending is the status of Tx whose result is not fetched from node yet.
+	// The result of it is queried again by the reconciler in background.
+	TxStatusPending = "Pending"
+)
+
 // Tx is transaction data.
 type Tx struct {
 	gorm.Model
@@ -36,9 +47,29 @@ type Tx struct {
 	Channel     string    `gorm:"type:VARCHAR(64);not null;index"`
 	BlockHeight int64     `gorm:"type:BIGINT;not null;index"`
 	Timestamp   time.Time `gorm:"index"`
-	From        string    `gorm:"type:VARCHAR(128);not null"`
-	To          string    `gorm:"type:VARCHAR(128);not null"`
-	Data        string    `gorm:"type:MEDIUMTEXT;not null"`
+	From        string    `gorm:"type:VARCHAR(128);not null;index"`
+	To          string    `gorm:"type:VARCHAR(128);not null;index"`
+	Value       string    `gorm:"type:VARCHAR(80)"` // ICX value in loop, decimal string.
+	DataType    string    `gorm:"type:VARCHAR(20);index"`
+	Data        string    `gorm:"size:16777215;not null"` // LONGTEXT of mysql, TEXT of postgres and sqlite.
+	Result      TxResult  `gorm:"foreignkey:TxID"`
+}
+
+// TxResult is the result of transaction, responded by icx_getTransactionResult.
+type TxResult struct {
+	gorm.Model
+	TxID           uint   `gorm:"index"`
+	TxHash         string `gorm:"type:VARCHAR(512);not null;index"`
+	Channel        string `gorm:"type:VARCHAR(64);not null;index"`
+	BlockHeight    int64  `gorm:"type:BIGINT;not null;index"`
+	StepUsed       int64  `gorm:"type:BIGINT"`
+	StepPrice      int64  `gorm:"type:BIGINT"`
+	Fee            string `gorm:"type:VARCHAR(80)"` // StepUsed * StepPrice in loop, decimal string.
+	ScoreAddress   string `gorm:"type:VARCHAR(128);index"`
+	FailureCode    int64
+	FailureMessage string     `gorm:"type:VARCHAR(1024)"`
+	LogsBloom      string     `gorm:"type:TEXT"`
+	EventLogs      []EventLog `gorm:"foreignkey:TxResultID"`
 }
 
 // Symptom is peer symptom data.
@@ -59,6 +90,8 @@ type TxSearch struct {
 	FromAddress string
 	ToAddress   string
 	Data        string
+	FromHeight  int64 // 0 for no limit.
+	ToHeight    int64 // 0 for no limit.
 }
 
 var instance *gorm.DB
@@ -69,17 +102,30 @@ func Database() *gorm.DB {
 	return instance
 }
 
-// InitDB create instance of DB.
-func InitDB(dbType string, dbOption ...string) *gorm.DB {
+// InitDB create instance of DB, and applies the migrations of the tables.
+// The data source is from DataSourceName of DBConfig.
+func InitDB(dbType string, dataSource string) *gorm.DB {
+	OpenDB(dbType, dataSource)
+
+	// Create and migrate tables for Tx, and Block.
+	if _, err := Migrate(false); err != nil {
+		panic(err)
+	}
+	return instance
+}
+
+// OpenDB create instance of DB without the migrations.
+func OpenDB(dbType string, dataSource string) *gorm.DB {
 	var err error
 
 	switch dbType {
-	case constants.DBTypeSqlite3: //sqlite3
-		instance, err = gorm.Open(dbType, dbOption[0])
-	case constants.DBTypeMysql: //mysql
-		instance, err = gorm.Open(dbType, dbOption[0]+
-			":"+dbOption[1]+"@"+dbOption[2]+
-			"?charset=utf8mb4,utf8&parseTime=True&loc=Local")
+	case constants.DBTypeSqlite3, constants.DBTypeMysql:
+		instance, err = gorm.Open(dbType, dataSource)
+	case constants.DBTypePostgres:
+		instance, err = gorm.Open(dbType, dataSource)
+		if err == nil {
+			err = db.CreateSchemaIfNotExist(instance, dataSource)
+		}
 	}
 
 	if err != nil {
@@ -89,8 +135,8 @@ func InitDB(dbType string, dbOption ...string) *gorm.DB {
 		panic("Failed to create the handle")
 	}
 
-	// Create tables for Tx, and Block.
-	initCreateTable()
+	// Store blocks, Txs and symptoms in the DB.
+	SetStore(NewGormStore(instance))
 	return instance
 }
 
@@ -103,18 +149,6 @@ func CloseDBInstance() {
 	logger.Info("Delete polarbear instance of DB")
 }
 
-func initCreateTable() {
-	if !instance.HasTable(&Tx{}) {
-		instance.CreateTable(&Tx{})
-	}
-	if !instance.HasTable(&Block{}) {
-		instance.CreateTable(&Block{})
-	}
-	if !instance.HasTable(&Symptom{}) {
-		instance.CreateTable(&Symptom{})
-	}
-}
-
 func convUnixTimeStampToTime(Timestamp int64) time.Time {
 	tmpDecStr := strconv.FormatInt(Timestamp, 10)
 
@@ -133,99 +167,173 @@ func convUnixTimeStampToTime(Timestamp int64) time.Time {
 	return time.Unix(0, 0)
 }
 
-func buildBlockRecordFromJSON(JSONData map[string]interface{}, URI string, channelName string, block *Block) {
+// buildBlockRecordFromJSON builds the block from the block data of any version responded from node.
+// If URI is "", the status of Txs is set as success because JSONData should be test data.
+func buildBlockRecordFromJSON(JSONData map[string]interface{}, URI string, channelName string, block *Block) error {
+	parsed, err := parseBlockFromJSON(JSONData)
+	if err != nil {
+		return err
+	}
+
+	buildBlockRecord(parsed, URI, channelName, block)
+	return nil
+}
+
+// parseBlockFromJSON decodes the block in the response of icx_getBlockByHeight.
+func parseBlockFromJSON(JSONData map[string]interface{}) (*parsedBlock, error) {
+	result, ok := JSONData["result"].(map[string]interface{})
+	if !ok {
+		return nil, isaacerror.SysErrFailToParseBlockData
+	}
+	return parseBlock(result)
+}
+
+// txResultsFunc returns the results of icx_getTransactionResult for the Tx hashes. The Tx without result is left out.
+// If it returns error, the block of the Txs is not stored.
+type txResultsFunc func(txHashes []string) (map[string]map[string]interface{}, error)
+
+// txResultsFromNode returns the function to get the results of Txs from the node of URI.
+// If URI is "", it returns nil to set the status of Txs as success, because the block should be test data.
+func txResultsFromNode(URI string, channelName string) txResultsFunc {
+	if URI == "" {
+		return nil
+	}
+
+	// Set status and result by calling of JSON RPC in batch.
+	return func(txHashes []string) (map[string]map[string]interface{}, error) {
+		nodes := getNodePool(channelName)
+		txResults := nodes.getTxResults(URI, txHashes)
+
+		for _, txHash := range txHashes {
+			if _, ok := txResults[txHash]; ok {
+				continue
+			}
+
+			// Try again for the Tx failed in batch.
+			if result, err := nodes.getTxResult(URI, txHash); err == nil {
+				txResults[txHash] = result
+			}
+		}
+		return txResults, nil
+	}
+}
 
-	result := JSONData["result"].(map[string]interface{})
-	height := int64(result["height"].(float64))
+// buildBlockRecord builds the block from the decoded block, and sets the result of Txs from the node of URI.
+func buildBlockRecord(parsed *parsedBlock, URI string, channelName string, block *Block) {
+	_ = buildBlockRecordWithResults(parsed, channelName, txResultsFromNode(URI, channelName), block)
+}
 
+// buildBlockRecordWithResults builds the block from the decoded block, and sets the result of Txs by resultsOf.
+// If resultsOf is nil, the status of Txs is set as success. The Tx without result is set as pending.
+func buildBlockRecordWithResults(parsed *parsedBlock, channelName string, resultsOf txResultsFunc, block *Block) error {
 	// Put block data into table.
-	block.BlockHash = util.AddHexHD(result["block_hash"].(string))
+	block.BlockHash = parsed.hash
 	block.Channel = channelName
-	block.PeerID = result["peer_id"].(string)
-	block.BlockHeight = height
+	block.PeerID = parsed.peerID
+	block.BlockHeight = parsed.height
 
-	// Convert float64 to UNIX time.
-	tmpDecInt := int64(result["time_stamp"].(float64))
-	block.Timestamp = convUnixTimeStampToTime(tmpDecInt)
+	// Keep the previous block hash to verify the linkage of chain. Genesis block has no previous hash.
+	block.PrevBlockHash = parsed.prevHash
+	block.Timestamp = parsed.timestamp
 
 	// Singature
-	block.Signature = result["signature"].(string)
+	block.Signature = parsed.signature
 
 	// Traversal confirmed TXs.
-	confirmedTxList := result["confirmed_transaction_list"].([]interface{})
+	logger.Infof("Tx count %d,  in the block %s", len(parsed.txs), block.BlockHash)
+	for _, txData := range parsed.txs {
+		tx := buildTxFromData(txData, channelName, parsed)
 
-	logger.Infof("Tx count %d,  in the block %s", len(confirmedTxList), block.BlockHash)
-	for _, t := range confirmedTxList {
+		// Set status after parsing all Txs.
+		if resultsOf == nil {
+			tx.Status = TxStatusSuccess
+		}
 
-		temp := t.(map[string]interface{})
+		block.Txs = append(block.Txs, tx)
+	}
 
-		// Convert UNIX timestamp in  string  to Time structure.
-		var tmpDecInt int64
-		if reflect.TypeOf(temp["timestamp"]).Name() == "string" {
-			tmpHexStr := temp["timestamp"].(string)
-			if strings.Contains(tmpHexStr, "0x") {
-				// Hex string to Int64.
-				tmpDecInt, _ = strconv.ParseInt(tmpHexStr[2:], 16, 64)
-			} else {
-				// Decimal string to Int64.
-				tmpDecInt, _ = strconv.ParseInt(tmpHexStr, 16, 64)
-			}
-		} else if reflect.TypeOf(temp["timestamp"]).Name() == "float64" {
-			// Float64 timestamp to Int64.
-			tmpDecInt = int64(temp["timestamp"].(float64))
+	if resultsOf != nil && len(block.Txs) != 0 {
+		txHashes := make([]string, 0, len(block.Txs))
+		for _, t := range block.Txs {
+			txHashes = append(txHashes, t.TxHash)
 		}
-		txTimestamp := convUnixTimeStampToTime(tmpDecInt)
-
-		// Parse data string. In some case no data in Tx.
-		dataString := ""
-		if val, ok := temp["data"]; ok {
-			//typeName := reflect.TypeOf(val).String()
-			//fmt.Println(typeName)
-			if reflect.TypeOf(val).Name() == "string" {
-				dataString = temp["data"].(string)
+		txResults, err := resultsOf(txHashes)
+		if err != nil {
+			return err
+		}
+
+		// The Tx without result is queried again by the reconciler.
+		for i := range block.Txs {
+			if result, ok := txResults[block.Txs[i].TxHash]; ok {
+				buildTxResultFromJSON(result, &block.Txs[i])
 			} else {
-				dataMap := val.(map[string]interface{})
-				b, err := json.Marshal(dataMap)
-				if err != nil {
-					panic(err)
-				}
-				dataString = string(b)
+				block.Txs[i].Status = TxStatusPending
 			}
 		}
+	}
 
-		// Get the TX's status. If URI is "", then just set status as success because JSONData should be test data.
-		var txHash string
-		if val, ok := temp["txHash"]; ok {
-			txHash = util.AddHexHD(val.(string))
-		} else if val, ok := temp["tx_hash"]; ok {
-			txHash = util.AddHexHD(val.(string))
-		} else {
-			logger.Error("No key for TxHash!! Block hash: ", result["block_hash"].(string))
-		}
+	return nil
+}
 
-		// Set status by calling of JSON RPC.
-		var status string
-		if URI != "" {
-			status, _ = getTxStatus(URI, channelName, txHash)
-		} else {
-			status = "Success"
+// parseHexInt64 converts the hex string with 0x or the number to int64.
+func parseHexInt64(val interface{}) int64 {
+	switch v := val.(type) {
+	case string:
+		n, _ := strconv.ParseInt(strings.TrimPrefix(v, "0x"), 16, 64)
+		return n
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// parseHexBigInt converts the hex string with 0x or the number to big integer. Returns 0 if no value.
+func parseHexBigInt(val interface{}) *big.Int {
+	n := new(big.Int)
+	switch v := val.(type) {
+	case string:
+		if _, ok := n.SetString(strings.TrimPrefix(v, "0x"), 16); !ok {
+			return new(big.Int)
 		}
+	case float64:
+		n.SetInt64(int64(v))
+	}
+	return n
+}
+
+// buildTxResultFromJSON sets the status and result of Tx from the result of icx_getTransactionResult.
+func buildTxResultFromJSON(result map[string]interface{}, tx *Tx) {
+	tx.Status = parseTxStatus(result)
 
-		block.Txs = append(
-			block.Txs,
-			Tx{
-				TxHash:      txHash,
-				Channel:     channelName,
-				Status:      status,
-				BlockHeight: height,
-				Timestamp:   txTimestamp,
-				From:        temp["from"].(string),
-				To:          temp["to"].(string),
-				Data:        dataString,
-			},
-		)
+	tx.Result = TxResult{
+		TxHash:      tx.TxHash,
+		Channel:     tx.Channel,
+		BlockHeight: tx.BlockHeight,
+		StepUsed:    parseHexInt64(result["stepUsed"]),
+		StepPrice:   parseHexInt64(result["stepPrice"]),
+	}
+
+	// Fee may be bigger than int64.
+	fee := new(big.Int).Mul(parseHexBigInt(result["stepUsed"]), parseHexBigInt(result["stepPrice"]))
+	tx.Result.Fee = fee.String()
+
+	if scoreAddress, ok := result["scoreAddress"].(string); ok {
+		tx.Result.ScoreAddress = scoreAddress
+	}
+	if logsBloom, ok := result["logsBloom"].(string); ok {
+		tx.Result.LogsBloom = logsBloom
 	}
+	tx.Result.EventLogs = buildEventLogsFromJSON(result, tx)
 
+	if failure, ok := result["failure"].(map[string]interface{}); ok {
+		tx.Result.FailureCode = parseHexInt64(failure["code"])
+		if message, ok := failure["message"].(string); ok {
+			if len(message) > 1024 {
+				message = message[:1024]
+			}
+			tx.Result.FailureMessage = message
+		}
+	}
 }
 
 // AddBlockRecordFromJSONResponse build block data from JSON data.
@@ -235,15 +343,27 @@ func AddBlockRecordFromJSONResponse(
 	channelName string,
 	block *Block) error {
 
-	buildBlockRecordFromJSON(JSONData, URI, channelName, block)
+	parsed, err := parseBlockFromJSON(JSONData)
+	if err != nil {
+		return err
+	}
 
-	if Database().NewRecord(&block) {
-		if err := Database().Save(&block).Error; err != nil {
-			return err
-		}
+	return addBlockRecord(parsed, channelName, txResultsFromNode(URI, channelName), block)
+}
+
+// addBlockRecord builds the block from the decoded block with the results of Txs by resultsOf,
+// and saves it with the contracts and token transfers in it. Every block crawled or imported is stored by it.
+func addBlockRecord(parsed *parsedBlock, channelName string, resultsOf txResultsFunc, block *Block) error {
+	if err := buildBlockRecordWithResults(parsed, channelName, resultsOf, block); err != nil {
+		return err
 	}
 
-	return nil
+	return saveBlockRecord(block)
+}
+
+// saveBlockRecord saves the block in the store.
+func saveBlockRecord(block *Block) error {
+	return CurrentStore().SaveBlock(block)
 }
 
 // GetCurrentBlockHeightInDB is
@@ -255,20 +375,36 @@ func GetCurrentBlockHeightInDB(channelName string) int64 {
 		return -1
 	}
 
-	var block Block
-	blockTable := Database().Preload("Txs").Model(&Block{}).Order("block_height desc")
-	if err := blockTable.First(&block, &Block{Channel: channelName}).Error; err != nil {
-		if err.Error() == "record not found" {
-			logger.Info("No blocks in the DB. ")
-			return 0
-		} else {
-			logger.Errorf("%s", err)
-			return -1
-		}
+	return CurrentStore().GetCurrentBlockHeight(channelName)
+}
 
-	} else {
-		return block.BlockHeight
+// QueryBlockHashesInRange queries blocks without Txs between the heights in channel, ordered by height.
+func QueryBlockHashesInRange(
+	channelName string,
+	beginHeight int64,
+	endHeight int64,
+	out *[]Block) error {
+
+	// Check arguments.
+	if beginHeight > endHeight || channelName == "" {
+		logger.Errorf("Arguments is wrong. begin:%d, end:%d, channelName:%s", beginHeight, endHeight, channelName)
+		return isaacerror.SysErrFailToQueryBlocksInChannel
 	}
+
+	return CurrentStore().QueryBlockHashesInRange(channelName, beginHeight, endHeight, out)
+}
+
+// DeleteBlocksFromHeight deletes the blocks and their Txs with results, event logs, contract history
+// and token transfers from the height to the top in channel.
+func DeleteBlocksFromHeight(channelName string, height int64) error {
+
+	// Check arguments.
+	if height <= 0 || channelName == "" {
+		logger.Errorf("Arguments is wrong. height:%d, channelName:%s", height, channelName)
+		return isaacerror.SysErrFailToDeleteBlockData
+	}
+
+	return CurrentStore().DeleteBlocksFromHeight(channelName, height)
 }
 
 // QueryBlocksInChannel queries blocks in channel.
@@ -284,24 +420,7 @@ func QueryBlocksInChannel(
 		return -1, isaacerror.SysErrFailToQueryBlockInChannel
 	}
 
-	//Query block data.
-	var block Block
-	Database().First(&block, 1)
-	blockTable := Database().Preload("Txs").Model(&block).Order(
-		"block_height desc")
-
-	if err := blockTable.Offset(offset).Limit(limit).Find(out, &Block{
-		Channel: channelName,
-	}).Error; err != nil {
-		return -1, isaacerror.SysErrFailToQueryBlockInChannel
-	} else {
-		var count int64
-		var block2 Block
-		Database().Model(&block2).Where(&Block{
-			Channel: channelName,
-		}).Count(&count)
-		return count, nil
-	}
+	return CurrentStore().QueryBlocks(channelName, limit, offset, out)
 }
 
 // QueryBlockByHeightInChannel queries block by height in channel.
@@ -316,22 +435,7 @@ func QueryBlockByHeightInChannel(
 		return isaacerror.SysErrFailToQueryBlockInChannel
 	}
 
-	//Query block data.
-	var block Block
-	Database().First(&block, 1)
-	blockTable := Database().Preload(
-		"Txs").Model(
-		&block).Order(
-		"block_height desc")
-
-	if err := blockTable.Find(out, &Block{
-		Channel:     channelName,
-		BlockHeight: height},
-	).Error; err != nil {
-		return isaacerror.SysErrFailToQueryBlocksInChannel
-	} else {
-		return nil
-	}
+	return CurrentStore().QueryBlockByHeight(channelName, height, out)
 }
 
 // QueryBlockInChannelByHash queries block by height in channel.
@@ -340,20 +444,7 @@ func QueryBlockInChannelByHash(
 	blockHash string,
 	out interface{}) error {
 
-	//Query block data.
-	var block Block
-	Database().First(&block, 1)
-	blockTable := Database().Preload("Txs").Model(&block).Order(
-		"block_height desc")
-
-	if err := blockTable.Find(out, &Block{
-		Channel:   channelName,
-		BlockHash: blockHash},
-	).Error; err != nil {
-		return isaacerror.SysErrFailToQueryBlockInChannel
-	} else {
-		return nil
-	}
+	return CurrentStore().QueryBlockByHash(channelName, blockHash, out)
 }
 
 // QueryTxsInChannelByTime is
@@ -363,28 +454,7 @@ func QueryTxsInChannelByTime(
 	offset int,
 	out interface{}) (int64, error) {
 
-	//Query Tx data.
-	var tx Tx
-	Database().First(&tx, 1)
-	txTable :=
-		Database().Model(
-			&tx).Offset(
-			offset).Limit(
-			limit).Order("block_height desc").Order("timestamp desc")
-
-	if err := txTable.Find(out, &Tx{
-		Channel: channelName,
-	}).Error; err != nil {
-		return -1, isaacerror.SysErrFailToQueryTxsInChannel
-	} else {
-		var count int64
-		var tx2 Tx
-		Database().Model(&tx2).Where(Tx{
-			Channel: channelName,
-		}).Count(&count)
-
-		return count, nil
-	}
+	return CurrentStore().QueryTxs(channelName, limit, offset, TxSearch{BlockHeight: -1}, out)
 }
 
 // QueryTxsInChannelBySearch is
@@ -395,11 +465,25 @@ func QueryTxsInChannelBySearch(
 	search TxSearch,
 	out interface{}) (int64, error) {
 
-	//Query Tx data.
-	var count int64
+	// Check the range of time before querying in the store.
+	if search.From.IsZero() != search.To.IsZero() {
+		return -1, isaacerror.SysErrInvalidTimeSearchCondition
+	}
+
+	return CurrentStore().QueryTxs(channelName, limit, offset, search, out)
+}
+
+// searchTxs builds the query of Txs searched in channel. The columns are qualified to join the other tables.
+func searchTxs(channelName string, search TxSearch) (*gorm.DB, error) {
+	return searchTxsIn(Database(), channelName, search)
+}
+
+// searchTxsIn builds the query of Txs searched in channel in the DB.
+func searchTxsIn(db *gorm.DB, channelName string, search TxSearch) (*gorm.DB, error) {
+	tableName := db.NewScope(&Tx{}).TableName()
 
 	// Search by channel name.
-	txTable := Database().Where(Tx{Channel: channelName}).Model(&Tx{})
+	txTable := db.Where(Tx{Channel: channelName}).Model(&Tx{})
 
 	// If exists search entity, search by search entity.
 	//  entity : status, blockHeight, fromAddress, toAddress
@@ -421,26 +505,25 @@ func QueryTxsInChannelBySearch(
 
 	// If exists 'from / to' entity, search by 'from / to'.
 	if !search.From.IsZero() && !search.To.IsZero() {
-		txTable = txTable.Where("timeStamp BETWEEN ? AND ?", search.From, search.To).Model(&Tx{})
+		txTable = txTable.Where(tableName+".timestamp BETWEEN ? AND ?", search.From, search.To).Model(&Tx{})
 	} else if search.From.IsZero() && search.To.IsZero() {
 	} else {
-		return -1, isaacerror.SysErrInvalidTimeSearchCondition
+		return nil, isaacerror.SysErrInvalidTimeSearchCondition
 	}
 	// If exists data entity, search by data entity.
 	if search.Data != "" {
-		txTable = txTable.Where("data LIKE ?", "%"+search.Data+"%").Model(&Tx{})
+		txTable = txTable.Where(tableName+".data LIKE ?", "%"+search.Data+"%").Model(&Tx{})
 	}
 
-	// Get count about Tx list.
-	txTable.Count(&count)
-
-	// Get Tx list.
-	err := txTable.Offset(offset).Limit(limit).Order("block_height desc").Order("timestamp desc").Find(out, &Tx{}).Error
-	if err != nil {
-		return -1, isaacerror.SysErrFailToQueryTxsInChannel
+	// If exists range of block heights, search in the range.
+	if search.FromHeight > 0 {
+		txTable = txTable.Where(tableName+".block_height >= ?", search.FromHeight).Model(&Tx{})
+	}
+	if search.ToHeight > 0 {
+		txTable = txTable.Where(tableName+".block_height <= ?", search.ToHeight).Model(&Tx{})
 	}
 
-	return count, nil
+	return txTable, nil
 }
 
 // QueryTxInChannelByHash is
@@ -449,22 +532,9 @@ func QueryTxInChannelByHash(
 	txHash string,
 	out interface{}) error {
 
-	//Query block data.
-	var tx Tx
-	Database().First(&tx, 1)
-
-	if err := Database().Model(&tx).Find(out, &Tx{
-		Channel: channelName,
-		TxHash:  txHash,
-	}).Error; err != nil {
-		return isaacerror.SysErrFailToQueryTxInChannel
-	} else {
-		return nil
-	}
-
+	return CurrentStore().QueryTxByHash(channelName, txHash, out)
 }
 
-
 // AddPeerSymptom insert peer symptom data tables.
 func AddPeerSymptom(
 	channelName string,
@@ -480,13 +550,7 @@ func AddPeerSymptom(
 		Timestamp: time.Now(),
 	}
 
-	if Database().NewRecord(&peerSymptomMappingTB) {
-		if err := Database().Save(&peerSymptomMappingTB).Error; err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return CurrentStore().AddSymptom(peerSymptomMappingTB)
 }
 
 // QueryPeerSymptomListTable return peersymptom table Info.
@@ -504,35 +568,17 @@ func QueryPeerSymptomListTable(
 		return -1, isaacerror.SysErrFailToQueryPeerSymptom
 	}
 
-	//Query symptom data.
-	var peerSymptomTB []Symptom
-	var symptomTable *gorm.DB
-	Database().First(&peerSymptomTB, 1)
+	// Query symptom data in the range of time if both of from and to exist.
+	var fromTime, toTime time.Time
 	if from != "" && to != "" {
-		fromTimer, err := time.Parse(time.RFC3339, from)
-		if err != nil {
+		var err error
+		if fromTime, err = time.Parse(time.RFC3339, from); err != nil {
 			return -1, isaacerror.SysErrFailToParseTimeStringToTimeObject
 		}
-		toTimer, err := time.Parse(time.RFC3339, to)
-		if err != nil {
+		if toTime, err = time.Parse(time.RFC3339, to); err != nil {
 			return -1, isaacerror.SysErrFailToParseTimeStringToTimeObject
 		}
-		symptomTable = Database().Model(&peerSymptomTB).Order("Timestamp desc").
-			Where("Timestamp BETWEEN ? AND ?", fromTimer, toTimer)
-	} else {
-		symptomTable = Database().Model(&peerSymptomTB).Order("Timestamp desc")
-	}
-
-	var count int64
-	symptomTable.Where("Channel_PK IN (?)", channelPermission).
-		Count(&count)
-
-	if err := symptomTable.Offset(offset).Limit(limit).
-		Where("Channel_PK IN (?)", channelPermission).
-		Find(out, &Symptom{
-	}).Error; err != nil {
-		return -1, isaacerror.SysErrFailToQueryPeerSymptom
 	}
 
-	return count, nil
-}
\ No newline at end of file
+	return CurrentStore().QuerySymptoms(channelPermission, fromTime, toTime, limit, offset, out)
+}
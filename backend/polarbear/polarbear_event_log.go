@@ -0,0 +1,154 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// EventLog is the event log in the result of transaction.
+// The first indexed value is the event signature, and the other indexed values are kept in Indexed1~3 to search.
+type EventLog struct {
+	gorm.Model
+	TxResultID   uint      `gorm:"index"`
+	TxHash       string    `gorm:"type:VARCHAR(512);not null;index"`
+	Channel      string    `gorm:"type:VARCHAR(64);not null;index"`
+	BlockHeight  int64     `gorm:"type:BIGINT;not null;index"`
+	Timestamp    time.Time `gorm:"index"`
+	LogIndex     int
+	ScoreAddress string `gorm:"type:VARCHAR(128);not null;index"`
+	Signature    string `gorm:"type:VARCHAR(512);not null;index"`
+	Indexed1     string `gorm:"type:VARCHAR(512);index"`
+	Indexed2     string `gorm:"type:VARCHAR(512);index"`
+	Indexed3     string `gorm:"type:VARCHAR(512);index"`
+	Indexed      string `gorm:"type:TEXT"` // JSON array of indexed values except signature.
+	Data         string `gorm:"type:TEXT"` // JSON array of data values.
+}
+
+// EventLogSearch is the condition to search event logs.
+type EventLogSearch struct {
+	ScoreAddress string
+	Signature    string
+	Indexed      string // Matched with any indexed value.
+	FromHeight   int64  // -1 if no condition.
+	ToHeight     int64  // -1 if no condition.
+	From         time.Time
+	To           time.Time
+}
+
+// buildEventLogsFromJSON builds event logs of Tx from the eventLogs in the Tx result.
+func buildEventLogsFromJSON(result map[string]interface{}, tx *Tx) []EventLog {
+	eventLogList, ok := result["eventLogs"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	eventLogs := make([]EventLog, 0, len(eventLogList))
+	for i, l := range eventLogList {
+		logData, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		eventLog := EventLog{
+			TxHash:      tx.TxHash,
+			Channel:     tx.Channel,
+			BlockHeight: tx.BlockHeight,
+			Timestamp:   tx.Timestamp,
+			LogIndex:    i,
+		}
+		if scoreAddress, ok := logData["scoreAddress"].(string); ok {
+			eventLog.ScoreAddress = scoreAddress
+		}
+
+		// Indexed values can be null.
+		indexed, _ := logData["indexed"].([]interface{})
+		indexedValues := make([]string, 0, len(indexed))
+		for _, v := range indexed {
+			value, _ := v.(string)
+			indexedValues = append(indexedValues, value)
+		}
+		if len(indexedValues) > 0 {
+			eventLog.Signature = indexedValues[0]
+			indexedValues = indexedValues[1:]
+		}
+		for j, value := range indexedValues {
+			switch j {
+			case 0:
+				eventLog.Indexed1 = value
+			case 1:
+				eventLog.Indexed2 = value
+			case 2:
+				eventLog.Indexed3 = value
+			}
+		}
+
+		b, _ := json.Marshal(indexedValues)
+		eventLog.Indexed = string(b)
+		if data, ok := logData["data"].([]interface{}); ok {
+			b, _ := json.Marshal(data)
+			eventLog.Data = string(b)
+		} else {
+			eventLog.Data = "[]"
+		}
+
+		eventLogs = append(eventLogs, eventLog)
+	}
+
+	return eventLogs
+}
+
+// QueryEventLogsInChannel queries event logs in channel by search condition.
+func QueryEventLogsInChannel(
+	channelName string,
+	limit int,
+	offset int,
+	search EventLogSearch,
+	out *[]EventLog) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 || channelName == "" {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, channelName:%s", limit, offset, channelName)
+		return -1, isaacerror.SysErrFailToQueryEventLogs
+	}
+
+	table := Database().Model(&EventLog{}).Where("channel = ?", channelName)
+
+	if search.ScoreAddress != "" {
+		table = table.Where("score_address = ?", search.ScoreAddress)
+	}
+	if search.Signature != "" {
+		table = table.Where("signature = ?", search.Signature)
+	}
+	if search.Indexed != "" {
+		table = table.Where("indexed1 = ? OR indexed2 = ? OR indexed3 = ?",
+			search.Indexed, search.Indexed, search.Indexed)
+	}
+	if search.FromHeight != -1 {
+		table = table.Where("block_height >= ?", search.FromHeight)
+	}
+	if search.ToHeight != -1 {
+		table = table.Where("block_height <= ?", search.ToHeight)
+	}
+	if !search.From.IsZero() {
+		table = table.Where("timestamp >= ?", search.From)
+	}
+	if !search.To.IsZero() {
+		table = table.Where("timestamp <= ?", search.To)
+	}
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryEventLogs
+	}
+
+	if err := table.Order("block_height desc").Order("id asc").Offset(
+		offset).Limit(limit).Find(out).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryEventLogs
+	}
+
+	return count, nil
+}
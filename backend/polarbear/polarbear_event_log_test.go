@@ -0,0 +1,108 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestBuildEventLogsFromJSON(t *testing.T) {
+	var result map[string]interface{}
+	_ = json.Unmarshal([]byte(`{
+		"status": "0x1",
+		"eventLogs": [
+			{
+				"scoreAddress": "cx54d95fee187faaea03cee908f50623c8381179d0",
+				"indexed": [
+					"Transfer(Address,Address,int,bytes)",
+					"hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+					"hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b",
+					"0xde0b6b3a7640000"
+				],
+				"data": ["0x"]
+			},
+			{
+				"scoreAddress": "cx0000000000000000000000000000000000000000",
+				"indexed": ["PRepRegistered(Address)"],
+				"data": ["hx5d91dee6102ead2aca60256cf33ebf9aab102c82", null]
+			}
+		]
+	}`), &result)
+
+	tx := Tx{TxHash: "0x1234", Channel: "channel1", BlockHeight: 10, Timestamp: time.Unix(1000, 0)}
+	eventLogs := buildEventLogsFromJSON(result, &tx)
+
+	assert.Equal(t, len(eventLogs), 2)
+	assert.Equal(t, eventLogs[0].TxHash, "0x1234")
+	assert.Equal(t, eventLogs[0].BlockHeight, int64(10))
+	assert.Equal(t, eventLogs[0].Timestamp, time.Unix(1000, 0))
+	assert.Equal(t, eventLogs[0].ScoreAddress, "cx54d95fee187faaea03cee908f50623c8381179d0")
+	assert.Equal(t, eventLogs[0].Signature, "Transfer(Address,Address,int,bytes)")
+	assert.Equal(t, eventLogs[0].Indexed1, "hx5d91dee6102ead2aca60256cf33ebf9aab102c82")
+	assert.Equal(t, eventLogs[0].Indexed2, "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b")
+	assert.Equal(t, eventLogs[0].Indexed3, "0xde0b6b3a7640000")
+	assert.Equal(t, eventLogs[0].Data, `["0x"]`)
+
+	assert.Equal(t, eventLogs[1].LogIndex, 1)
+	assert.Equal(t, eventLogs[1].Signature, "PRepRegistered(Address)")
+	assert.Equal(t, eventLogs[1].Indexed, `[]`)
+	assert.Equal(t, eventLogs[1].Data, `["hx5d91dee6102ead2aca60256cf33ebf9aab102c82",null]`)
+
+	// No event logs.
+	assert.Equal(t, len(buildEventLogsFromJSON(map[string]interface{}{"status": "0x1"}, &tx)), 0)
+}
+
+func TestQueryEventLogsInChannel(t *testing.T) {
+	dbpath := "test_event_log.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_event_log"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every Tx has a Transfer event.
+	search := EventLogSearch{FromHeight: -1, ToHeight: -1}
+	var eventLogs []EventLog
+	count, err := QueryEventLogsInChannel(channelName, 5, 0, search, &eventLogs)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(10))
+	assert.Equal(t, len(eventLogs), 5)
+	assert.Equal(t, eventLogs[0].BlockHeight, int64(10))
+	assert.Equal(t, eventLogs[0].Signature, "Transfer(Address,Address,int,bytes)")
+
+	// Search by contract, signature, indexed value and height range.
+	search = EventLogSearch{
+		ScoreAddress: "cx0000000000000000000000000000000000000001",
+		Signature:    "Transfer(Address,Address,int,bytes)",
+		Indexed:      "hx0000000000000000000000000000000000000003",
+		FromHeight:   3,
+		ToHeight:     5,
+	}
+	eventLogs = nil
+	count, _ = QueryEventLogsInChannel(channelName, 10, 0, search, &eventLogs)
+	assert.Equal(t, count, int64(3))
+
+	search.Signature = "Approval(Address,Address,int)"
+	count, _ = QueryEventLogsInChannel(channelName, 10, 0, search, &eventLogs)
+	assert.Equal(t, count, int64(0))
+
+	// The event logs are deleted with blocks.
+	assert.Equal(t, DeleteBlocksFromHeight(channelName, 6), nil)
+	count, _ = QueryEventLogsInChannel(channelName, 10, 0, EventLogSearch{FromHeight: -1, ToHeight: -1}, &eventLogs)
+	assert.Equal(t, count, int64(5))
+}
@@ -0,0 +1,199 @@
+package polarbear
+
+import (
+	"database/sql"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// The count of rows read from DB at once to export. No cursor is kept open while the rows are written to client,
+// so the crawler can commit to sqlite in the meantime.
+var exportPageSize = 1000
+
+// BlockSearch is the range of blocks to export.
+type BlockSearch struct {
+	FromHeight int64     // 0 for no limit.
+	ToHeight   int64     // 0 for no limit.
+	From       time.Time // Zero for no limit.
+	To         time.Time // Zero for no limit.
+}
+
+// ExportBlocksInChannel streams the blocks searched in channel to fn in the order of height, without their Txs.
+// The blocks are read in pages after the last one exported, so they are not counted.
+func ExportBlocksInChannel(channelName string, search BlockSearch, fn func(block *Block) error) error {
+	if channelName == "" {
+		logger.Errorf("Arguments is wrong. channelName:%s", channelName)
+		return isaacerror.SysErrFailToExportData
+	}
+
+	table := Database().Model(&Block{}).Where("channel = ?", channelName)
+	if search.FromHeight > 0 {
+		table = table.Where("block_height >= ?", search.FromHeight)
+	}
+	if search.ToHeight > 0 {
+		table = table.Where("block_height <= ?", search.ToHeight)
+	}
+	if !search.From.IsZero() {
+		table = table.Where("timestamp >= ?", search.From)
+	}
+	if !search.To.IsZero() {
+		table = table.Where("timestamp <= ?", search.To)
+	}
+
+	var lastHeight int64 = -1
+	var lastID uint
+	for {
+		var blocks []Block
+		if err := table.Where("block_height > ? OR (block_height = ? AND id > ?)", lastHeight, lastHeight,
+			lastID).Order("block_height asc").Order("id asc").Limit(exportPageSize).Find(&blocks).Error; err != nil {
+			logger.Errorf("%s", err)
+			return isaacerror.SysErrFailToExportData
+		}
+
+		for i := range blocks {
+			if err := fn(&blocks[i]); err != nil {
+				return err
+			}
+		}
+		if len(blocks) < exportPageSize {
+			return nil
+		}
+		lastHeight = blocks[len(blocks)-1].BlockHeight
+		lastID = blocks[len(blocks)-1].ID
+	}
+}
+
+// ExportTxsInChannel streams the Txs searched in channel to fn with their results, in the order of block height.
+// The Txs are read in pages after the last one exported, so they are not counted.
+func ExportTxsInChannel(channelName string, search TxSearch, fn func(tx *Tx) error) error {
+	if channelName == "" {
+		logger.Errorf("Arguments is wrong. channelName:%s", channelName)
+		return isaacerror.SysErrFailToExportData
+	}
+
+	table, err := searchTxs(channelName, search)
+	if err != nil {
+		return err
+	}
+
+	txTable := Database().NewScope(&Tx{}).TableName()
+	txResultTable := Database().NewScope(&TxResult{}).TableName()
+	from := txTable + "." + Database().Dialect().Quote("from")
+	to := txTable + "." + Database().Dialect().Quote("to")
+
+	columns := []string{
+		txTable + ".id", txTable + ".tx_hash", txTable + ".status", txTable + ".channel", txTable + ".block_height",
+		txTable + ".timestamp", from, to, txTable + ".value", txTable + ".data_type", txTable + ".data",
+		txResultTable + ".step_used", txResultTable + ".step_price", txResultTable + ".fee",
+		txResultTable + ".score_address", txResultTable + ".failure_code", txResultTable + ".failure_message",
+		txResultTable + ".logs_bloom",
+	}
+	join := "LEFT JOIN " + txResultTable + " ON " + txResultTable + ".tx_id = " + txTable + ".id AND " +
+		txResultTable + ".deleted_at IS NULL"
+
+	table = table.Select(strings.Join(columns, ", ")).Joins(join)
+	keyset := txTable + ".block_height > ? OR (" + txTable + ".block_height = ? AND " + txTable + ".id > ?)"
+
+	var lastHeight int64 = -1
+	var lastID uint
+	for {
+		txs, err := queryTxPageToExport(table.Where(keyset, lastHeight, lastHeight, lastID).Order(
+			txTable + ".block_height asc").Order(txTable + ".id asc").Limit(exportPageSize))
+		if err != nil {
+			logger.Errorf("%s", err)
+			return isaacerror.SysErrFailToExportData
+		}
+
+		for i := range txs {
+			if err := fn(&txs[i]); err != nil {
+				return err
+			}
+		}
+		if len(txs) < exportPageSize {
+			return nil
+		}
+		lastHeight = txs[len(txs)-1].BlockHeight
+		lastID = txs[len(txs)-1].ID
+	}
+}
+
+// queryTxPageToExport reads a page of Txs with their results to export. The cursor is closed before they are written.
+func queryTxPageToExport(table *gorm.DB) ([]Tx, error) {
+	rows, err := table.Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := make([]Tx, 0, exportPageSize)
+	for rows.Next() {
+		var tx Tx
+		var value, dataType, fee, scoreAddress, failureMessage, logsBloom sql.NullString
+		var stepUsed, stepPrice, failureCode sql.NullInt64
+		if err := rows.Scan(&tx.ID, &tx.TxHash, &tx.Status, &tx.Channel, &tx.BlockHeight, &tx.Timestamp, &tx.From,
+			&tx.To, &value, &dataType, &tx.Data, &stepUsed, &stepPrice, &fee, &scoreAddress, &failureCode,
+			&failureMessage, &logsBloom); err != nil {
+			return nil, err
+		}
+		tx.Value = value.String
+		tx.DataType = dataType.String
+		tx.Result = TxResult{
+			TxHash:         tx.TxHash,
+			Channel:        tx.Channel,
+			BlockHeight:    tx.BlockHeight,
+			StepUsed:       stepUsed.Int64,
+			StepPrice:      stepPrice.Int64,
+			Fee:            fee.String,
+			ScoreAddress:   scoreAddress.String,
+			FailureCode:    failureCode.Int64,
+			FailureMessage: failureMessage.String,
+			LogsBloom:      logsBloom.String,
+		}
+		txs = append(txs, tx)
+	}
+
+	return txs, rows.Err()
+}
+
+// ExportSymptoms streams the symptoms of the channels between the times to fn in the order of time.
+// The channels are PKs like QueryPeerSymptomListTable. Zero time is no limit.
+func ExportSymptoms(channelPKs []string, from time.Time, to time.Time, fn func(symptom *Symptom) error) error {
+	table := Database().Model(&Symptom{}).Where("channel_pk IN (?)", channelPKs)
+	if !from.IsZero() {
+		table = table.Where("timestamp >= ?", from)
+	}
+	if !to.IsZero() {
+		table = table.Where("timestamp <= ?", to)
+	}
+
+	var last time.Time
+	var lastID uint
+	for page := 0; ; page++ {
+		pageTable := table
+		if page != 0 {
+			pageTable = table.Where("timestamp > ? OR (timestamp = ? AND id > ?)", last, last, lastID)
+		}
+
+		var symptoms []Symptom
+		if err := pageTable.Order("timestamp asc").Order("id asc").Limit(exportPageSize).Find(
+			&symptoms).Error; err != nil {
+			logger.Errorf("%s", err)
+			return isaacerror.SysErrFailToExportData
+		}
+
+		for i := range symptoms {
+			if err := fn(&symptoms[i]); err != nil {
+				return err
+			}
+		}
+		if len(symptoms) < exportPageSize {
+			return nil
+		}
+		last = symptoms[len(symptoms)-1].Timestamp
+		lastID = symptoms[len(symptoms)-1].ID
+	}
+}
@@ -0,0 +1,127 @@
+package polarbear
+
+import (
+	"errors"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestExportInChannel(t *testing.T) {
+	dbpath := "test_export.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_export"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// Read in pages smaller than the data.
+	defaultPageSize := exportPageSize
+	exportPageSize = 2
+	defer func() { exportPageSize = defaultPageSize }()
+
+	// Blocks in the range of heights, in the order of height. DB is written while exporting.
+	var heights []int64
+	err := ExportBlocksInChannel(channelName, BlockSearch{FromHeight: 3, ToHeight: 7}, func(block *Block) error {
+		heights = append(heights, block.BlockHeight)
+		return Database().Create(&Symptom{Channel: channelName, Timestamp: time.Now()}).Error
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, heights, []int64{3, 4, 5, 6, 7})
+
+	// Blocks in the range of time.
+	Database().Model(&Block{}).Where("channel = ? AND block_height <= ?", channelName, 5).Update(
+		"timestamp", time.Now().AddDate(0, 0, -1))
+	heights = nil
+	err = ExportBlocksInChannel(channelName, BlockSearch{From: time.Now().Add(-time.Hour)}, func(block *Block) error {
+		heights = append(heights, block.BlockHeight)
+		return nil
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, heights, []int64{6, 7, 8, 9, 10})
+
+	// Txs are the same as searched.
+	var txs []Tx
+	count, err := QueryTxsInChannelBySearch(channelName, 100, 0, TxSearch{BlockHeight: -1, FromHeight: 2, ToHeight: 4}, &txs)
+	assert.Equal(t, err, nil)
+
+	var exported []Tx
+	err = ExportTxsInChannel(channelName, TxSearch{BlockHeight: -1, FromHeight: 2, ToHeight: 4}, func(tx *Tx) error {
+		exported = append(exported, *tx)
+		return nil
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, int64(len(exported)), count)
+	assert.Equal(t, len(exported) > exportPageSize, true)
+	for i := 1; i < len(exported); i++ {
+		assert.Equal(t, exported[i-1].BlockHeight <= exported[i].BlockHeight, true)
+	}
+	assert.Equal(t, exported[0].BlockHeight, int64(2))
+	assert.NotEqual(t, exported[0].TxHash, "")
+	assert.Equal(t, exported[0].Value, txs[len(txs)-1].Value)
+
+	// Search of the other filters.
+	exported = nil
+	err = ExportTxsInChannel(channelName, TxSearch{BlockHeight: -1, FromAddress: txs[0].From}, func(tx *Tx) error {
+		exported = append(exported, *tx)
+		return nil
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(exported), 1)
+	assert.Equal(t, exported[0].TxHash, txs[0].TxHash)
+
+	// Export stops at the error.
+	errStop := errors.New("stop")
+	err = ExportTxsInChannel(channelName, TxSearch{BlockHeight: -1}, func(tx *Tx) error { return errStop })
+	assert.Equal(t, err, errStop)
+}
+
+func TestExportSymptoms(t *testing.T) {
+	dbpath := "test_export_symptom.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	defaultPageSize := exportPageSize
+	exportPageSize = 1
+	defer func() { exportPageSize = defaultPageSize }()
+
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		symptom := Symptom{
+			Channel:     "channel1",
+			Channel_PK:  "PKCH_1",
+			Msg:         "[node4]response time slowly [25.106827] sec",
+			SymptomType: "Slow response",
+			Timestamp:   now.Add(-time.Duration(i) * time.Hour),
+		}
+		if i%2 == 1 {
+			symptom.Channel = "channel2"
+			symptom.Channel_PK = "PKCH_2"
+		}
+		Database().Save(&symptom)
+	}
+
+	var symptoms []Symptom
+	err := ExportSymptoms([]string{"PKCH_1"}, now.Add(-3*time.Hour), time.Time{}, func(symptom *Symptom) error {
+		symptoms = append(symptoms, *symptom)
+		return nil
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(symptoms), 2)
+	assert.Equal(t, symptoms[0].Timestamp.Before(symptoms[1].Timestamp), true)
+	assert.Equal(t, symptoms[0].Channel, "channel1")
+}
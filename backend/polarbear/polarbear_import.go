@@ -0,0 +1,269 @@
+package polarbear
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	util "motherbear/backend/utility"
+)
+
+// Max size of a line in the dump files. A block having many Txs can be a few MB.
+const maxImportLineSize = 64 * 1024 * 1024
+
+// Max count of errors kept in the import result.
+const maxImportErrors = 100
+
+// ImportResult is the result of import of dump files.
+type ImportResult struct {
+	Imported   int64         // Count of blocks stored.
+	Duplicated int64         // Count of blocks already stored with the same hash.
+	Failed     int64         // Count of lines failed to import.
+	Errors     []ImportError // The first errors up to maxImportErrors.
+}
+
+// ImportError is the error of a line in the block dump file.
+type ImportError struct {
+	Line        int64
+	BlockHeight int64 // 0 if the block is not parsed.
+	Error       string
+}
+
+// txResultDump reads the dump of icx_getTransactionResult responses along with the dump of blocks.
+// The results are expected in the order of blocks, so only the results read ahead are kept.
+type txResultDump struct {
+	scanner *bufio.Scanner
+	line    int64
+	results map[string]map[string]interface{} // The results read and not used yet. The key is Tx hash with 0x.
+	err     error
+}
+
+// ImportBlocks stores the blocks in the dump of icx_getBlockByHeight responses, one block per line, in channel.
+// A line can be the JSON-RPC response or the block itself. If txResults is not nil, it is the dump of
+// icx_getTransactionResult responses for the Txs in the same way and order. The block whose Tx has no result in it
+// fails. Without txResults, every Tx is regarded as success.
+// The block already stored at the height is skipped if it has the same hash, or fails if not.
+func ImportBlocks(channelName string, blocks io.Reader, txResults io.Reader) (ImportResult, error) {
+	var result ImportResult
+
+	// Check arguments.
+	if channelName == "" || blocks == nil {
+		logger.Errorf("Arguments is wrong. channelName:%s", channelName)
+		return result, isaacerror.SysErrFailToImportBlocks
+	}
+
+	// Read the first result before the blocks, not to import any block with the wrong dump.
+	var dump *txResultDump
+	if txResults != nil {
+		dump = newTxResultDump(txResults)
+		if dump.read(); dump.err != nil {
+			return result, dump.err
+		}
+	}
+
+	// The heights imported in a row are recorded as crawled at once.
+	var begin, end int64 = -1, -1
+	flush := func() error {
+		if begin < 0 {
+			return nil
+		}
+		err := SetCrawlRangeStatus(channelName, begin, end, CrawlRangeDone, "")
+		begin, end = -1, -1
+		return err
+	}
+
+	scanner := bufio.NewScanner(blocks)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+	var line int64
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		height, duplicated, err := importBlock(channelName, data, dump)
+		if dump != nil && dump.err != nil {
+			_ = flush()
+			return result, dump.err
+		}
+		if err != nil {
+			logger.Errorf("Fail to import the block in line %d in %s. %s", line, channelName, err)
+			result.Failed++
+			if len(result.Errors) < maxImportErrors {
+				result.Errors = append(result.Errors, ImportError{Line: line, BlockHeight: height, Error: err.Error()})
+			}
+			continue
+		}
+		if duplicated {
+			result.Duplicated++
+		} else {
+			result.Imported++
+		}
+
+		if begin >= 0 && height != end+1 {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+		if begin < 0 {
+			begin = height
+		}
+		end = height
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Errorf("Fail to read the blocks to import in %s. %s", channelName, err)
+		_ = flush()
+		return result, isaacerror.SysErrFailToImportBlocks
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	logger.Infof("Import of blocks in %s is done. imported:%d, duplicated:%d, failed:%d",
+		channelName, result.Imported, result.Duplicated, result.Failed)
+	return result, nil
+}
+
+// importBlock stores the block in the line of dump with the results of Txs in the dump of them.
+// Returns the height of block, and true if it is already stored.
+func importBlock(channelName string, data []byte, dump *txResultDump) (int64, bool, error) {
+	JSONData, err := unmarshalDumpLine(data)
+	if err != nil {
+		return 0, false, err
+	}
+
+	parsed, err := parseBlockFromJSON(JSONData)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Not to be mixed with the block crawled at the same height.
+	unlock := lockChannelCrawl(channelName)
+	defer unlock()
+
+	var stored []Block
+	if err := QueryBlockHashesInRange(channelName, parsed.height, parsed.height, &stored); err != nil {
+		return parsed.height, false, err
+	}
+	if len(stored) != 0 {
+		if stored[0].BlockHash != parsed.hash {
+			return parsed.height, false, fmt.Errorf("%s stored:%s, imported:%s",
+				isaacerror.SysErrConflictedBlockHash, stored[0].BlockHash, parsed.hash)
+		}
+		return parsed.height, true, nil
+	}
+
+	var resultsOf txResultsFunc
+	if dump != nil {
+		resultsOf = dump.resultsOf
+		defer dump.discardBefore(parsed.height)
+	}
+
+	var block Block
+	return parsed.height, false, addBlockRecord(parsed, channelName, resultsOf, &block)
+}
+
+func newTxResultDump(txResults io.Reader) *txResultDump {
+	scanner := bufio.NewScanner(txResults)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	return &txResultDump{
+		scanner: scanner,
+		results: make(map[string]map[string]interface{}),
+	}
+}
+
+// resultsOf returns the results of the Txs, reading the dump until every one of them is found.
+// Fails if any of them is not in the dump.
+func (d *txResultDump) resultsOf(txHashes []string) (map[string]map[string]interface{}, error) {
+	found := make(map[string]map[string]interface{}, len(txHashes))
+	for _, txHash := range txHashes {
+		for d.results[txHash] == nil && d.read() {
+		}
+
+		result, ok := d.results[txHash]
+		if !ok {
+			if d.err != nil {
+				return nil, d.err
+			}
+			return nil, fmt.Errorf("%s %s", isaacerror.SysErrNoTxResultInDump, txHash)
+		}
+		found[txHash] = result
+		delete(d.results, txHash)
+	}
+
+	return found, nil
+}
+
+// read reads the next result, one result per line. Returns false at the end of dump or if it fails.
+func (d *txResultDump) read() bool {
+	if d.err != nil {
+		return false
+	}
+
+	for d.scanner.Scan() {
+		d.line++
+		data := bytes.TrimSpace(d.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		JSONData, err := unmarshalDumpLine(data)
+		if err != nil {
+			logger.Errorf("Fail to read the Tx result in line %d. %s", d.line, err)
+			d.err = isaacerror.SysErrFailToImportTxResults
+			return false
+		}
+		result, ok := JSONData["result"].(map[string]interface{})
+		if !ok {
+			logger.Errorf("No Tx result in line %d.", d.line)
+			d.err = isaacerror.SysErrFailToImportTxResults
+			return false
+		}
+		txHash, ok := result["txHash"].(string)
+		if !ok || len(txHash) < 2 {
+			logger.Errorf("No Tx hash in the result in line %d.", d.line)
+			d.err = isaacerror.SysErrFailToImportTxResults
+			return false
+		}
+
+		d.results[util.AddHexHD(txHash)] = result
+		return true
+	}
+	if err := d.scanner.Err(); err != nil {
+		logger.Errorf("Fail to read the Tx results to import. %s", err)
+		d.err = isaacerror.SysErrFailToImportTxResults
+	}
+
+	return false
+}
+
+// discardBefore discards the results read ahead for the blocks lower than the height, like the blocks skipped.
+// The result without block height is kept.
+func (d *txResultDump) discardBefore(height int64) {
+	for txHash, result := range d.results {
+		if h, ok := result["blockHeight"]; ok && parseHexInt64(h) < height {
+			delete(d.results, txHash)
+		}
+	}
+}
+
+// unmarshalDumpLine decodes the line of dump as the JSON-RPC response. The bare result is wrapped in the response.
+func unmarshalDumpLine(data []byte) (map[string]interface{}, error) {
+	var JSONData map[string]interface{}
+	if err := json.Unmarshal(data, &JSONData); err != nil {
+		return nil, err
+	}
+
+	if _, ok := JSONData["jsonrpc"]; ok {
+		return JSONData, nil
+	}
+	if _, ok := JSONData["result"]; ok {
+		return JSONData, nil
+	}
+	return map[string]interface{}{"result": JSONData}, nil
+}
@@ -0,0 +1,132 @@
+package polarbear
+
+import (
+	"bytes"
+	"encoding/json"
+	"motherbear/backend/isaacerror"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// dumpBlocks makes the dump of blocks in the node between the heights, and the dump of their Tx results.
+func dumpBlocks(node *fakeNode, beginHeight int64, endHeight int64) (string, string) {
+	var blocks, txResults bytes.Buffer
+	for h := beginHeight; h <= endHeight; h++ {
+		block := node.blocks[h]
+
+		// Both of the JSON-RPC response and the bare block.
+		var line []byte
+		if h%2 == 0 {
+			line, _ = json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "result": block, "id": h})
+		} else {
+			line, _ = json.Marshal(block)
+		}
+		blocks.Write(line)
+		blocks.WriteString("\n")
+
+		for _, tx := range block["confirmed_transaction_list"].([]interface{}) {
+			response := node.handleRequest(map[string]interface{}{
+				"method": "icx_getTransactionResult",
+				"params": map[string]interface{}{"txHash": tx.(map[string]interface{})["txHash"]},
+				"id":     h,
+			})
+			response["result"].(map[string]interface{})["blockHeight"] = "0x" + strconv.FormatInt(h, 16)
+			line, _ = json.Marshal(response)
+			txResults.Write(line)
+			txResults.WriteString("\n")
+		}
+	}
+	return blocks.String(), txResults.String()
+}
+
+func TestImportBlocks(t *testing.T) {
+	dbpath := "test_import.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	node := newFakeNode(5)
+	defer node.close()
+
+	channelName := "channel_import"
+	blocks, txResults := dumpBlocks(node, 0, 5)
+
+	result, err := ImportBlocks(channelName, strings.NewReader(blocks), strings.NewReader(txResults))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Imported, int64(6))
+	assert.Equal(t, result.Failed, int64(0))
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(5))
+
+	// Txs have the results in the dump.
+	var txs []Tx
+	count, err := QueryTxsInChannelBySearch(channelName, 100, 0, TxSearch{BlockHeight: 3}, &txs)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, txs[0].Status, "Success")
+
+	var ranges []CrawlRange
+	_ = QueryCrawlRanges(channelName, CrawlRangeDone, &ranges)
+	assert.Equal(t, len(ranges), 1)
+	assert.Equal(t, ranges[0].BeginHeight, int64(0))
+	assert.Equal(t, ranges[0].EndHeight, int64(5))
+
+	// The same blocks are skipped.
+	result, err = ImportBlocks(channelName, strings.NewReader(blocks), nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Imported, int64(0))
+	assert.Equal(t, result.Duplicated, int64(6))
+
+	// The other block at the same height fails, and the broken line too.
+	node.replaceBlocks(4, 7)
+	blocks, _ = dumpBlocks(node, 4, 7)
+	result, err = ImportBlocks(channelName, strings.NewReader(blocks+"{broken\n"), nil)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Imported, int64(2))
+	assert.Equal(t, result.Failed, int64(3))
+	assert.Equal(t, result.Errors[0].Line, int64(1))
+	assert.Equal(t, result.Errors[0].BlockHeight, int64(4))
+	assert.Equal(t, result.Errors[2].Line, int64(5))
+
+	// Txs without the dump of results are success.
+	count, _ = QueryTxsInChannelBySearch(channelName, 100, 0, TxSearch{BlockHeight: 7, Status: "Success"}, &txs)
+	assert.Equal(t, count, int64(1))
+
+	// Wrong dump of Tx results.
+	_, err = ImportBlocks(channelName, strings.NewReader(blocks), strings.NewReader("{}\n"))
+	assert.NotEqual(t, err, nil)
+}
+
+// Test the block whose Tx has no result in the dump fails, instead of storing the Tx as pending.
+func TestImportBlocksWithoutTxResult(t *testing.T) {
+	dbpath := "test_import_missing.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	node := newFakeNode(3)
+	defer node.close()
+
+	channelName := "channel_import_missing"
+	blocks, txResults := dumpBlocks(node, 0, 3)
+
+	// Leave out the result of the Tx in the block at 2.
+	lines := strings.Split(strings.TrimSpace(txResults), "\n")
+	assert.Equal(t, len(lines), 4)
+	txResults = strings.Join(append(lines[:2], lines[3:]...), "\n")
+
+	result, err := ImportBlocks(channelName, strings.NewReader(blocks), strings.NewReader(txResults))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, result.Imported, int64(3))
+	assert.Equal(t, result.Failed, int64(1))
+	assert.Equal(t, result.Errors[0].BlockHeight, int64(2))
+	assert.Equal(t, strings.HasPrefix(result.Errors[0].Error, isaacerror.SysErrNoTxResultInDump.Error()), true)
+
+	// The results read ahead are used for the next blocks.
+	var txs []Tx
+	count, _ := QueryTxsInChannelBySearch(channelName, 100, 0, TxSearch{BlockHeight: 3, Status: "Success"}, &txs)
+	assert.Equal(t, count, int64(1))
+	countOfPending, err := CountPendingTxs(channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfPending, int64(0))
+}
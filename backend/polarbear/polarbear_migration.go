@@ -0,0 +1,72 @@
+package polarbear
+
+import (
+	"motherbear/backend/migration"
+
+	"github.com/jinzhu/gorm"
+)
+
+// migrator has the migrations of polarbear DB. Append the new migration with the next version.
+var migrator = migration.Migrator{
+	Table: "polarbear_schema_migrations",
+	Migrations: []migration.Migration{
+		{
+			Version:     1,
+			Description: "Create the tables of blocks, Txs, symptoms and the data derived from them.",
+			Up: func(database *gorm.DB) error {
+				return migration.CreateTablesIfNotExist(database, &Tx{}, &Block{}, &Symptom{}, &TxResult{},
+					&EventLog{}, &CrawlRange{}, &Contract{}, &ContractHistory{}, &Token{}, &TokenTransfer{},
+					&TokenBalance{}, &QuarantinedBlock{}, &PrunedRange{})
+			},
+		},
+		{
+			Version:     2,
+			Description: "Add the previous block hash, value and data type of Tx, and the indexes of Tx addresses.",
+			Up: func(database *gorm.DB) error {
+				if err := migration.AddColumnIfNotExist(database, &Block{}, "PrevBlockHash"); err != nil {
+					return err
+				}
+				if err := migration.AddColumnIfNotExist(database, &Tx{}, "Value"); err != nil {
+					return err
+				}
+				if err := migration.AddColumnIfNotExist(database, &Tx{}, "DataType"); err != nil {
+					return err
+				}
+				if err := migration.AddIndexIfNotExist(database, &Tx{}, "idx_txes_from", "from"); err != nil {
+					return err
+				}
+				if err := migration.AddIndexIfNotExist(database, &Tx{}, "idx_txes_to", "to"); err != nil {
+					return err
+				}
+				return migration.AddIndexIfNotExist(database, &Tx{}, "idx_txes_data_type", "data_type")
+			},
+		},
+		{
+			Version:     3,
+			Description: "Create the tables of the paused channels and crawl jobs.",
+			Up: func(database *gorm.DB) error {
+				return migration.CreateTablesIfNotExist(database, &CrawlPause{}, &CrawlJob{})
+			},
+		},
+		{
+			Version:     4,
+			Description: "Create the table of the pending Txs, and mark the Txs without status as pending.",
+			Up: func(database *gorm.DB) error {
+				if err := migration.CreateTablesIfNotExist(database, &PendingTx{}); err != nil {
+					return err
+				}
+				return database.Model(&Tx{}).Where("status = ?", "").Update("status", TxStatusPending).Error
+			},
+		},
+	},
+}
+
+// Migrate applies the pending migrations to polarbear DB, and returns them. Nothing is applied in dry run.
+func Migrate(dryRun bool) ([]migration.Migration, error) {
+	return migrator.Up(Database(), dryRun)
+}
+
+// SchemaVersion returns the schema version of polarbear DB.
+func SchemaVersion() (int, error) {
+	return migrator.Version(Database())
+}
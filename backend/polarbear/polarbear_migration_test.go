@@ -0,0 +1,49 @@
+package polarbear
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// oldTx is Tx of the release before the value and data type.
+type oldTx struct {
+	gorm.Model
+	TxHash string `gorm:"type:VARCHAR(128);not null;index"`
+	Status string `gorm:"type:VARCHAR(20);not null;index"`
+}
+
+func (oldTx) TableName() string {
+	return "txes"
+}
+
+func TestMigrate(t *testing.T) {
+	OpenDB("sqlite3", ":memory:")
+	defer Database().Close()
+
+	// The tables created before the migrations.
+	assert.Equal(t, Database().CreateTable(&oldTx{}).Error, nil)
+	assert.Equal(t, Database().Create(&oldTx{TxHash: "0x1234"}).Error, nil)
+
+	pending, err := Migrate(true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(pending), len(migrator.Migrations))
+	assert.Equal(t, Database().Dialect().HasColumn("txes", "value"), false)
+
+	applied, err := Migrate(false)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(applied), len(migrator.Migrations))
+	assert.Equal(t, Database().Dialect().HasColumn("txes", "value"), true)
+	assert.Equal(t, Database().Dialect().HasIndex("txes", "idx_txes_data_type"), true)
+	assert.Equal(t, Database().HasTable(&Block{}), true)
+
+	// The Tx saved without status is pending.
+	var tx Tx
+	assert.Equal(t, Database().Where("tx_hash = ?", "0x1234").First(&tx).Error, nil)
+	assert.Equal(t, tx.Status, TxStatusPending)
+
+	version, err := SchemaVersion()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version, migrator.Migrations[len(migrator.Migrations)-1].Version)
+}
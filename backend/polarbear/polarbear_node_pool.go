@@ -0,0 +1,406 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sync"
+	"time"
+)
+
+// The count of consecutive errors to open the circuit of node.
+const nodeFailureThreshold = 3
+
+// The duration to keep the circuit of node open. After it, a trial request is allowed.
+const nodeCircuitOpenDuration = 30 * time.Second
+
+// The weight of the last request in the moving average of latency and error rate.
+const nodeHealthWeight = 0.2
+
+// The error rate to regard the node as unhealthy.
+const unhealthyNodeErrorRate = 0.5
+
+// Circuit state of node.
+const (
+	circuitClosed   = "Closed"
+	circuitOpen     = "Open"
+	circuitHalfOpen = "HalfOpen"
+)
+
+// nodeResponseError is the error responded by the node, like the block not found.
+// The node is alive, so it doesn't open the circuit.
+type nodeResponseError struct {
+	message string
+}
+
+func (e *nodeResponseError) Error() string {
+	return e.message
+}
+
+// newNodeResponseError returns the error in JSON-RPC response, or nil if no error.
+func newNodeResponseError(response map[string]interface{}) error {
+	if errData, ok := response["error"]; ok && errData != nil {
+		b, _ := json.Marshal(errData)
+		return &nodeResponseError{message: string(b)}
+	}
+	return nil
+}
+
+// nodeHealth is the health of a node, measured by the requests to it.
+type nodeHealth struct {
+	nodeIP            string
+	height            int64 // Last block height of node. -1 if unknown.
+	latency           time.Duration
+	errorRate         float64
+	countOfRequest    int64
+	countOfError      int64
+	consecutiveErrors int
+	circuit           string
+	openedAt          time.Time
+	trialInFlight     bool
+}
+
+// NodeHealthStatus is the snapshot of health of a node.
+type NodeHealthStatus struct {
+	NodeIP         string  `json:"nodeIP"`
+	Height         int64   `json:"height"`
+	LatencyInMs    int64   `json:"latencyInMs"`
+	ErrorRate      float64 `json:"errorRate"`
+	CountOfRequest int64   `json:"countOfRequest"`
+	CountOfError   int64   `json:"countOfError"`
+	Circuit        string  `json:"circuit"`
+}
+
+// nodePool selects a healthy node of channel for each JSON-RPC request.
+// The node failing continuously is excluded by the circuit breaker until it recovers.
+type nodePool struct {
+	mu          sync.Mutex
+	channelName string
+	nodes       []*nodeHealth
+	next        int
+}
+
+var nodePools = make(map[string]*nodePool)
+var nodePoolsLock sync.Mutex
+
+// getNodePool returns the node pool of channel. The nodes are synchronized with the configuration.
+func getNodePool(channelName string) *nodePool {
+	nodeIPList := getNodeIPList(channelName)
+
+	nodePoolsLock.Lock()
+	defer nodePoolsLock.Unlock()
+
+	pool, ok := nodePools[channelName]
+	if !ok {
+		pool = &nodePool{channelName: channelName}
+		nodePools[channelName] = pool
+	}
+	pool.setNodes(nodeIPList)
+
+	return pool
+}
+
+// GetNodeHealthStatus returns the health of nodes in channel.
+func GetNodeHealthStatus(channelName string) []NodeHealthStatus {
+	return getNodePool(channelName).status()
+}
+
+// setNodes keeps the health of remained nodes, and adds the new nodes.
+func (p *nodePool) setNodes(nodeIPList []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	nodes := make([]*nodeHealth, 0, len(nodeIPList))
+	for _, nodeIP := range nodeIPList {
+		node := p.find(nodeIP)
+		if node == nil {
+			node = &nodeHealth{nodeIP: nodeIP, height: -1, circuit: circuitClosed}
+		}
+		nodes = append(nodes, node)
+	}
+	p.nodes = nodes
+}
+
+func (p *nodePool) find(nodeIP string) *nodeHealth {
+	for _, n := range p.nodes {
+		if n.nodeIP == nodeIP {
+			return n
+		}
+	}
+	return nil
+}
+
+// isAvailable checks the circuit of node. Call it with lock.
+func (n *nodeHealth) isAvailable(now time.Time) bool {
+	switch n.circuit {
+	case circuitOpen:
+		return now.Sub(n.openedAt) >= nodeCircuitOpenDuration
+	case circuitHalfOpen:
+		return !n.trialInFlight
+	default:
+		return true
+	}
+}
+
+// pick selects the node in round robin, except the nodes tried already.
+// The healthy node which has the block at the height is preferred. Use height -1 for any height.
+// The preferred node is selected first if it is available.
+func (p *nodePool) pick(height int64, preferredNodeIP string, tried map[string]bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best := p.find(preferredNodeIP)
+	if best == nil || tried[best.nodeIP] || !best.isAvailable(now) {
+		best = nil
+		bestScore := -1
+		for i := 0; i < len(p.nodes); i++ {
+			node := p.nodes[(p.next+i)%len(p.nodes)]
+			if tried[node.nodeIP] || !node.isAvailable(now) {
+				continue
+			}
+
+			score := 0
+			if node.errorRate < unhealthyNodeErrorRate {
+				score += 2
+			}
+			if height < 0 || node.height < 0 || node.height >= height {
+				score++
+			}
+			if score > bestScore {
+				best = node
+				bestScore = score
+			}
+		}
+
+		if best == nil {
+			return "", isaacerror.SysErrNoAvailableNodeInChannel
+		}
+		p.next = (p.next + 1) % len(p.nodes)
+	}
+
+	if best.circuit == circuitOpen {
+		best.circuit = circuitHalfOpen
+	}
+	if best.circuit == circuitHalfOpen {
+		best.trialInFlight = true
+	}
+
+	return best.nodeIP, nil
+}
+
+// report updates the health of node with the result of request.
+func (p *nodePool) report(nodeIP string, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	node := p.find(nodeIP)
+	if node == nil {
+		return
+	}
+
+	node.countOfRequest++
+	node.trialInFlight = false
+	if node.latency == 0 {
+		node.latency = latency
+	} else {
+		node.latency = time.Duration((1-nodeHealthWeight)*float64(node.latency) + nodeHealthWeight*float64(latency))
+	}
+
+	// The node responded, so close the circuit.
+	if _, ok := err.(*nodeResponseError); err == nil || ok {
+		node.consecutiveErrors = 0
+		if node.circuit != circuitClosed {
+			logger.Infof("Node %s in %s is recovered.", nodeIP, p.channelName)
+		}
+		node.circuit = circuitClosed
+	}
+
+	if err == nil {
+		node.errorRate = (1 - nodeHealthWeight) * node.errorRate
+		return
+	}
+
+	node.countOfError++
+	node.errorRate = (1-nodeHealthWeight)*node.errorRate + nodeHealthWeight
+	if _, ok := err.(*nodeResponseError); ok {
+		return
+	}
+
+	node.consecutiveErrors++
+	if node.circuit == circuitHalfOpen || node.consecutiveErrors >= nodeFailureThreshold {
+		if node.circuit != circuitOpen {
+			logger.Errorf("Node %s in %s is excluded for %s. %s", nodeIP, p.channelName, nodeCircuitOpenDuration, err)
+		}
+		node.circuit = circuitOpen
+		node.openedAt = time.Now()
+	}
+}
+
+// setHeight updates the last block height of node.
+func (p *nodePool) setHeight(nodeIP string, height int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if node := p.find(nodeIP); node != nil {
+		node.height = height
+	}
+}
+
+// call requests to the nodes in turn until it succeeds. Each node is tried once at most.
+// Returns the node which served the request.
+func (p *nodePool) call(height int64, preferredNodeIP string, request func(nodeIP string) error) (string, error) {
+	tried := make(map[string]bool)
+	err := isaacerror.SysErrNoAvailableNodeInChannel
+	for {
+		nodeIP, pickErr := p.pick(height, preferredNodeIP, tried)
+		if pickErr != nil {
+			return "", err
+		}
+		tried[nodeIP] = true
+
+		// The latency is measured after the request is allowed by the limit of requests per second.
+		waitForRequestSlot(nodeIP)
+		begin := time.Now()
+		err = request(nodeIP)
+		p.report(nodeIP, time.Since(begin), err)
+		if err == nil {
+			return nodeIP, nil
+		}
+		logger.Errorf("Request to %s in %s failed. %s", nodeIP, p.channelName, err)
+	}
+}
+
+// getLastBlockHeight requests the last block height to every available node.
+// Returns the highest one.
+func (p *nodePool) getLastBlockHeight() (int64, error) {
+	tried := make(map[string]bool)
+	lastHeight := int64(-1)
+	for {
+		nodeIP, err := p.pick(-1, "", tried)
+		if err != nil {
+			break
+		}
+		tried[nodeIP] = true
+
+		waitForRequestSlot(nodeIP)
+		begin := time.Now()
+		height, err := getLastBlockHeight(nodeIP, p.channelName)
+		p.report(nodeIP, time.Since(begin), err)
+		if err != nil {
+			logger.Errorf("Fail to get the last block height from %s in %s. %s", nodeIP, p.channelName, err)
+			continue
+		}
+
+		p.setHeight(nodeIP, height)
+		if height > lastHeight {
+			lastHeight = height
+		}
+	}
+
+	if lastHeight < 0 {
+		recordCrawlError(p.channelName, isaacerror.SysErrFailToGetLastBlockHeight)
+		return -1, isaacerror.SysErrFailToGetLastBlockHeight
+	}
+	return lastHeight, nil
+}
+
+// getBlockByHeight requests the block to the node which has the block.
+// Returns the node which served the block.
+func (p *nodePool) getBlockByHeight(out *map[string]interface{}, height int64) (string, error) {
+	return p.call(height, "", func(nodeIP string) error {
+		var blockData map[string]interface{}
+		if err := getBlockByHeight(&blockData, nodeIP, p.channelName, height); err != nil {
+			return err
+		}
+		if err := newNodeResponseError(blockData); err != nil {
+			return err
+		}
+		if _, ok := blockData["result"].(map[string]interface{}); !ok {
+			return isaacerror.SysErrFailToGetBlockData
+		}
+
+		*out = blockData
+		return nil
+	})
+}
+
+// getTxResult requests the Tx result. The node which served the block is tried first.
+func (p *nodePool) getTxResult(nodeIP string, txHash string) (map[string]interface{}, error) {
+	var txResult map[string]interface{}
+	_, err := p.call(-1, nodeIP, func(nodeIP string) error {
+		var err error
+		txResult, err = _getTxResult(nodeIP, p.channelName, txHash)
+		return err
+	})
+
+	return txResult, err
+}
+
+// getBlocksByHeights requests the blocks at the heights in a batch request to the node which has them.
+// Returns the responses in the order of heights, and the node which served them.
+// The response of a block which the node failed to serve has "error".
+func (p *nodePool) getBlocksByHeights(heights []int64) ([]map[string]interface{}, string, error) {
+	var maxHeight int64 = -1
+	for _, h := range heights {
+		if h > maxHeight {
+			maxHeight = h
+		}
+	}
+
+	var responses []map[string]interface{}
+	nodeIP, err := p.call(maxHeight, "", func(nodeIP string) error {
+		var err error
+		responses, err = getBlocksByHeights(nodeIP, p.channelName, heights)
+		return err
+	})
+
+	return responses, nodeIP, err
+}
+
+// getTxResults requests the result of Txs in batch requests. The node which served the block is tried first.
+// Returns the result by Tx hash. The Tx which failed to get the result is not in the result.
+func (p *nodePool) getTxResults(nodeIP string, txHashes []string) map[string]map[string]interface{} {
+	txResults := make(map[string]map[string]interface{})
+	for begin := 0; begin < len(txHashes); begin += maxCountOfBatchRequest {
+		end := begin + maxCountOfBatchRequest
+		if end > len(txHashes) {
+			end = len(txHashes)
+		}
+
+		if _, err := p.call(-1, nodeIP, func(nodeIP string) error {
+			results, err := getTxResults(nodeIP, p.channelName, txHashes[begin:end])
+			if err != nil {
+				return err
+			}
+			for txHash, result := range results {
+				txResults[txHash] = result
+			}
+			return nil
+		}); err != nil {
+			logger.Errorf("Fail to get the result of %d Txs in %s. %s", end-begin, p.channelName, err)
+		}
+	}
+
+	return txResults
+}
+
+func (p *nodePool) status() []NodeHealthStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := make([]NodeHealthStatus, 0, len(p.nodes))
+	for _, n := range p.nodes {
+		status = append(status, NodeHealthStatus{
+			NodeIP:         n.nodeIP,
+			Height:         n.height,
+			LatencyInMs:    n.latency.Nanoseconds() / int64(time.Millisecond),
+			ErrorRate:      n.errorRate,
+			CountOfRequest: n.countOfRequest,
+			CountOfError:   n.countOfError,
+			Circuit:        n.circuit,
+		})
+	}
+
+	return status
+}
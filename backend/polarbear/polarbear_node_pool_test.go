@@ -0,0 +1,148 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	conf "motherbear/backend/configuration"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestNodePoolCircuitBreaker(t *testing.T) {
+	pool := &nodePool{channelName: "channel1"}
+	pool.setNodes([]string{"node1", "node2"})
+
+	// The circuit opens after the consecutive errors.
+	for i := 0; i < nodeFailureThreshold; i++ {
+		pool.report("node1", time.Millisecond, isaacerror.SysErrFailToGetBlockData)
+	}
+	assert.Equal(t, pool.find("node1").circuit, circuitOpen)
+
+	// The failed node isn't selected while the circuit is open.
+	for i := 0; i < 4; i++ {
+		nodeIP, err := pool.pick(-1, "", map[string]bool{})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, nodeIP, "node2")
+	}
+	_, err := pool.pick(-1, "", map[string]bool{"node2": true})
+	assert.Equal(t, err, isaacerror.SysErrNoAvailableNodeInChannel)
+
+	// After the duration, only one trial request is allowed.
+	pool.find("node1").openedAt = time.Now().Add(-nodeCircuitOpenDuration)
+	nodeIP, _ := pool.pick(-1, "", map[string]bool{"node2": true})
+	assert.Equal(t, nodeIP, "node1")
+	assert.Equal(t, pool.find("node1").circuit, circuitHalfOpen)
+	_, err = pool.pick(-1, "", map[string]bool{"node2": true})
+	assert.Equal(t, err, isaacerror.SysErrNoAvailableNodeInChannel)
+
+	// The circuit closes if the trial succeeds.
+	pool.report("node1", time.Millisecond, nil)
+	assert.Equal(t, pool.find("node1").circuit, circuitClosed)
+
+	// The error responded by node doesn't open the circuit.
+	for i := 0; i < nodeFailureThreshold; i++ {
+		pool.report("node2", time.Millisecond, &nodeResponseError{message: "fail wrong block height"})
+	}
+	assert.Equal(t, pool.find("node2").circuit, circuitClosed)
+	assert.Equal(t, pool.find("node2").countOfError, int64(nodeFailureThreshold))
+}
+
+func TestNodePoolPickNodeHavingBlock(t *testing.T) {
+	pool := &nodePool{channelName: "channel1"}
+	pool.setNodes([]string{"node1", "node2"})
+	pool.setHeight("node1", 10)
+	pool.setHeight("node2", 20)
+
+	// The node behind isn't selected for the block it doesn't have.
+	for i := 0; i < 4; i++ {
+		nodeIP, _ := pool.pick(15, "", map[string]bool{})
+		assert.Equal(t, nodeIP, "node2")
+	}
+
+	// The preferred node is selected first.
+	nodeIP, _ := pool.pick(-1, "node1", map[string]bool{})
+	assert.Equal(t, nodeIP, "node1")
+}
+
+func TestCrawlWithDeadNode(t *testing.T) {
+	dbpath := "test_node_pool.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	deadNode := newFakeNode(0)
+	defer deadNode.close()
+	deadNode.setDown(true)
+	node := newFakeNode(30)
+	defer node.close()
+
+	channelName := "channel_dead_node"
+	confPath := initFakeNodeConf(t, channelName, deadNode, node)
+	defer os.Remove(confPath)
+
+	// The dead node is the first node of channel, but crawling is done with the other node.
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(30))
+
+	missing, _ := FindMissingBlockHeights(channelName, 1, 30)
+	assert.Equal(t, len(missing), 0)
+
+	// The dead node is excluded.
+	for _, s := range GetNodeHealthStatus(channelName) {
+		if s.NodeIP == deadNode.server.URL {
+			assert.Equal(t, s.Circuit, circuitOpen)
+		} else {
+			assert.Equal(t, s.Circuit, circuitClosed)
+			assert.Equal(t, s.Height, int64(30))
+		}
+	}
+
+	// Crawling doesn't stop even if every node is dead.
+	node.appendBlocks(35)
+	node.setDown(true)
+	assert.Equal(t, crawlBlockchain(channelName), isaacerror.SysErrFailToGetLastBlockHeight)
+}
+
+func TestCrawlInBatch(t *testing.T) {
+	dbpath := "test_batch.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := &fakeNode{failHeights: make(map[int64]bool), txsPerBlock: 150}
+	node.appendBlocks(20)
+	node.server = httptest.NewServer(http.HandlerFunc(node.serveJSONRPC))
+	defer node.close()
+	node.setFailHeight(7, true)
+
+	channelName := "channel_batch"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+	conf.Conf().Channel[0].Concurrency = 1
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// 1 request for the last block, 2 batch requests for 20 blocks and 1 request for the failed block,
+	// and 2 batch requests for 150 Txs of each block.
+	assert.Equal(t, node.countOfHTTPCalls(), 1+2+1+2*19)
+
+	var count int64
+	Database().Model(&Tx{}).Where("channel = ? AND status = ?", channelName, "Success").Count(&count)
+	assert.Equal(t, count, int64(150*19))
+
+	missing, _ := FindMissingBlockHeights(channelName, 1, 20)
+	assert.Equal(t, missing, []HeightRange{{Begin: 7, End: 7}})
+}
@@ -0,0 +1,149 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Status of the quarantined block.
+const (
+	QuarantineStatusPending  = "Pending"
+	QuarantineStatusResolved = "Resolved"
+)
+
+// QuarantinedBlock is the block which polarbear failed to decode, with the raw block data responded from node.
+// The pending blocks are not crawled again by backfill, until the operator retries them.
+type QuarantinedBlock struct {
+	gorm.Model
+	Channel      string `gorm:"type:VARCHAR(64);not null;index"`
+	BlockHeight  int64  `gorm:"type:BIGINT;not null;index"`
+	NodeIP       string `gorm:"type:VARCHAR(256)"`
+	RawData      string `gorm:"size:16777215;not null"`
+	Error        string `gorm:"type:VARCHAR(1024)"`
+	Status       string `gorm:"type:VARCHAR(20);not null;index"` // Pending, Resolved
+	CountOfTrial int
+	Timestamp    time.Time // Last time to fail.
+}
+
+// quarantineBlock records the block failed to decode. The block quarantined before is updated with the new data.
+func quarantineBlock(channelName string, height int64, nodeIP string, result map[string]interface{}, cause error) error {
+	rawData, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	errMsg := cause.Error()
+	if len(errMsg) > 1024 {
+		errMsg = errMsg[:1024]
+	}
+
+	var block QuarantinedBlock
+	err = Database().Where("channel = ? AND block_height = ?", channelName, height).First(&block).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return err
+	}
+
+	block.Channel = channelName
+	block.BlockHeight = height
+	block.NodeIP = nodeIP
+	block.RawData = string(rawData)
+	block.Error = errMsg
+	block.Status = QuarantineStatusPending
+	block.CountOfTrial++
+	block.Timestamp = time.Now()
+
+	return Database().Save(&block).Error
+}
+
+// queryPendingQuarantinedHeights returns the heights of pending quarantined blocks in channel.
+func queryPendingQuarantinedHeights(channelName string) (map[int64]bool, error) {
+	var heights []int64
+	if err := Database().Model(&QuarantinedBlock{}).Where("channel = ? AND status = ?",
+		channelName, QuarantineStatusPending).Pluck("block_height", &heights).Error; err != nil {
+		return nil, isaacerror.SysErrFailToQueryQuarantine
+	}
+
+	pending := make(map[int64]bool, len(heights))
+	for _, h := range heights {
+		pending[h] = true
+	}
+	return pending, nil
+}
+
+// QueryQuarantinedBlocksInChannel queries the quarantined blocks in channel ordered by height, without the raw data.
+// If status is "", the blocks in every status are queried.
+func QueryQuarantinedBlocksInChannel(channelName string, status string, limit int, offset int,
+	out *[]QuarantinedBlock) (int64, error) {
+	table := Database().Model(&QuarantinedBlock{}).Where("channel = ?", channelName)
+	if status != "" {
+		table = table.Where("status = ?", status)
+	}
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		return 0, isaacerror.SysErrFailToQueryQuarantine
+	}
+
+	// The raw data is not in the list, it can be large.
+	columns := []string{"id", "created_at", "updated_at", "deleted_at", "channel", "block_height", "node_ip",
+		"error", "status", "count_of_trial", "timestamp"}
+	if err := table.Select(columns).Order("block_height desc").Limit(limit).Offset(offset).Find(
+		out).Error; err != nil {
+		return 0, isaacerror.SysErrFailToQueryQuarantine
+	}
+
+	return count, nil
+}
+
+// QueryQuarantinedBlockInChannel queries the quarantined block at the height in channel.
+func QueryQuarantinedBlockInChannel(channelName string, height int64, out *QuarantinedBlock) error {
+	err := Database().Where("channel = ? AND block_height = ?", channelName, height).First(out).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return isaacerror.SysErrNoQuarantinedBlock
+	} else if err != nil {
+		return isaacerror.SysErrFailToQueryQuarantine
+	}
+
+	return nil
+}
+
+// RetryQuarantinedBlock crawls the quarantined block at the height again. If it is stored, the block is resolved.
+// If it fails to be decoded again, the block stays in quarantine with the new data.
+// It is refused while crawling in channel is paused or disabled, or a crawl job is running.
+func RetryQuarantinedBlock(channelName string, height int64, out *QuarantinedBlock) error {
+	if err := QueryQuarantinedBlockInChannel(channelName, height, out); err != nil {
+		return err
+	}
+
+	// Not to be mixed with the block crawled at the same height.
+	unlock := lockChannelCrawl(channelName)
+	defer unlock()
+	if isCrawlSuspended(channelName) {
+		return isaacerror.SysErrCrawlSuspended
+	}
+
+	// The block may be stored by the crawling after the quarantine.
+	var blocks []Block
+	if err := QueryBlockHashesInRange(channelName, height, height, &blocks); err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		if err := unitCrawlAndStoreBlock(getNodePool(channelName), channelName, height); err != nil {
+			logger.Errorf("Fail to retry the quarantined block %d in %s. %s", height, channelName, err)
+			_ = QueryQuarantinedBlockInChannel(channelName, height, out)
+			return err
+		}
+	}
+
+	out.Status = QuarantineStatusResolved
+	if err := Database().Save(out).Error; err != nil {
+		return err
+	}
+	logger.Infof("The quarantined block %d in %s is resolved.", height, channelName)
+
+	return SetCrawlRangeStatus(channelName, height, height, CrawlRangeDone, "")
+}
@@ -0,0 +1,96 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// setTxReceiver sets the receiver of the first Tx in the block at the height of node.
+func (n *fakeNode) setTxReceiver(height int64, to interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	txList := n.blocks[height]["confirmed_transaction_list"].([]interface{})
+	txList[0].(map[string]interface{})["to"] = to
+}
+
+// Test the block failed to decode is quarantined, and the other blocks are crawled.
+func TestCrawlQuarantinedBlock(t *testing.T) {
+	dbpath := "test_quarantine.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+	node.setTxReceiver(5, 12345)
+
+	channelName := "channel_quarantine"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks []Block
+	assert.Equal(t, QueryBlockHashesInRange(channelName, 1, 10, &blocks), nil)
+	assert.Equal(t, len(blocks), 9)
+
+	var quarantined []QuarantinedBlock
+	count, err := QueryQuarantinedBlocksInChannel(channelName, QuarantineStatusPending, 10, 0, &quarantined)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, quarantined[0].BlockHeight, int64(5))
+	assert.Equal(t, quarantined[0].CountOfTrial, 1)
+	assert.Equal(t, quarantined[0].NodeIP, node.server.URL)
+	assert.Equal(t, quarantined[0].RawData, "")
+	assert.NotEqual(t, quarantined[0].Error, "")
+
+	// The raw data is only in the block queried at the height.
+	var block QuarantinedBlock
+	assert.Equal(t, QueryQuarantinedBlockInChannel(channelName, 5, &block), nil)
+	assert.Equal(t, strings.Contains(block.RawData, `"to":12345`), true)
+
+	// Backfill doesn't crawl the quarantined block again.
+	requests := node.countOfRequests()
+	countOfMissing, err := backfillChannel(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfMissing, int64(1))
+	assert.Equal(t, node.countOfRequests(), requests)
+
+	// Retry fails while the block is still malformed.
+	assert.NotEqual(t, RetryQuarantinedBlock(channelName, 5, &block), nil)
+	assert.Equal(t, block.Status, QuarantineStatusPending)
+	assert.Equal(t, block.CountOfTrial, 2)
+
+	// Retry is refused while crawling is paused.
+	node.setTxReceiver(5, "hxab2d8215eab14bc6bdd8bfb2c8151257032ecd8b")
+	assert.Equal(t, PauseCrawling(channelName), nil)
+	assert.Equal(t, RetryQuarantinedBlock(channelName, 5, &block), isaacerror.SysErrCrawlSuspended)
+	assert.Equal(t, ResumeCrawling(channelName), nil)
+
+	// Retry succeeds after the block is fixed.
+	assert.Equal(t, RetryQuarantinedBlock(channelName, 5, &block), nil)
+	assert.Equal(t, block.Status, QuarantineStatusResolved)
+
+	assert.Equal(t, QueryBlockHashesInRange(channelName, 1, 10, &blocks), nil)
+	assert.Equal(t, len(blocks), 10)
+	brokenHeight, err := findBrokenLinkage(channelName, 1, 10)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, brokenHeight, int64(-1))
+
+	var ranges []CrawlRange
+	assert.Equal(t, QueryCrawlRanges(channelName, CrawlRangeFailed, &ranges), nil)
+	assert.Equal(t, len(ranges), 0)
+
+	// No quarantined block at the height.
+	assert.Equal(t, RetryQuarantinedBlock(channelName, 6, &block), isaacerror.SysErrNoQuarantinedBlock)
+}
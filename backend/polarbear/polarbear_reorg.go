@@ -0,0 +1,138 @@
+package polarbear
+
+import (
+	"fmt"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+)
+
+// The maximum depth to go back from the mismatched block to find the fork point.
+const maxReorgSearchDepth = 100
+
+// findBrokenLinkage verifies the hash linkage of stored blocks between the heights.
+// Returns the first height whose previous block hash does not match the stored block hash at height-1,
+// or -1 if every block is linked. The linkage across a missing height is not checked.
+func findBrokenLinkage(channelName string, beginHeight int64, endHeight int64) (int64, error) {
+	if beginHeight < 1 {
+		beginHeight = 1
+	}
+	if beginHeight > endHeight {
+		return -1, nil
+	}
+
+	var blocks []Block
+	if err := QueryBlockHashesInRange(channelName, beginHeight, endHeight, &blocks); err != nil {
+		return -1, err
+	}
+
+	for i := 1; i < len(blocks); i++ {
+		prev := blocks[i-1]
+		curr := blocks[i]
+		if curr.BlockHeight != prev.BlockHeight+1 || curr.PrevBlockHash == "" {
+			continue
+		}
+		if curr.PrevBlockHash != prev.BlockHash {
+			return curr.BlockHeight, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// getBlockHashByHeight returns the hash of the block at the height from the node.
+func getBlockHashByHeight(nodes *nodePool, height int64) (string, error) {
+	var blockData map[string]interface{}
+	if _, err := nodes.getBlockByHeight(&blockData, height); err != nil {
+		return "", err
+	}
+
+	result, ok := blockData["result"].(map[string]interface{})
+	if !ok {
+		return "", isaacerror.SysErrFailToGetBlockData
+	}
+	parsed, err := parseBlock(result)
+	if err != nil {
+		return "", err
+	}
+
+	return parsed.hash, nil
+}
+
+// findForkHeight goes back from the height until the stored block hash is same with the hash in the node.
+// Returns the lowest height of divergent blocks.
+func findForkHeight(nodes *nodePool, channelName string, mismatchHeight int64) (int64, error) {
+	forkHeight := mismatchHeight
+	for h := mismatchHeight - 1; h >= 1 && mismatchHeight-h <= maxReorgSearchDepth; h-- {
+		var blocks []Block
+		if err := QueryBlockHashesInRange(channelName, h, h, &blocks); err != nil {
+			return -1, err
+		}
+
+		// Stop at missing height. The blocks below it will be verified in next time.
+		if len(blocks) == 0 {
+			break
+		}
+
+		nodeBlockHash, err := getBlockHashByHeight(nodes, h)
+		if err != nil {
+			return -1, err
+		}
+		if nodeBlockHash == blocks[0].BlockHash {
+			break
+		}
+		forkHeight = h
+	}
+
+	return forkHeight, nil
+}
+
+// repairChainReorg rolls back the divergent blocks from the fork point and crawls them again.
+func repairChainReorg(nodes *nodePool, channelName string, mismatchHeight int64, endHeight int64) error {
+	forkHeight, err := findForkHeight(nodes, channelName, mismatchHeight)
+	if err != nil {
+		logger.Errorf("Fail to find the fork point of %d block in %s. %s", mismatchHeight, channelName, err)
+		return isaacerror.SysErrFailToRepairChainReorg
+	}
+
+	msg := fmt.Sprintf("Block hash linkage is broken at %d block. Rollback and crawl again from %d to %d block",
+		mismatchHeight, forkHeight, endHeight)
+	logger.Symptomf(channelName, constants.ChainReorg, msg)
+	if err := AddPeerSymptom(channelName, db.GetChannelPK(channelName), constants.ChainReorg, msg); err != nil {
+		logger.Error("AddPeerSymptom, Symptom insert ChainReorg failed!")
+	}
+
+	if err := DeleteBlocksFromHeight(channelName, forkHeight); err != nil {
+		logger.Errorf("Fail to rollback the blocks from %d in %s. %s", forkHeight, channelName, err)
+		return isaacerror.SysErrFailToRepairChainReorg
+	}
+
+	return crawlAndStoreBlock(nodes, channelName, forkHeight, endHeight)
+}
+
+// verifyAndRepairChain checks the hash linkage of blocks between the heights, and repairs the divergent blocks.
+func verifyAndRepairChain(nodes *nodePool, channelName string, beginHeight int64, endHeight int64) error {
+	mismatchHeight, err := findBrokenLinkage(channelName, beginHeight, endHeight)
+	if err != nil {
+		return err
+	}
+	if mismatchHeight < 0 {
+		return nil
+	}
+
+	if err := repairChainReorg(nodes, channelName, mismatchHeight, endHeight); err != nil {
+		return err
+	}
+
+	// Check once more. If it is still broken, it will be repaired in next crawling.
+	mismatchHeight, err = findBrokenLinkage(channelName, beginHeight, endHeight)
+	if err != nil {
+		return err
+	}
+	if mismatchHeight >= 0 {
+		logger.Errorf("Block hash linkage is still broken at %d block in %s.", mismatchHeight, channelName)
+	}
+
+	return nil
+}
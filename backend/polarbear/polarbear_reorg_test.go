@@ -0,0 +1,341 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	conf "motherbear/backend/configuration"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// fakeNode is the pseudo loopchain node which serves JSON-RPC API for testing.
+type fakeNode struct {
+	mu          sync.Mutex
+	blocks      []map[string]interface{} // Index is block height.
+	failHeights map[int64]bool           // Heights which the node fails to serve.
+	requests    int                      // Count of requests served.
+	httpCalls   int                      // Count of HTTP requests. A batch request is counted once.
+	txsPerBlock int                      // Count of Txs in each new block.
+	upgradeAt   int64                    // Height from which the blocks are in the format of 0.4. 0 if no upgrade.
+	down        bool                     // The node fails to serve every request.
+	failResults bool                     // The node fails to serve the result of Txs.
+	server      *httptest.Server
+}
+
+func newFakeNode(height int64) *fakeNode {
+	node := &fakeNode{failHeights: make(map[int64]bool), txsPerBlock: 1}
+	node.appendBlocks(height)
+	node.server = httptest.NewServer(http.HandlerFunc(node.serveJSONRPC))
+	return node
+}
+
+func (n *fakeNode) close() {
+	n.server.Close()
+}
+
+func (n *fakeNode) newBlock(height int64) map[string]interface{} {
+	prevBlockHash := ""
+	if height > 0 {
+		prevBlockHash = fakeBlockHash(n.blocks[height-1])
+	}
+
+	txList := []interface{}{}
+	for i := 0; i < n.txsPerBlock; i++ {
+		txList = append(txList, map[string]interface{}{
+			"version":   "0x3",
+			"from":      generateWalletID(),
+			"to":        generateWalletID(),
+			"value":     "0xde0b6b3a7640000",
+			"stepLimit": "0x12345",
+			"timestamp": "0x" + strconv.FormatInt(time.Now().UnixNano()/1000, 16),
+			"nid":       "0x3",
+			"nonce":     "0x1",
+			"signature": generateRandString(32),
+			"txHash":    generateBlockTxHash()[2:66],
+			"dataType":  "call",
+			"data":      map[string]interface{}{"method": "transfer"},
+		})
+	}
+
+	if n.upgradeAt > 0 && height >= n.upgradeAt {
+		return map[string]interface{}{
+			"version":          "0.4",
+			"prevHash":         prevBlockHash,
+			"transactionsHash": generateBlockTxHash()[:66],
+			"timestamp":        "0x" + strconv.FormatInt(time.Now().UnixNano()/1000, 16),
+			"transactions":     txList,
+			"hash":             generateBlockTxHash()[:66],
+			"height":           "0x" + strconv.FormatInt(height, 16),
+			"leader":           generateWalletID(),
+			"signature":        generateRandString(80),
+		}
+	}
+
+	return map[string]interface{}{
+		"version":                    "0.1a",
+		"prev_block_hash":            strings.TrimPrefix(prevBlockHash, "0x"),
+		"merkle_tree_root_hash":      generateRandString(64),
+		"time_stamp":                 float64(time.Now().UnixNano() / 1000),
+		"confirmed_transaction_list": txList,
+		"block_hash":                 generateBlockTxHash()[2:66],
+		"height":                     float64(height),
+		"peer_id":                    generateWalletID(),
+		"signature":                  generateRandString(80),
+	}
+}
+
+// appendBlocks makes the chain grow up to the height.
+func (n *fakeNode) appendBlocks(height int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for h := int64(len(n.blocks)); h <= height; h++ {
+		n.blocks = append(n.blocks, n.newBlock(h))
+	}
+}
+
+// replaceBlocks makes the fork from the height, and grows the new chain up to the height.
+func (n *fakeNode) replaceBlocks(forkHeight int64, height int64) {
+	n.mu.Lock()
+	n.blocks = n.blocks[:forkHeight]
+	n.mu.Unlock()
+
+	n.appendBlocks(height)
+}
+
+// setFailHeight makes the node fail or succeed to serve the block at the height.
+func (n *fakeNode) setFailHeight(height int64, fail bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.failHeights[height] = fail
+}
+
+// setFailTxResults makes the node fail or succeed to serve the result of Txs.
+func (n *fakeNode) setFailTxResults(fail bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.failResults = fail
+}
+
+// setDown makes the node fail or succeed to serve every request.
+func (n *fakeNode) setDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.down = down
+}
+
+func (n *fakeNode) countOfRequests() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.requests
+}
+
+func (n *fakeNode) countOfHTTPCalls() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.httpCalls
+}
+
+func (n *fakeNode) blockHash(height int64) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return fakeBlockHash(n.blocks[height])
+}
+
+// fakeBlockHash returns the hash with 0x of the block in any format.
+func fakeBlockHash(block map[string]interface{}) string {
+	if hash, ok := block["hash"].(string); ok {
+		return hash
+	}
+	return "0x" + block["block_hash"].(string)
+}
+
+func (n *fakeNode) handleRequest(request map[string]interface{}) map[string]interface{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.requests++
+	response := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      request["id"],
+	}
+
+	params, _ := request["params"].(map[string]interface{})
+	switch request["method"] {
+	case "icx_getLastBlock":
+		response["result"] = n.blocks[len(n.blocks)-1]
+	case "icx_getBlockByHeight":
+		height, _ := strconv.ParseInt(params["height"].(string)[2:], 16, 64)
+		if n.failHeights[height] {
+			response["error"] = map[string]interface{}{"code": -32000, "message": "server error"}
+		} else if height < int64(len(n.blocks)) {
+			response["result"] = n.blocks[height]
+		} else {
+			response["error"] = map[string]interface{}{"code": -32602, "message": "fail wrong block height"}
+		}
+	case "icx_getTransactionResult":
+		if n.failResults {
+			response["error"] = map[string]interface{}{"code": -32602, "message": "Pending transaction"}
+			break
+		}
+		response["result"] = map[string]interface{}{
+			"txHash":    params["txHash"],
+			"status":    "0x1",
+			"stepUsed":  "0x1234",
+			"stepPrice": "0x2540be400",
+			"logsBloom": "0x" + strings.Repeat("0", 512),
+			"eventLogs": []interface{}{
+				map[string]interface{}{
+					"scoreAddress": "cx0000000000000000000000000000000000000001",
+					"indexed": []interface{}{
+						"Transfer(Address,Address,int,bytes)",
+						"hx0000000000000000000000000000000000000002",
+						"hx0000000000000000000000000000000000000003",
+						"0x1",
+					},
+					"data": []interface{}{"0x"},
+				},
+			},
+		}
+	default:
+		response["error"] = map[string]interface{}{"code": -32601, "message": "Method not found"}
+	}
+
+	return response
+}
+
+func (n *fakeNode) serveJSONRPC(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	down := n.down
+	n.mu.Unlock()
+	if down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(body) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	n.httpCalls++
+	n.mu.Unlock()
+
+	w.Header().Set(constants.HTTPHeaderContentType, constants.HTTPContentTypeApplicationJson)
+
+	// Batch request.
+	if body[0] == '[' {
+		var requests []map[string]interface{}
+		if err := json.Unmarshal(body, &requests); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		responses := make([]map[string]interface{}, 0, len(requests))
+		for _, request := range requests {
+			responses = append(responses, n.handleRequest(request))
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(n.handleRequest(request))
+}
+
+// initFakeNodeConf writes the configuration file which has a channel of the fake nodes, and loads it.
+func initFakeNodeConf(t *testing.T, channelName string, nodes ...*fakeNode) string {
+	yaml := "node:\n"
+	for i, n := range nodes {
+		yaml += "  - name: node" + strconv.Itoa(i) + "\n    ip: " + n.server.URL + "\n"
+	}
+	yaml += "channel:\n  - name: \"" + channelName + "\"\n    nodes: ["
+	for i := range nodes {
+		if i != 0 {
+			yaml += ", "
+		}
+		yaml += "node" + strconv.Itoa(i)
+	}
+	yaml += "]\nblockchain:\n  crawlingInterval: 1\n  requestPerSecond: 1000\n"
+
+	confPath := "fake_node_conf.yaml"
+	if err := ioutil.WriteFile(confPath, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf.InitConfigData(confPath)
+
+	return confPath
+}
+
+func TestRepairChainReorg(t *testing.T) {
+	dbpath := "test_reorg.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(20)
+	defer node.close()
+
+	channelName := "channel1"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	// Crawl the chain without fork.
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(20))
+
+	mismatchHeight, _ := findBrokenLinkage(channelName, 1, 20)
+	assert.Equal(t, mismatchHeight, int64(-1))
+
+	// The blocks from 18 are replaced in the node, then crawl the new blocks.
+	node.replaceBlocks(18, 25)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(25))
+
+	// All blocks should be same with the blocks in the node.
+	for h := int64(1); h <= 25; h++ {
+		var blocks []Block
+		_ = QueryBlockHashesInRange(channelName, h, h, &blocks)
+		assert.Equal(t, len(blocks), 1)
+		assert.Equal(t, blocks[0].BlockHash, node.blockHash(h))
+	}
+
+	// Txs of the divergent blocks should be removed.
+	var count int64
+	Database().Model(&Tx{}).Where("channel = ? AND block_height = ?", channelName, 18).Count(&count)
+	assert.Equal(t, count, int64(1))
+
+	// Reorg is recorded as the symptom.
+	var symptoms []Symptom
+	Database().Where(&Symptom{SymptomType: constants.ChainReorg}).Find(&symptoms)
+	assert.Equal(t, len(symptoms), 1)
+	assert.Equal(t, symptoms[0].Channel, channelName)
+}
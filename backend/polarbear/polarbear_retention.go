@@ -0,0 +1,304 @@
+package polarbear
+
+import (
+	"motherbear/backend/configuration"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sync/atomic"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// The count of blocks to delete in a transaction. The crawler can store blocks between the batches.
+const pruneBatchSize = 100
+
+// The count of symptoms to delete in a transaction.
+const pruneSymptomBatchSize = 1000
+
+// The pause between the batches, to let the crawler access DB.
+const pruneBatchInterval = 50 * time.Millisecond
+
+// PrunedRange is the range of block heights deleted by the retention policy in channel.
+// Blocks are pruned from the lowest height, so a channel has a range.
+type PrunedRange struct {
+	gorm.Model
+	Channel       string `gorm:"type:VARCHAR(64);not null;unique_index"`
+	BeginHeight   int64  `gorm:"type:BIGINT;not null"`
+	EndHeight     int64  `gorm:"type:BIGINT;not null"`
+	CountOfBlocks int64
+	Timestamp     time.Time // Last time to prune.
+}
+
+// RetentionStatus is the retention policy of channel and the data retained by it.
+type RetentionStatus struct {
+	Channel                string
+	MaxAgeInDays           int
+	MaxBlocks              int64
+	PrunedRange            *PrunedRange // nil if no block is pruned.
+	RetainedSinceHeight    int64        // 0 if no block.
+	RetainedSince          time.Time
+	SymptomRetentionInDays int
+	SymptomRetainedSince   time.Time // Zero if no symptom.
+}
+
+// 1 while the pruner is running.
+var pruning int32
+
+// getRetention returns the retention policy of channel. The default policy is used for the channel without its own.
+func getRetention(channelName string) configuration.Retention {
+	var retention configuration.Retention
+	for _, r := range configuration.Conf().Blockchain.Retention {
+		if r.Channel == channelName {
+			return r
+		}
+		if r.Channel == "" {
+			retention = r
+		}
+	}
+	retention.Channel = channelName
+
+	return retention
+}
+
+// getPrunedHeight returns the highest height pruned in channel. 0 if no block is pruned.
+func getPrunedHeight(channelName string) (int64, error) {
+	var prunedRange PrunedRange
+	err := Database().Where("channel = ?", channelName).First(&prunedRange).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return 0, nil
+	} else if err != nil {
+		return -1, err
+	}
+
+	return prunedRange.EndHeight, nil
+}
+
+// findPruneHeight finds the highest height to prune by the retention policy in channel. 0 if nothing to prune.
+// The last block is always retained.
+func findPruneHeight(channelName string, retention configuration.Retention, now time.Time) (int64, error) {
+	lastHeight := GetCurrentBlockHeightInDB(channelName)
+	if lastHeight <= 1 {
+		return 0, nil
+	}
+
+	var pruneHeight int64
+	if retention.MaxBlocks > 0 && lastHeight > retention.MaxBlocks {
+		pruneHeight = lastHeight - retention.MaxBlocks
+	}
+
+	if retention.MaxAgeInDays > 0 {
+		cutoff := now.AddDate(0, 0, -retention.MaxAgeInDays)
+		var heights []int64
+		if err := Database().Model(&Block{}).Where("channel = ? AND timestamp < ?", channelName, cutoff).Order(
+			"block_height desc").Limit(1).Pluck("block_height", &heights).Error; err != nil {
+			return -1, err
+		}
+		if len(heights) != 0 && heights[0] > pruneHeight {
+			pruneHeight = heights[0]
+		}
+	}
+
+	if pruneHeight >= lastHeight {
+		pruneHeight = lastHeight - 1
+	}
+
+	return pruneHeight, nil
+}
+
+// pruneBlocks deletes the blocks up to the height in channel in small batches, from the lowest height.
+// Each batch locks the crawling of channel, and the pruning stops while crawling in channel is suspended.
+// Returns the count of blocks deleted.
+func pruneBlocks(channelName string, pruneHeight int64) (int64, error) {
+	if pruneHeight <= 0 {
+		return 0, nil
+	}
+
+	var prunedRange PrunedRange
+	err := Database().Where("channel = ?", channelName).First(&prunedRange).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return 0, err
+	}
+	if prunedRange.ID == 0 {
+		prunedRange.Channel = channelName
+		prunedRange.BeginHeight = 1
+	}
+
+	// Skip the heights below the lowest block, which are pruned before or not crawled.
+	beginHeight := prunedRange.BeginHeight
+	missing, err := CurrentStore().FindMissingBlockHeights(channelName, beginHeight, pruneHeight)
+	if err != nil {
+		return 0, err
+	}
+	if len(missing) != 0 && missing[0].Begin == beginHeight {
+		beginHeight = missing[0].End + 1
+	}
+
+	var countOfBlocks int64
+	for beginHeight <= pruneHeight {
+		endHeight := beginHeight + pruneBatchSize - 1
+		if endHeight > pruneHeight {
+			endHeight = pruneHeight
+		}
+
+		count, ok, err := pruneBlockBatch(&prunedRange, beginHeight, endHeight)
+		countOfBlocks += count
+		if err != nil || !ok {
+			return countOfBlocks, err
+		}
+
+		beginHeight = endHeight + 1
+		if beginHeight <= pruneHeight {
+			time.Sleep(pruneBatchInterval)
+		}
+	}
+
+	return countOfBlocks, nil
+}
+
+// pruneBlockBatch deletes the blocks between the heights through the store, and advances the pruned range to them.
+// Returns the count of blocks deleted, and false if crawling in the channel is suspended.
+func pruneBlockBatch(prunedRange *PrunedRange, beginHeight int64, endHeight int64) (int64, bool, error) {
+	// Not to be mixed with the block crawled at the same height. The crawler gets in between the batches.
+	unlock := lockChannelCrawl(prunedRange.Channel)
+	defer unlock()
+	if isCrawlSuspended(prunedRange.Channel) {
+		logger.Infof("Crawling in %s is suspended. Stop pruning the blocks.", prunedRange.Channel)
+		return 0, false, nil
+	}
+
+	count, err := CurrentStore().DeleteBlocksInRange(prunedRange.Channel, beginHeight, endHeight)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Record the pruned range in the lock, then backfill doesn't crawl the pruned blocks again.
+	if endHeight > prunedRange.EndHeight {
+		prunedRange.EndHeight = endHeight
+	}
+	prunedRange.CountOfBlocks += count
+	prunedRange.Timestamp = time.Now()
+	if err := Database().Save(prunedRange).Error; err != nil {
+		return count, false, err
+	}
+
+	return count, true, nil
+}
+
+// pruneSymptoms deletes the symptoms before the time in small batches. Returns the count of symptoms deleted.
+func pruneSymptoms(before time.Time) (int64, error) {
+	var countOfSymptoms int64
+	for {
+		var ids []uint
+		if err := Database().Unscoped().Model(&Symptom{}).Where("timestamp < ?", before).Limit(
+			pruneSymptomBatchSize).Pluck("id", &ids).Error; err != nil {
+			return countOfSymptoms, err
+		}
+		if len(ids) == 0 {
+			return countOfSymptoms, nil
+		}
+
+		if err := Database().Unscoped().Where("id IN (?)", ids).Delete(&Symptom{}).Error; err != nil {
+			return countOfSymptoms, err
+		}
+		countOfSymptoms += int64(len(ids))
+
+		time.Sleep(pruneBatchInterval)
+	}
+}
+
+// pruneChannels prunes the blocks of every channel and the symptoms by the retention policy.
+func pruneChannels(channelNames []string) {
+	now := time.Now()
+	for _, channelName := range channelNames {
+		// The blocks in the channel paused or in a crawl job are kept as they are.
+		if isCrawlSuspended(channelName) {
+			logger.Debugf("Crawling in %s is suspended. Skip pruning.", channelName)
+			continue
+		}
+
+		pruneHeight, err := findPruneHeight(channelName, getRetention(channelName), now)
+		if err != nil {
+			logger.Errorf("Fail to find the blocks to prune in %s. %s", channelName, err)
+			continue
+		}
+		if pruneHeight <= 0 {
+			continue
+		}
+
+		count, err := pruneBlocks(channelName, pruneHeight)
+		if err != nil {
+			logger.Errorf("Fail to prune the blocks up to %d in %s. %s", pruneHeight, channelName, err)
+			continue
+		}
+		logger.Infof("Pruned %d blocks up to %d in %s.", count, pruneHeight, channelName)
+	}
+
+	if days := configuration.Conf().Blockchain.SymptomRetentionInDays; days > 0 {
+		count, err := pruneSymptoms(now.AddDate(0, 0, -days))
+		if err != nil {
+			logger.Errorf("Fail to prune the symptoms. %s", err)
+			return
+		}
+		logger.Infof("Pruned %d symptoms before %d days.", count, days)
+	}
+}
+
+// cronJobForPruning prunes in background, because the jobs in scheduler don't run at the same time.
+// The pruning is skipped if the previous one is running.
+func cronJobForPruning(channelNames []string) {
+	if !atomic.CompareAndSwapInt32(&pruning, 0, 1) {
+		logger.Infof("Pruning process is running..")
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&pruning, 0)
+		pruneChannels(channelNames)
+	}()
+}
+
+// hasRetention checks any retention policy is configured.
+func hasRetention() bool {
+	return len(configuration.Conf().Blockchain.Retention) != 0 || configuration.Conf().Blockchain.SymptomRetentionInDays > 0
+}
+
+// QueryRetentionInChannel queries the retention policy of channel, the pruned range and the oldest data retained.
+func QueryRetentionInChannel(channelName string, out *RetentionStatus) error {
+	retention := getRetention(channelName)
+	*out = RetentionStatus{
+		Channel:                channelName,
+		MaxAgeInDays:           retention.MaxAgeInDays,
+		MaxBlocks:              retention.MaxBlocks,
+		SymptomRetentionInDays: configuration.Conf().Blockchain.SymptomRetentionInDays,
+	}
+
+	var prunedRange PrunedRange
+	err := Database().Where("channel = ?", channelName).First(&prunedRange).Error
+	if err == nil {
+		out.PrunedRange = &prunedRange
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return isaacerror.SysErrFailToQueryRetention
+	}
+
+	var oldest []Block
+	if err := Database().Where("channel = ?", channelName).Order(
+		"block_height asc").Limit(1).Find(&oldest).Error; err != nil {
+		return isaacerror.SysErrFailToQueryRetention
+	}
+	if len(oldest) != 0 {
+		out.RetainedSinceHeight = oldest[0].BlockHeight
+		out.RetainedSince = oldest[0].Timestamp
+	}
+
+	var symptoms []Symptom
+	if err := Database().Where("channel = ?", channelName).Order(
+		"timestamp asc").Limit(1).Find(&symptoms).Error; err != nil {
+		return isaacerror.SysErrFailToQueryRetention
+	}
+	if len(symptoms) != 0 {
+		out.SymptomRetainedSince = symptoms[0].Timestamp
+	}
+
+	return nil
+}
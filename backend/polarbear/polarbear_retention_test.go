@@ -0,0 +1,132 @@
+package polarbear
+
+import (
+	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestGetRetention(t *testing.T) {
+	configuration.Conf().Blockchain.Retention = []configuration.Retention{
+		{Channel: "channel1", MaxBlocks: 100},
+		{Channel: "", MaxAgeInDays: 30},
+	}
+	defer func() { configuration.Conf().Blockchain.Retention = nil }()
+
+	assert.Equal(t, getRetention("channel1"), configuration.Retention{Channel: "channel1", MaxBlocks: 100})
+	assert.Equal(t, getRetention("channel2"), configuration.Retention{Channel: "channel2", MaxAgeInDays: 30})
+
+	configuration.Conf().Blockchain.Retention = nil
+	assert.Equal(t, getRetention("channel2"), configuration.Retention{Channel: "channel2"})
+}
+
+func TestPruneBlocks(t *testing.T) {
+	dbpath := "test_retention.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(30)
+	defer node.close()
+
+	channelName := "channel_retention"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// The blocks up to 10 are older than the retention.
+	now := time.Now()
+	Database().Model(&Block{}).Where("channel = ? AND block_height <= ?", channelName, 10).Update(
+		"timestamp", now.AddDate(0, 0, -10))
+
+	pruneHeight, err := findPruneHeight(channelName, configuration.Retention{MaxAgeInDays: 7}, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pruneHeight, int64(10))
+
+	count, err := pruneBlocks(channelName, pruneHeight)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(10))
+
+	var blocks []Block
+	assert.Equal(t, QueryBlockHashesInRange(channelName, 1, 30, &blocks), nil)
+	assert.Equal(t, len(blocks), 20)
+	assert.Equal(t, blocks[0].BlockHeight, int64(11))
+
+	var countOfTxs, countOfLinks int
+	Database().Model(&Tx{}).Where("channel = ? AND block_height <= ?", channelName, 10).Count(&countOfTxs)
+	assert.Equal(t, countOfTxs, 0)
+	Database().Table("block_tx").Count(&countOfLinks)
+	assert.Equal(t, countOfLinks, 20)
+
+	// Backfill doesn't crawl the pruned blocks again.
+	requests := node.countOfRequests()
+	countOfMissing, err := backfillChannel(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfMissing, int64(0))
+	assert.Equal(t, node.countOfRequests(), requests)
+
+	// Keep the most recent blocks. The latest block is always retained.
+	pruneHeight, err = findPruneHeight(channelName, configuration.Retention{MaxBlocks: 5}, now)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pruneHeight, int64(25))
+	pruneHeight, err = findPruneHeight(channelName, configuration.Retention{MaxAgeInDays: 7, MaxBlocks: 0}, now.AddDate(0, 0, 30))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pruneHeight, int64(29))
+
+	// Nothing is pruned while crawling is paused.
+	assert.Equal(t, PauseCrawling(channelName), nil)
+	count, err = pruneBlocks(channelName, 25)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(0))
+	assert.Equal(t, QueryBlockHashesInRange(channelName, 1, 30, &blocks), nil)
+	assert.Equal(t, len(blocks), 20)
+	var status RetentionStatus
+	assert.Equal(t, QueryRetentionInChannel(channelName, &status), nil)
+	assert.Equal(t, status.PrunedRange.EndHeight, int64(10))
+	assert.Equal(t, ResumeCrawling(channelName), nil)
+
+	count, err = pruneBlocks(channelName, 25)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(15))
+
+	assert.Equal(t, QueryRetentionInChannel(channelName, &status), nil)
+	assert.Equal(t, status.PrunedRange.BeginHeight, int64(1))
+	assert.Equal(t, status.PrunedRange.EndHeight, int64(25))
+	assert.Equal(t, status.PrunedRange.CountOfBlocks, int64(25))
+	assert.Equal(t, status.RetainedSinceHeight, int64(26))
+	assert.Equal(t, status.RetainedSince.IsZero(), false)
+}
+
+func TestPruneSymptoms(t *testing.T) {
+	dbpath := "test_retention_symptom.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		symptom := Symptom{
+			Channel:     "channel1",
+			Msg:         "[node4]response time slowly [25.106827] sec",
+			SymptomType: "Slow response",
+			Timestamp:   now.AddDate(0, 0, -i),
+		}
+		Database().Save(&symptom)
+	}
+
+	count, err := pruneSymptoms(now.AddDate(0, 0, -5).Add(time.Minute))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(5))
+
+	var countOfSymptoms int
+	Database().Unscoped().Model(&Symptom{}).Count(&countOfSymptoms)
+	assert.Equal(t, countOfSymptoms, 5)
+}
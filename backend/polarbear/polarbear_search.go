@@ -0,0 +1,206 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of the search query.
+const (
+	SearchQueryHeight   = "height"   // Block height in decimal.
+	SearchQueryHash     = "hash"     // Block hash or Tx hash in hex, with or without 0x.
+	SearchQueryAddress  = "address"  // Address starting with hx.
+	SearchQueryContract = "contract" // Address of contract starting with cx.
+)
+
+// Types of the search hit.
+const (
+	SearchHitBlock    = "block"
+	SearchHitTx       = "tx"
+	SearchHitAddress  = "address"
+	SearchHitContract = "contract"
+)
+
+// Ranks of the search hit. The hit identified exactly ranks higher than the hit every channel may have.
+const (
+	searchRankHeight  = 1
+	searchRankAddress = 2
+	searchRankExact   = 3
+)
+
+const (
+	lengthOfHash    = 64
+	lengthOfAddress = 42
+)
+
+// SearchHit is the block, Tx, address or contract found by the search in channel.
+type SearchHit struct {
+	Type        string
+	Channel     string
+	Key         string // Block hash, Tx hash or address.
+	BlockHeight int64
+	Timestamp   time.Time
+	Rank        int
+}
+
+// ClassifySearchQuery returns the kind of the search query, and the query normalized to search.
+func ClassifySearchQuery(query string) (string, string, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	if height, err := strconv.ParseInt(query, 10, 64); err == nil {
+		if height < 0 {
+			return "", "", isaacerror.SysErrInvalidSearchQuery
+		}
+		return SearchQueryHeight, strconv.FormatInt(height, 10), nil
+	}
+
+	if len(query) == lengthOfAddress && isHexString(query[2:]) {
+		switch query[:2] {
+		case "hx":
+			return SearchQueryAddress, query, nil
+		case "cx":
+			return SearchQueryContract, query, nil
+		}
+	}
+
+	hash := strings.TrimPrefix(query, "0x")
+	if len(hash) == lengthOfHash && isHexString(hash) {
+		return SearchQueryHash, hash, nil
+	}
+
+	return "", "", isaacerror.SysErrInvalidSearchQuery
+}
+
+// SearchInChannels searches the query in the channels, and returns the kind of the query.
+// The hits are ordered by the rank, the latest and the name of channel.
+func SearchInChannels(channelNames []string, query string, out *[]SearchHit) (string, error) {
+	kind, key, err := ClassifySearchQuery(query)
+	if err != nil {
+		return "", err
+	}
+
+	hits := make([]SearchHit, 0)
+	for _, channelName := range channelNames {
+		var found []SearchHit
+		switch kind {
+		case SearchQueryHeight:
+			found, err = searchBlockByHeight(channelName, key)
+		case SearchQueryHash:
+			found, err = searchHash(channelName, key)
+		case SearchQueryAddress:
+			found, err = searchAddress(channelName, key)
+		case SearchQueryContract:
+			if found, err = searchContract(channelName, key); err == nil {
+				var activity []SearchHit
+				activity, err = searchAddress(channelName, key)
+				found = append(found, activity...)
+			}
+		}
+		if err != nil {
+			logger.Errorf("Fail to search %s in %s. %s", query, channelName, err)
+			return "", isaacerror.SysErrFailToSearch
+		}
+		hits = append(hits, found...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Rank != hits[j].Rank {
+			return hits[i].Rank > hits[j].Rank
+		}
+		if !hits[i].Timestamp.Equal(hits[j].Timestamp) {
+			return hits[i].Timestamp.After(hits[j].Timestamp)
+		}
+		return hits[i].Channel < hits[j].Channel
+	})
+	*out = hits
+
+	return kind, nil
+}
+
+// searchBlockByHeight searches the block at the height in channel.
+func searchBlockByHeight(channelName string, key string) ([]SearchHit, error) {
+	height, _ := strconv.ParseInt(key, 10, 64)
+
+	var blocks []Block
+	if err := Database().Where("channel = ? AND block_height = ?", channelName, height).Limit(1).Find(
+		&blocks).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(blocks))
+	for _, block := range blocks {
+		hits = append(hits, SearchHit{Type: SearchHitBlock, Channel: channelName, Key: block.BlockHash,
+			BlockHeight: block.BlockHeight, Timestamp: block.Timestamp, Rank: searchRankHeight})
+	}
+	return hits, nil
+}
+
+// searchHash searches the block and the Tx of the hash in channel. The hash is stored with or without 0x.
+func searchHash(channelName string, hash string) ([]SearchHit, error) {
+	hashes := []string{hash, "0x" + hash}
+
+	var blocks []Block
+	if err := Database().Where("channel = ? AND block_hash IN (?)", channelName, hashes).Limit(1).Find(
+		&blocks).Error; err != nil {
+		return nil, err
+	}
+	var txs []Tx
+	if err := Database().Where("channel = ? AND tx_hash IN (?)", channelName, hashes).Limit(1).Find(
+		&txs).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(blocks)+len(txs))
+	for _, block := range blocks {
+		hits = append(hits, SearchHit{Type: SearchHitBlock, Channel: channelName, Key: block.BlockHash,
+			BlockHeight: block.BlockHeight, Timestamp: block.Timestamp, Rank: searchRankExact})
+	}
+	for _, tx := range txs {
+		hits = append(hits, SearchHit{Type: SearchHitTx, Channel: channelName, Key: tx.TxHash,
+			BlockHeight: tx.BlockHeight, Timestamp: tx.Timestamp, Rank: searchRankExact})
+	}
+	return hits, nil
+}
+
+// searchAddress searches the address which sent or received Txs in channel, with the latest Tx of it.
+func searchAddress(channelName string, address string) ([]SearchHit, error) {
+	condition, _ := addressCondition("")
+
+	var txs []Tx
+	if err := Database().Where("channel = ?", channelName).Where(condition, address, address).Order(
+		"timestamp desc").Limit(1).Find(&txs).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(txs))
+	for _, tx := range txs {
+		hits = append(hits, SearchHit{Type: SearchHitAddress, Channel: channelName, Key: address,
+			BlockHeight: tx.BlockHeight, Timestamp: tx.Timestamp, Rank: searchRankAddress})
+	}
+	return hits, nil
+}
+
+// searchContract searches the contract in channel, with the latest deployment or update of it.
+func searchContract(channelName string, address string) ([]SearchHit, error) {
+	var contracts []Contract
+	if err := Database().Where("channel = ? AND address = ?", channelName, address).Limit(1).Find(
+		&contracts).Error; err != nil {
+		return nil, err
+	}
+
+	hits := make([]SearchHit, 0, len(contracts))
+	for _, contract := range contracts {
+		hit := SearchHit{Type: SearchHitContract, Channel: channelName, Key: contract.Address,
+			BlockHeight: contract.DeployHeight, Timestamp: contract.DeployTimestamp, Rank: searchRankExact}
+		if contract.LastUpdateHeight > contract.DeployHeight {
+			hit.BlockHeight = contract.LastUpdateHeight
+			hit.Timestamp = contract.LastUpdateTimestamp
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
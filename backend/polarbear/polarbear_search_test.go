@@ -0,0 +1,121 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestClassifySearchQuery(t *testing.T) {
+	hash := "1fcf7c34dc875681761bdaa5d75d770e78e8166b5c4f06c226c53300cbe85f57"
+
+	testCases := []struct {
+		query string
+		kind  string
+		key   string
+		err   error
+	}{
+		{" 100 ", SearchQueryHeight, "100", nil},
+		{"0", SearchQueryHeight, "0", nil},
+		{"-1", "", "", isaacerror.SysErrInvalidSearchQuery},
+		{hash, SearchQueryHash, hash, nil},
+		{"0x" + strings.ToUpper(hash), SearchQueryHash, hash, nil},
+		{"hx5d91dee6102ead2aca60256cf33ebf9aab102c82", SearchQueryAddress, "hx5d91dee6102ead2aca60256cf33ebf9aab102c82", nil},
+		{"cx0000000000000000000000000000000000000001", SearchQueryContract, "cx0000000000000000000000000000000000000001", nil},
+		{"hx1234", "", "", isaacerror.SysErrInvalidSearchQuery},
+		{"0x" + hash[:63], "", "", isaacerror.SysErrInvalidSearchQuery},
+		{"", "", "", isaacerror.SysErrInvalidSearchQuery},
+	}
+
+	for _, testCase := range testCases {
+		kind, key, err := ClassifySearchQuery(testCase.query)
+		assert.Equal(t, kind, testCase.kind)
+		assert.Equal(t, key, testCase.key)
+		assert.Equal(t, err, testCase.err)
+	}
+}
+
+func TestSearchInChannels(t *testing.T) {
+	dbpath := "test_search.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(3)
+	defer node.close()
+
+	channelName := "channel_search"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// The block in another channel at the same height, later than the crawled one.
+	other := Block{Channel: "channel_other", BlockHeight: 2, BlockHash: "0x1234", Timestamp: time.Now().Add(time.Hour)}
+	assert.Equal(t, Database().Create(&other).Error, nil)
+	channelNames := []string{channelName, "channel_other", "channel_empty"}
+
+	// Height.
+	var hits []SearchHit
+	kind, err := SearchInChannels(channelNames, "2", &hits)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, kind, SearchQueryHeight)
+	assert.Equal(t, len(hits), 2)
+	assert.Equal(t, hits[0].Channel, "channel_other")
+	assert.Equal(t, hits[1].Channel, channelName)
+	assert.Equal(t, hits[1].Type, SearchHitBlock)
+	assert.Equal(t, hits[1].Key, fakeBlockHash(node.blocks[2]))
+
+	// Block hash without 0x.
+	kind, err = SearchInChannels(channelNames, strings.TrimPrefix(fakeBlockHash(node.blocks[3]), "0x"), &hits)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, kind, SearchQueryHash)
+	assert.Equal(t, len(hits), 1)
+	assert.Equal(t, hits[0].Type, SearchHitBlock)
+	assert.Equal(t, hits[0].BlockHeight, int64(3))
+
+	// Tx hash.
+	var tx Tx
+	assert.Equal(t, Database().Where("channel = ? AND block_height = ?", channelName, 1).First(&tx).Error, nil)
+	_, err = SearchInChannels(channelNames, tx.TxHash, &hits)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(hits), 1)
+	assert.Equal(t, hits[0].Type, SearchHitTx)
+	assert.Equal(t, hits[0].Key, tx.TxHash)
+	assert.Equal(t, hits[0].Channel, channelName)
+
+	// Address with the latest Tx.
+	kind, _ = SearchInChannels(channelNames, tx.From, &hits)
+	assert.Equal(t, kind, SearchQueryAddress)
+	assert.Equal(t, len(hits), 1)
+	assert.Equal(t, hits[0].Type, SearchHitAddress)
+	assert.Equal(t, hits[0].BlockHeight, int64(1))
+
+	// Contract ranks higher than the Txs of it.
+	contract := Contract{Channel: channelName, Address: "cx0000000000000000000000000000000000000002", DeployHeight: 1}
+	assert.Equal(t, Database().Create(&contract).Error, nil)
+	assert.Equal(t, Database().Create(&Tx{Channel: channelName, TxHash: "0xabcd", BlockHeight: 3,
+		To: contract.Address, Timestamp: time.Now()}).Error, nil)
+	kind, _ = SearchInChannels(channelNames, contract.Address, &hits)
+	assert.Equal(t, kind, SearchQueryContract)
+	assert.Equal(t, len(hits), 2)
+	assert.Equal(t, hits[0].Type, SearchHitContract)
+	assert.Equal(t, hits[0].BlockHeight, int64(1))
+	assert.Equal(t, hits[1].Type, SearchHitAddress)
+
+	// Nothing is found.
+	_, err = SearchInChannels(channelNames, "hx0000000000000000000000000000000000000099", &hits)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(hits), 0)
+	_, err = SearchInChannels(channelNames, "block", &hits)
+	assert.Equal(t, err, isaacerror.SysErrInvalidSearchQuery)
+}
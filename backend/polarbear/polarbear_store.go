@@ -0,0 +1,102 @@
+package polarbear
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Store is the storage of the blocks, Txs and symptoms of polarbear. The crawler and the queries of handlers
+// for them go through the store set by SetStore. The gorm store of InitDB is set by default.
+// The out of queries is the pointer of a slice, or of a struct to get the first one, like Find of gorm.
+// The store doesn't replace DB of InitDB. The contracts, tokens, event logs, addresses, quarantine, retention,
+// crawl states and search are derived and queried in DB directly, so they follow the gorm store only.
+type Store interface {
+	// SaveBlock saves the block with the Txs in it. The block saved before is not saved again.
+	SaveBlock(block *Block) error
+
+	// GetCurrentBlockHeight returns the highest block height in channel. 0 if no block, -1 if it fails.
+	GetCurrentBlockHeight(channelName string) int64
+
+	// QueryBlockHashesInRange queries the blocks without Txs between the heights in channel, ordered by height.
+	QueryBlockHashesInRange(channelName string, beginHeight int64, endHeight int64, out *[]Block) error
+
+	// FindMissingBlockHeights finds the ranges of block heights not stored between the heights in channel.
+	FindMissingBlockHeights(channelName string, beginHeight int64, endHeight int64) ([]HeightRange, error)
+
+	// QueryBlocks queries the blocks in channel ordered by height desc. Returns the count of blocks in channel.
+	QueryBlocks(channelName string, limit int, offset int, out interface{}) (int64, error)
+
+	// QueryBlockByHeight queries the block at the height in channel.
+	QueryBlockByHeight(channelName string, height int64, out interface{}) error
+
+	// QueryBlockByHash queries the block of the hash in channel.
+	QueryBlockByHash(channelName string, blockHash string, out interface{}) error
+
+	// DeleteBlocksFromHeight deletes the blocks and their Txs from the height to the top in channel.
+	DeleteBlocksFromHeight(channelName string, height int64) error
+
+	// DeleteBlocksInRange deletes the blocks and their Txs between the heights in channel.
+	// Returns the count of blocks deleted.
+	DeleteBlocksInRange(channelName string, beginHeight int64, endHeight int64) (int64, error)
+
+	// QueryTxs queries the Txs searched in channel with the results, ordered by height and time desc.
+	// Returns the count of Txs searched.
+	QueryTxs(channelName string, limit int, offset int, search TxSearch, out interface{}) (int64, error)
+
+	// QueryTxByHash queries the Tx of the hash in channel with the result.
+	QueryTxByHash(channelName string, txHash string, out interface{}) error
+
+	// AddSymptom saves the symptom.
+	AddSymptom(symptom *Symptom) error
+
+	// QuerySymptoms queries the symptoms of the channel PKs ordered by time desc. Zero time is no limit.
+	// Returns the count of symptoms searched.
+	QuerySymptoms(channelPKs []string, from time.Time, to time.Time, limit int, offset int, out interface{}) (int64, error)
+}
+
+var currentStore Store
+var currentStoreLock sync.RWMutex
+
+// SetStore sets the store used by polarbear.
+func SetStore(s Store) {
+	currentStoreLock.Lock()
+	defer currentStoreLock.Unlock()
+
+	currentStore = s
+}
+
+// CurrentStore returns the store used by polarbear.
+func CurrentStore() Store {
+	currentStoreLock.RLock()
+	defer currentStoreLock.RUnlock()
+
+	return currentStore
+}
+
+// assignOut sets the items into out like Find of gorm. items is a slice of the type of out.
+// If out is the pointer of a struct, the first item is set, or gorm.ErrRecordNotFound if no item.
+func assignOut(out interface{}, items interface{}) error {
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return gorm.ErrInvalidSQL
+	}
+
+	src := reflect.ValueOf(items)
+	dst := value.Elem()
+	switch {
+	case dst.Type() == src.Type():
+		dst.Set(src)
+	case dst.Type() == src.Type().Elem():
+		if src.Len() == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		dst.Set(src.Index(0))
+	default:
+		return gorm.ErrInvalidSQL
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package polarbear
+
+import (
+	"math"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// gormStore is the store in the DB of gorm. The contracts and token transfers in the blocks are saved together.
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore returns the store in the DB of gorm. The tables should be created by InitDB.
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+// SaveBlock saves the block with the contracts and token transfers in it.
+func (s *gormStore) SaveBlock(block *Block) error {
+	if s.db.NewRecord(&block) {
+		transfers := buildTokenTransfersFromBlock(block)
+		if len(transfers) != 0 {
+			tokenBalanceLock.Lock()
+			defer tokenBalanceLock.Unlock()
+		}
+
+		// Save the block with the contracts and token transfers in it at once.
+		tx := s.db.Begin()
+		if err := tx.Save(&block).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := registerContractsInBlock(tx, block); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := registerTokenTransfers(tx, transfers); err != nil {
+			tx.Rollback()
+			return err
+		}
+		return tx.Commit().Error
+	}
+
+	return nil
+}
+
+func (s *gormStore) GetCurrentBlockHeight(channelName string) int64 {
+	var block Block
+	blockTable := s.db.Model(&Block{}).Order("block_height desc")
+	if err := blockTable.First(&block, &Block{Channel: channelName}).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			logger.Info("No blocks in the DB. ")
+			return 0
+		}
+		logger.Errorf("%s", err)
+		return -1
+	}
+
+	return block.BlockHeight
+}
+
+func (s *gormStore) QueryBlockHashesInRange(channelName string, beginHeight int64, endHeight int64,
+	out *[]Block) error {
+	if err := s.db.Model(&Block{}).Where(
+		"channel = ? AND block_height BETWEEN ? AND ?", channelName, beginHeight, endHeight).Order(
+		"block_height asc").Find(out).Error; err != nil {
+		return isaacerror.SysErrFailToQueryBlocksInChannel
+	}
+
+	return nil
+}
+
+func (s *gormStore) FindMissingBlockHeights(channelName string, beginHeight int64, endHeight int64) (
+	[]HeightRange, error) {
+	missing := make([]HeightRange, 0)
+
+	// The lowest block height is missing if no block at the height.
+	var lowest []int64
+	if err := s.db.Model(&Block{}).Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Order("block_height asc").Limit(1).Pluck(
+		"block_height", &lowest).Error; err != nil {
+		return nil, isaacerror.SysErrFailToQueryBlocksInChannel
+	}
+	if len(lowest) == 0 {
+		return append(missing, HeightRange{Begin: beginHeight, End: endHeight}), nil
+	}
+	if lowest[0] > beginHeight {
+		missing = append(missing, HeightRange{Begin: beginHeight, End: lowest[0] - 1})
+	}
+
+	// Find the blocks which don't have the next block.
+	rows, err := s.db.Raw(`SELECT b.block_height + 1 FROM blocks b
+		LEFT JOIN blocks n ON n.channel = b.channel AND n.block_height = b.block_height + 1 AND n.deleted_at IS NULL
+		WHERE b.channel = ? AND b.block_height >= ? AND b.block_height < ? AND b.deleted_at IS NULL AND n.id IS NULL
+		ORDER BY b.block_height`, channelName, beginHeight, endHeight).Rows()
+	if err != nil {
+		return nil, isaacerror.SysErrFailToQueryBlocksInChannel
+	}
+
+	var gapBegins []int64
+	for rows.Next() {
+		var gapBegin int64
+		if err := rows.Scan(&gapBegin); err != nil {
+			rows.Close()
+			return nil, isaacerror.SysErrFailToQueryBlocksInChannel
+		}
+		gapBegins = append(gapBegins, gapBegin)
+	}
+	rows.Close()
+
+	for _, gapBegin := range gapBegins {
+		var next []int64
+		if err := s.db.Model(&Block{}).Where("channel = ? AND block_height > ?",
+			channelName, gapBegin).Order("block_height asc").Limit(1).Pluck("block_height", &next).Error; err != nil {
+			return nil, isaacerror.SysErrFailToQueryBlocksInChannel
+		}
+
+		gapEnd := endHeight
+		if len(next) != 0 && next[0]-1 < gapEnd {
+			gapEnd = next[0] - 1
+		}
+		missing = append(missing, HeightRange{Begin: gapBegin, End: gapEnd})
+	}
+
+	return missing, nil
+}
+
+func (s *gormStore) QueryBlocks(channelName string, limit int, offset int, out interface{}) (int64, error) {
+	blockTable := s.db.Preload("Txs").Model(&Block{}).Order("block_height desc")
+	if err := blockTable.Offset(offset).Limit(limit).Find(out, &Block{
+		Channel: channelName,
+	}).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryBlockInChannel
+	}
+
+	var count int64
+	s.db.Model(&Block{}).Where(&Block{
+		Channel: channelName,
+	}).Count(&count)
+	return count, nil
+}
+
+func (s *gormStore) QueryBlockByHeight(channelName string, height int64, out interface{}) error {
+	blockTable := s.db.Preload("Txs").Model(&Block{}).Order("block_height desc")
+	if err := blockTable.Find(out, &Block{
+		Channel:     channelName,
+		BlockHeight: height},
+	).Error; err != nil {
+		return isaacerror.SysErrFailToQueryBlocksInChannel
+	}
+
+	return nil
+}
+
+func (s *gormStore) QueryBlockByHash(channelName string, blockHash string, out interface{}) error {
+	blockTable := s.db.Preload("Txs").Model(&Block{}).Order("block_height desc")
+	if err := blockTable.Find(out, &Block{
+		Channel:   channelName,
+		BlockHash: blockHash},
+	).Error; err != nil {
+		return isaacerror.SysErrFailToQueryBlockInChannel
+	}
+
+	return nil
+}
+
+// DeleteBlocksFromHeight deletes the blocks and their Txs with results, event logs, contract history
+// and token transfers from the height to the top in channel.
+func (s *gormStore) DeleteBlocksFromHeight(channelName string, height int64) error {
+	_, err := s.DeleteBlocksInRange(channelName, height, math.MaxInt64)
+	return err
+}
+
+func (s *gormStore) DeleteBlocksInRange(channelName string, beginHeight int64, endHeight int64) (int64, error) {
+	tokenBalanceLock.Lock()
+	defer tokenBalanceLock.Unlock()
+
+	tx := s.db.Begin()
+
+	var blockIDs []uint
+	if err := tx.Model(&Block{}).Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Pluck("id", &blockIDs).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if len(blockIDs) != 0 {
+		if err := tx.Exec("DELETE FROM block_tx WHERE block_id IN (?)", blockIDs).Error; err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := deleteContractsInRange(tx, channelName, beginHeight, endHeight); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := deleteTokenTransfersInRange(tx, channelName, beginHeight, endHeight); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&EventLog{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&TxResult{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&PendingTx{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&Tx{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&Block{}).Error; err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+	return int64(len(blockIDs)), nil
+}
+
+func (s *gormStore) QueryTxs(channelName string, limit int, offset int, search TxSearch, out interface{}) (
+	int64, error) {
+	var count int64
+
+	txTable, err := searchTxsIn(s.db, channelName, search)
+	if err != nil {
+		return -1, err
+	}
+
+	// Get count about Tx list.
+	txTable.Count(&count)
+
+	// Get Tx list.
+	err = txTable.Preload("Result").Offset(offset).Limit(limit).Order("block_height desc").Order(
+		"timestamp desc").Find(out, &Tx{}).Error
+	if err != nil {
+		return -1, isaacerror.SysErrFailToQueryTxsInChannel
+	}
+
+	return count, nil
+}
+
+func (s *gormStore) QueryTxByHash(channelName string, txHash string, out interface{}) error {
+	if err := s.db.Preload("Result").Model(&Tx{}).Find(out, &Tx{
+		Channel: channelName,
+		TxHash:  txHash,
+	}).Error; err != nil {
+		return isaacerror.SysErrFailToQueryTxInChannel
+	}
+
+	return nil
+}
+
+func (s *gormStore) AddSymptom(symptom *Symptom) error {
+	if s.db.NewRecord(symptom) {
+		if err := s.db.Save(symptom).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *gormStore) QuerySymptoms(channelPKs []string, from time.Time, to time.Time, limit int, offset int,
+	out interface{}) (int64, error) {
+	symptomTable := s.db.Model(&Symptom{}).Order("timestamp desc")
+	if !from.IsZero() && !to.IsZero() {
+		symptomTable = symptomTable.Where("timestamp BETWEEN ? AND ?", from, to)
+	}
+
+	var count int64
+	symptomTable.Where("channel_pk IN (?)", channelPKs).
+		Count(&count)
+
+	if err := symptomTable.Offset(offset).Limit(limit).
+		Where("channel_pk IN (?)", channelPKs).
+		Find(out, &Symptom{}).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryPeerSymptom
+	}
+
+	return count, nil
+}
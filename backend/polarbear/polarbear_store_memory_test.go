@@ -0,0 +1,306 @@
+package polarbear
+
+import (
+	"math"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/utility"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryStore is the store in memory for testing the crawler and the queries of blocks, Txs and symptoms.
+// The contracts and token transfers are not derived, and the other data is still in DB of InitDB.
+type memoryStore struct {
+	mu       sync.RWMutex
+	lastID   uint
+	blocks   []Block // In the order of saving.
+	symptoms []Symptom
+}
+
+// newMemoryStore returns the empty store in memory.
+func newMemoryStore() Store {
+	return &memoryStore{}
+}
+
+// nextID returns the new ID of record. The lock should be held.
+func (s *memoryStore) nextID() uint {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *memoryStore) SaveBlock(block *Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if block.ID != 0 {
+		return nil
+	}
+
+	now := time.Now()
+	block.ID = s.nextID()
+	block.CreatedAt, block.UpdatedAt = now, now
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		tx.ID = s.nextID()
+		tx.CreatedAt, tx.UpdatedAt = now, now
+		if tx.Result.TxHash != "" {
+			tx.Result.ID = s.nextID()
+			tx.Result.TxID = tx.ID
+			tx.Result.CreatedAt, tx.Result.UpdatedAt = now, now
+		}
+	}
+
+	s.blocks = append(s.blocks, copyBlock(block))
+	return nil
+}
+
+func (s *memoryStore) GetCurrentBlockHeight(channelName string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var height int64
+	for i := range s.blocks {
+		if s.blocks[i].Channel == channelName && s.blocks[i].BlockHeight > height {
+			height = s.blocks[i].BlockHeight
+		}
+	}
+	return height
+}
+
+func (s *memoryStore) QueryBlockHashesInRange(channelName string, beginHeight int64, endHeight int64,
+	out *[]Block) error {
+	blocks := s.findBlocks(func(block *Block) bool {
+		return block.Channel == channelName && block.BlockHeight >= beginHeight && block.BlockHeight <= endHeight
+	})
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].BlockHeight < blocks[j].BlockHeight })
+	for i := range blocks {
+		blocks[i].Txs = nil
+	}
+
+	*out = blocks
+	return nil
+}
+
+func (s *memoryStore) FindMissingBlockHeights(channelName string, beginHeight int64, endHeight int64) (
+	[]HeightRange, error) {
+	var blocks []Block
+	_ = s.QueryBlockHashesInRange(channelName, beginHeight, endHeight, &blocks)
+
+	missing := make([]HeightRange, 0)
+	next := beginHeight
+	for _, block := range blocks {
+		if block.BlockHeight > next {
+			missing = append(missing, HeightRange{Begin: next, End: block.BlockHeight - 1})
+		}
+		if block.BlockHeight >= next {
+			next = block.BlockHeight + 1
+		}
+	}
+	if next <= endHeight {
+		missing = append(missing, HeightRange{Begin: next, End: endHeight})
+	}
+
+	return missing, nil
+}
+
+func (s *memoryStore) QueryBlocks(channelName string, limit int, offset int, out interface{}) (int64, error) {
+	blocks := s.findBlocks(func(block *Block) bool { return block.Channel == channelName })
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].BlockHeight > blocks[j].BlockHeight })
+
+	begin, end := pageRange(len(blocks), limit, offset)
+	if err := assignOut(out, blocks[begin:end]); err != nil {
+		return -1, isaacerror.SysErrFailToQueryBlockInChannel
+	}
+	return int64(len(blocks)), nil
+}
+
+func (s *memoryStore) QueryBlockByHeight(channelName string, height int64, out interface{}) error {
+	blocks := s.findBlocks(func(block *Block) bool {
+		return block.Channel == channelName && block.BlockHeight == height
+	})
+
+	if err := assignOut(out, blocks); err != nil {
+		return isaacerror.SysErrFailToQueryBlocksInChannel
+	}
+	return nil
+}
+
+func (s *memoryStore) QueryBlockByHash(channelName string, blockHash string, out interface{}) error {
+	blocks := s.findBlocks(func(block *Block) bool {
+		return block.Channel == channelName && block.BlockHash == blockHash
+	})
+
+	if err := assignOut(out, blocks); err != nil {
+		return isaacerror.SysErrFailToQueryBlockInChannel
+	}
+	return nil
+}
+
+func (s *memoryStore) DeleteBlocksFromHeight(channelName string, height int64) error {
+	_, err := s.DeleteBlocksInRange(channelName, height, math.MaxInt64)
+	return err
+}
+
+func (s *memoryStore) DeleteBlocksInRange(channelName string, beginHeight int64, endHeight int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	remains := s.blocks[:0]
+	for _, block := range s.blocks {
+		if block.Channel != channelName || block.BlockHeight < beginHeight || block.BlockHeight > endHeight {
+			remains = append(remains, block)
+		}
+	}
+	count := int64(len(s.blocks) - len(remains))
+	s.blocks = remains
+	return count, nil
+}
+
+func (s *memoryStore) QueryTxs(channelName string, limit int, offset int, search TxSearch, out interface{}) (
+	int64, error) {
+	if search.From.IsZero() != search.To.IsZero() {
+		return -1, isaacerror.SysErrInvalidTimeSearchCondition
+	}
+
+	txs := s.findTxs(func(tx *Tx) bool { return tx.Channel == channelName && matchTx(tx, search) })
+	sort.SliceStable(txs, func(i, j int) bool {
+		if txs[i].BlockHeight != txs[j].BlockHeight {
+			return txs[i].BlockHeight > txs[j].BlockHeight
+		}
+		return txs[i].Timestamp.After(txs[j].Timestamp)
+	})
+
+	begin, end := pageRange(len(txs), limit, offset)
+	if err := assignOut(out, txs[begin:end]); err != nil {
+		return -1, isaacerror.SysErrFailToQueryTxsInChannel
+	}
+	return int64(len(txs)), nil
+}
+
+func (s *memoryStore) QueryTxByHash(channelName string, txHash string, out interface{}) error {
+	txs := s.findTxs(func(tx *Tx) bool { return tx.Channel == channelName && tx.TxHash == txHash })
+
+	if err := assignOut(out, txs); err != nil {
+		return isaacerror.SysErrFailToQueryTxInChannel
+	}
+	return nil
+}
+
+func (s *memoryStore) AddSymptom(symptom *Symptom) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if symptom.ID != 0 {
+		return nil
+	}
+
+	now := time.Now()
+	symptom.ID = s.nextID()
+	symptom.CreatedAt, symptom.UpdatedAt = now, now
+	s.symptoms = append(s.symptoms, *symptom)
+	return nil
+}
+
+func (s *memoryStore) QuerySymptoms(channelPKs []string, from time.Time, to time.Time, limit int, offset int,
+	out interface{}) (int64, error) {
+	s.mu.RLock()
+	symptoms := make([]Symptom, 0)
+	for _, symptom := range s.symptoms {
+		if !utility.IsExistValueInList(symptom.Channel_PK, channelPKs) {
+			continue
+		}
+		if !from.IsZero() && !to.IsZero() && (symptom.Timestamp.Before(from) || symptom.Timestamp.After(to)) {
+			continue
+		}
+		symptoms = append(symptoms, symptom)
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(symptoms, func(i, j int) bool { return symptoms[i].Timestamp.After(symptoms[j].Timestamp) })
+
+	begin, end := pageRange(len(symptoms), limit, offset)
+	if err := assignOut(out, symptoms[begin:end]); err != nil {
+		return -1, isaacerror.SysErrFailToQueryPeerSymptom
+	}
+	return int64(len(symptoms)), nil
+}
+
+// findBlocks returns the copies of blocks matched.
+func (s *memoryStore) findBlocks(match func(block *Block) bool) []Block {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blocks := make([]Block, 0)
+	for i := range s.blocks {
+		if match(&s.blocks[i]) {
+			blocks = append(blocks, copyBlock(&s.blocks[i]))
+		}
+	}
+	return blocks
+}
+
+// findTxs returns the copies of Txs matched in every block.
+func (s *memoryStore) findTxs(match func(tx *Tx) bool) []Tx {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	txs := make([]Tx, 0)
+	for i := range s.blocks {
+		for j := range s.blocks[i].Txs {
+			if match(&s.blocks[i].Txs[j]) {
+				txs = append(txs, s.blocks[i].Txs[j])
+			}
+		}
+	}
+	return txs
+}
+
+// matchTx checks the Tx is searched. The range of time should have both of from and to.
+func matchTx(tx *Tx, search TxSearch) bool {
+	if search.BlockHeight != -1 && tx.BlockHeight != search.BlockHeight {
+		return false
+	}
+	if search.Status != "" && tx.Status != search.Status {
+		return false
+	}
+	if search.FromAddress != "" && tx.From != search.FromAddress {
+		return false
+	}
+	if search.ToAddress != "" && tx.To != search.ToAddress {
+		return false
+	}
+	if !search.From.IsZero() && (tx.Timestamp.Before(search.From) || tx.Timestamp.After(search.To)) {
+		return false
+	}
+	if search.Data != "" && !strings.Contains(tx.Data, search.Data) {
+		return false
+	}
+	if search.FromHeight > 0 && tx.BlockHeight < search.FromHeight {
+		return false
+	}
+	if search.ToHeight > 0 && tx.BlockHeight > search.ToHeight {
+		return false
+	}
+	return true
+}
+
+// copyBlock copies the block not to share the Txs with the store.
+func copyBlock(block *Block) Block {
+	copied := *block
+	copied.Txs = append([]Tx(nil), block.Txs...)
+	return copied
+}
+
+// pageRange returns the range of the page in the items of length. Negative limit is no limit.
+func pageRange(length int, limit int, offset int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+	end := length
+	if limit >= 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return offset, end
+}
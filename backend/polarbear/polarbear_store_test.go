@@ -0,0 +1,174 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+// testStore checks the store with the blocks of heights 1 to 5 and the gap at 3, and the symptoms.
+func testStore(t *testing.T, s Store) {
+	channelName := "channel_store"
+	now := time.Now()
+	for _, h := range []int64{1, 2, 4, 5} {
+		block := Block{
+			Channel:     channelName,
+			BlockHeight: h,
+			BlockHash:   "0x" + strconv.FormatInt(h, 10),
+			Timestamp:   now,
+			Txs: []Tx{{
+				TxHash:      "0xa" + strconv.FormatInt(h, 10),
+				Status:      "Success",
+				Channel:     channelName,
+				BlockHeight: h,
+				Timestamp:   now,
+				From:        "hx5d91dee6102ead2aca60256cf33ebf9aab102c82",
+				To:          "cx54d95fee187faaea03cee908f50623c8381179d0",
+				Data:        `{"method":"transfer"}`,
+				Result:      TxResult{TxHash: "0xa" + strconv.FormatInt(h, 10), Channel: channelName, Fee: "100"},
+			}},
+		}
+		assert.Equal(t, s.SaveBlock(&block), nil)
+		assert.NotEqual(t, block.ID, uint(0))
+
+		// Saved once.
+		assert.Equal(t, s.SaveBlock(&block), nil)
+	}
+
+	assert.Equal(t, s.GetCurrentBlockHeight(channelName), int64(5))
+	assert.Equal(t, s.GetCurrentBlockHeight("other"), int64(0))
+
+	// Blocks.
+	var blocks []Block
+	count, err := s.QueryBlocks(channelName, 2, 1, &blocks)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(4))
+	assert.Equal(t, len(blocks), 2)
+	assert.Equal(t, blocks[0].BlockHeight, int64(4))
+	assert.Equal(t, len(blocks[0].Txs), 1)
+
+	var block Block
+	assert.Equal(t, s.QueryBlockByHeight(channelName, 2, &block), nil)
+	assert.Equal(t, block.BlockHash, "0x2")
+	assert.NotEqual(t, s.QueryBlockByHeight(channelName, 3, &block), nil)
+
+	block = Block{}
+	assert.Equal(t, s.QueryBlockByHash(channelName, "0x4", &block), nil)
+	assert.Equal(t, block.BlockHeight, int64(4))
+
+	assert.Equal(t, s.QueryBlockHashesInRange(channelName, 2, 5, &blocks), nil)
+	assert.Equal(t, len(blocks), 3)
+	assert.Equal(t, blocks[0].BlockHeight, int64(2))
+
+	missing, err := s.FindMissingBlockHeights(channelName, 1, 7)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, missing, []HeightRange{{Begin: 3, End: 3}, {Begin: 6, End: 7}})
+
+	// Txs.
+	var txs []Tx
+	count, err = s.QueryTxs(channelName, 10, 0, TxSearch{BlockHeight: -1, FromHeight: 2, ToHeight: 4}, &txs)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(2))
+	assert.Equal(t, txs[0].BlockHeight, int64(4))
+	assert.Equal(t, txs[0].Result.Fee, "100")
+
+	count, _ = s.QueryTxs(channelName, 10, 0, TxSearch{BlockHeight: 5, Data: "transfer"}, &txs)
+	assert.Equal(t, count, int64(1))
+	count, _ = s.QueryTxs(channelName, 10, 0, TxSearch{BlockHeight: -1, Status: "Failure"}, &txs)
+	assert.Equal(t, count, int64(0))
+
+	var tx Tx
+	assert.Equal(t, s.QueryTxByHash(channelName, "0xa5", &tx), nil)
+	assert.Equal(t, tx.BlockHeight, int64(5))
+
+	// Delete from the height.
+	assert.Equal(t, s.DeleteBlocksFromHeight(channelName, 4), nil)
+	assert.Equal(t, s.GetCurrentBlockHeight(channelName), int64(2))
+	count, _ = s.QueryTxs(channelName, 10, 0, TxSearch{BlockHeight: -1}, &txs)
+	assert.Equal(t, count, int64(2))
+
+	// Delete in the range. The missing heights are not counted.
+	count, err = s.DeleteBlocksInRange(channelName, 2, 3)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, s.GetCurrentBlockHeight(channelName), int64(1))
+
+	// Symptoms.
+	for i := 0; i < 3; i++ {
+		symptom := Symptom{
+			Channel:     channelName,
+			Channel_PK:  "PKCH_" + strconv.Itoa(i%2),
+			SymptomType: constants.SlowResponse,
+			Timestamp:   now.Add(-time.Duration(i) * time.Hour),
+		}
+		assert.Equal(t, s.AddSymptom(&symptom), nil)
+	}
+
+	var symptoms []Symptom
+	count, err = s.QuerySymptoms([]string{"PKCH_0"}, time.Time{}, time.Time{}, 10, 0, &symptoms)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(2))
+	assert.Equal(t, symptoms[0].Timestamp.After(symptoms[1].Timestamp), true)
+
+	count, _ = s.QuerySymptoms([]string{"PKCH_0", "PKCH_1"}, now.Add(-90*time.Minute), now.Add(time.Minute), 10, 0,
+		&symptoms)
+	assert.Equal(t, count, int64(2))
+}
+
+func TestGormStore(t *testing.T) {
+	dbpath := "test_gorm_store.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	testStore(t, CurrentStore())
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, newMemoryStore())
+}
+
+func TestCrawlInMemoryStore(t *testing.T) {
+	dbpath := "test_memory_store.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	defaultStore := CurrentStore()
+	SetStore(newMemoryStore())
+	defer SetStore(defaultStore)
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_memory_store"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// The blocks are in the memory store only.
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(10))
+	assert.Equal(t, defaultStore.GetCurrentBlockHeight(channelName), int64(0))
+
+	var block Block
+	assert.Equal(t, QueryBlockByHeightInChannel(channelName, 7, &block), nil)
+	assert.Equal(t, block.BlockHash, node.blockHash(7))
+
+	// The reorganized chain is repaired in the memory store.
+	node.replaceBlocks(8, 12)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	block = Block{}
+	assert.Equal(t, QueryBlockByHeightInChannel(channelName, 9, &block), nil)
+	assert.Equal(t, block.BlockHash, node.blockHash(9))
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(12))
+}
@@ -3,6 +3,9 @@ package polarbear
 import (
 	conf "motherbear/backend/configuration"
 	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
 )
 
 func TestJSONRPCGetBlockHeight(t *testing.T) {
@@ -16,18 +19,6 @@ func TestJSONRPCGetBlockHeight(t *testing.T) {
 	}
 }
 
-func TestGetTXStatus(t *testing.T) {
-    t.Skip("Skipping test Check TX Status.")
-	endPoint := "https://test-ctz.solidwallet.io"
-	txHash := "0x32f32c4021d8c35e76bc55e8ccde917c9a9626dc835113f2f10b21fa43bd91ac"
-
-	status, err := getTxStatus(endPoint, "", txHash)
-
-	if err != nil && status != "Success" {
-		t.Error("Fail to get Tx status.", status, err)
-	}
-}
-
 func TestJSONRPCGetBlockByHeight(t *testing.T) {
 
 	endPoint := "https://test-ctz.solidwallet.io"
@@ -129,3 +120,19 @@ func TestCrawlTestNetBlockchain(t *testing.T) {
 	// Run crawling.
 	cronJobForEveryChannel(conf.Conf())
 }
+
+func TestIsPollingDue(t *testing.T) {
+	conf.Conf().Blockchain.CrawlingInterval = 2
+	now := time.Now()
+
+	// The channel is polled in the interval of blockchain.
+	c := conf.Channels{Name: "channel_polling"}
+	assert.Equal(t, isPollingDue(c, now), true)
+	assert.Equal(t, isPollingDue(c, now.Add(time.Second)), false)
+	assert.Equal(t, isPollingDue(c, now.Add(2*time.Second)), true)
+
+	// The interval of channel is used instead.
+	c.CrawlingInterval = 5
+	assert.Equal(t, isPollingDue(c, now.Add(6*time.Second)), false)
+	assert.Equal(t, isPollingDue(c, now.Add(7*time.Second-time.Millisecond)), true)
+}
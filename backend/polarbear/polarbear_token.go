@@ -0,0 +1,392 @@
+package polarbear
+
+import (
+	"math/big"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// The signature of IRC-2 token transfer event.
+const tokenTransferSignature = "Transfer(Address,Address,int,bytes)"
+
+// Token is the IRC-2 token SCORE which emitted the transfer event in channel.
+type Token struct {
+	gorm.Model
+	Channel     string `gorm:"type:VARCHAR(64);not null;index"`
+	Address     string `gorm:"type:VARCHAR(128);not null;index"`
+	FirstHeight int64  `gorm:"type:BIGINT;not null;index"` // Height of the first transfer crawled.
+	FirstTxHash string `gorm:"type:VARCHAR(512);not null"`
+}
+
+// TokenTransfer is the transfer of IRC-2 token, extracted from the transfer event.
+type TokenTransfer struct {
+	gorm.Model
+	Channel      string    `gorm:"type:VARCHAR(64);not null;index"`
+	TokenAddress string    `gorm:"type:VARCHAR(128);not null;index"`
+	TxHash       string    `gorm:"type:VARCHAR(512);not null;index"`
+	BlockHeight  int64     `gorm:"type:BIGINT;not null;index"`
+	Timestamp    time.Time `gorm:"index"`
+	LogIndex     int
+	From         string `gorm:"type:VARCHAR(128);not null;index"`
+	To           string `gorm:"type:VARCHAR(128);not null;index"`
+	Amount       string `gorm:"type:VARCHAR(80);not null"` // Decimal string.
+}
+
+// TokenBalance is the balance of token holder, summed by the transfers crawled.
+// The balance can be negative if the address received the token before the crawled blocks, or minted it.
+type TokenBalance struct {
+	gorm.Model
+	Channel      string `gorm:"type:VARCHAR(64);not null;index"`
+	TokenAddress string `gorm:"type:VARCHAR(128);not null;index"`
+	Holder       string `gorm:"type:VARCHAR(128);not null;index"`
+	Balance      string `gorm:"type:VARCHAR(80);not null"` // Decimal string.
+}
+
+// TokenSummary is the token with the count of transfers and holders.
+type TokenSummary struct {
+	Token
+	TransferCount int64
+	HolderCount   int64
+}
+
+// tokenBalanceLock serializes the updates of token balances, because the balance is read and written in big integer.
+// Hold it until the DB transaction which updates the balances is committed.
+var tokenBalanceLock sync.Mutex
+
+// tokenBalanceKey is the key of balance to sum the transfers.
+type tokenBalanceKey struct {
+	tokenAddress string
+	holder       string
+}
+
+// buildTokenTransfersFromBlock builds the token transfers from the transfer events of successful Txs in the block.
+func buildTokenTransfersFromBlock(block *Block) []TokenTransfer {
+	var transfers []TokenTransfer
+	for _, tx := range block.Txs {
+		if tx.Status != TxStatusSuccess {
+			continue
+		}
+
+		for _, eventLog := range tx.Result.EventLogs {
+			if eventLog.Signature != tokenTransferSignature || eventLog.Indexed1 == "" || eventLog.Indexed2 == "" {
+				continue
+			}
+
+			transfers = append(transfers, TokenTransfer{
+				Channel:      tx.Channel,
+				TokenAddress: eventLog.ScoreAddress,
+				TxHash:       tx.TxHash,
+				BlockHeight:  tx.BlockHeight,
+				Timestamp:    tx.Timestamp,
+				LogIndex:     eventLog.LogIndex,
+				From:         eventLog.Indexed1,
+				To:           eventLog.Indexed2,
+				Amount:       parseHexBigInt(eventLog.Indexed3).String(),
+			})
+		}
+	}
+
+	return transfers
+}
+
+// registerTokenTransfers adds the token transfers, and updates the balances of holders.
+// Call it with tokenBalanceLock.
+func registerTokenTransfers(db *gorm.DB, transfers []TokenTransfer) error {
+	if len(transfers) == 0 {
+		return nil
+	}
+	channelName := transfers[0].Channel
+
+	// Sum the amounts by holder not to update the same balance many times.
+	deltas := make(map[tokenBalanceKey]*big.Int)
+	addDelta := func(tokenAddress string, holder string, amount *big.Int) {
+		key := tokenBalanceKey{tokenAddress: tokenAddress, holder: holder}
+		if _, ok := deltas[key]; !ok {
+			deltas[key] = new(big.Int)
+		}
+		deltas[key].Add(deltas[key], amount)
+	}
+
+	for i := range transfers {
+		if err := db.Create(&transfers[i]).Error; err != nil {
+			return err
+		}
+
+		var token Token
+		if err := db.Where(&Token{Channel: channelName, Address: transfers[i].TokenAddress}).Attrs(Token{
+			FirstHeight: transfers[i].BlockHeight,
+			FirstTxHash: transfers[i].TxHash,
+		}).FirstOrCreate(&token).Error; err != nil {
+			return err
+		}
+
+		// The blocks are crawled out of order by the workers.
+		if transfers[i].BlockHeight < token.FirstHeight {
+			if err := db.Model(&token).Updates(map[string]interface{}{
+				"first_height":  transfers[i].BlockHeight,
+				"first_tx_hash": transfers[i].TxHash,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		amount, _ := new(big.Int).SetString(transfers[i].Amount, 10)
+		addDelta(transfers[i].TokenAddress, transfers[i].From, new(big.Int).Neg(amount))
+		addDelta(transfers[i].TokenAddress, transfers[i].To, amount)
+	}
+
+	for key, delta := range deltas {
+		if err := addTokenBalance(db, channelName, key.tokenAddress, key.holder, delta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addTokenBalance adds the amount to the balance of holder.
+func addTokenBalance(db *gorm.DB, channelName string, tokenAddress string, holder string, amount *big.Int) error {
+	var balance TokenBalance
+	if err := db.Where(&TokenBalance{
+		Channel:      channelName,
+		TokenAddress: tokenAddress,
+		Holder:       holder,
+	}).FirstOrInit(&balance).Error; err != nil {
+		return err
+	}
+
+	sum, ok := new(big.Int).SetString(balance.Balance, 10)
+	if !ok {
+		sum = new(big.Int)
+	}
+	balance.Balance = sum.Add(sum, amount).String()
+
+	return db.Save(&balance).Error
+}
+
+// deleteTokenTransfersInRange deletes the token transfers between the heights in channel,
+// and reverts the balances of holders. Call it with tokenBalanceLock.
+func deleteTokenTransfersInRange(db *gorm.DB, channelName string, beginHeight int64, endHeight int64) error {
+	var transfers []TokenTransfer
+	if err := db.Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Find(&transfers).Error; err != nil {
+		return err
+	}
+
+	deltas := make(map[tokenBalanceKey]*big.Int)
+	for _, transfer := range transfers {
+		amount, _ := new(big.Int).SetString(transfer.Amount, 10)
+		for _, d := range []struct {
+			holder string
+			amount *big.Int
+		}{
+			{transfer.From, amount},
+			{transfer.To, new(big.Int).Neg(amount)},
+		} {
+			key := tokenBalanceKey{tokenAddress: transfer.TokenAddress, holder: d.holder}
+			if _, ok := deltas[key]; !ok {
+				deltas[key] = new(big.Int)
+			}
+			deltas[key].Add(deltas[key], d.amount)
+		}
+	}
+
+	for key, delta := range deltas {
+		if err := addTokenBalance(db, channelName, key.tokenAddress, key.holder, delta); err != nil {
+			return err
+		}
+	}
+
+	if err := db.Unscoped().Where("channel = ? AND block_height >= ? AND block_height <= ?",
+		channelName, beginHeight, endHeight).Delete(&TokenTransfer{}).Error; err != nil {
+		return err
+	}
+
+	// The token first seen in the deleted blocks is first seen in the remaining transfers, or has no transfer.
+	var tokens []Token
+	if err := db.Where("channel = ? AND first_height >= ? AND first_height <= ?",
+		channelName, beginHeight, endHeight).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		var first TokenTransfer
+		err := db.Where("channel = ? AND token_address = ?", channelName, token.Address).Order(
+			"block_height asc").First(&first).Error
+		if err == nil {
+			if err := db.Model(&token).Updates(map[string]interface{}{
+				"first_height":  first.BlockHeight,
+				"first_tx_hash": first.TxHash,
+			}).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if !gorm.IsRecordNotFoundError(err) {
+			return err
+		}
+
+		if err := db.Unscoped().Where("channel = ? AND token_address = ?",
+			channelName, token.Address).Delete(&TokenBalance{}).Error; err != nil {
+			return err
+		}
+		if err := db.Unscoped().Delete(&token).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// positiveBalanceCondition is the condition of the holder which has the token.
+const positiveBalanceCondition = "balance <> '0' AND balance NOT LIKE '-%'"
+
+// QueryTokensInChannel queries the tokens in channel with the count of transfers and holders,
+// ordered by the latest token.
+func QueryTokensInChannel(channelName string, limit int, offset int, out *[]TokenSummary) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 || channelName == "" {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, channelName:%s", limit, offset, channelName)
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	table := Database().Model(&Token{}).Where("channel = ?", channelName)
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	var tokens []Token
+	if err := table.Order("first_height desc").Order("id desc").Offset(
+		offset).Limit(limit).Find(&tokens).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	addresses := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		addresses = append(addresses, token.Address)
+	}
+
+	transferCounts, err := countByTokenAddress(Database().Model(&TokenTransfer{}).Where(
+		"channel = ?", channelName), addresses)
+	if err != nil {
+		return -1, err
+	}
+	holderCounts, err := countByTokenAddress(Database().Model(&TokenBalance{}).Where(
+		"channel = ? AND "+positiveBalanceCondition, channelName), addresses)
+	if err != nil {
+		return -1, err
+	}
+
+	*out = make([]TokenSummary, 0, len(tokens))
+	for _, token := range tokens {
+		*out = append(*out, TokenSummary{
+			Token:         token,
+			TransferCount: transferCounts[token.Address],
+			HolderCount:   holderCounts[token.Address],
+		})
+	}
+
+	return count, nil
+}
+
+// countByTokenAddress counts the rows of table by token address.
+func countByTokenAddress(table *gorm.DB, addresses []string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	if len(addresses) == 0 {
+		return counts, nil
+	}
+
+	rows, err := table.Select("token_address, COUNT(*)").Where(
+		"token_address IN (?)", addresses).Group("token_address").Rows()
+	if err != nil {
+		logger.Errorf("%s", err)
+		return nil, isaacerror.SysErrFailToQueryTokens
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address string
+		var count int64
+		if err := rows.Scan(&address, &count); err != nil {
+			return nil, isaacerror.SysErrFailToQueryTokens
+		}
+		counts[address] = count
+	}
+
+	return counts, nil
+}
+
+// numericOrder returns the order by the column of positive decimal string in numeric. The longer string is the larger
+// number, and the strings of same length are ordered as number. It is exact on every dialect, unlike the cast to REAL.
+func numericOrder(column string, direction string) string {
+	return "LENGTH(" + column + ") " + direction + ", " + column + " " + direction
+}
+
+// QueryTokenHoldersInChannel queries the holders of token in channel, ordered by the balance.
+func QueryTokenHoldersInChannel(
+	channelName string,
+	tokenAddress string,
+	limit int,
+	offset int,
+	out *[]TokenBalance) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 || channelName == "" || tokenAddress == "" {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, token:%s, channelName:%s",
+			limit, offset, tokenAddress, channelName)
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	table := Database().Model(&TokenBalance{}).Where(
+		"channel = ? AND token_address = ? AND "+positiveBalanceCondition, channelName, tokenAddress)
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	if err := table.Order(numericOrder("balance", "desc")).Order("id asc").Offset(
+		offset).Limit(limit).Find(out).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	return count, nil
+}
+
+// QueryTokenTransfersInChannel queries the transfers of token in channel, ordered by the latest.
+func QueryTokenTransfersInChannel(
+	channelName string,
+	tokenAddress string,
+	limit int,
+	offset int,
+	out *[]TokenTransfer) (int64, error) {
+
+	// Check arguments.
+	if limit < 0 || offset < 0 || channelName == "" || tokenAddress == "" {
+		logger.Errorf("Arguments is wrong. limit:%d, offset:%d, token:%s, channelName:%s",
+			limit, offset, tokenAddress, channelName)
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	table := Database().Model(&TokenTransfer{}).Where(
+		"channel = ? AND token_address = ?", channelName, tokenAddress)
+
+	var count int64
+	if err := table.Count(&count).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	if err := table.Order("block_height desc").Order("id desc").Offset(
+		offset).Limit(limit).Find(out).Error; err != nil {
+		return -1, isaacerror.SysErrFailToQueryTokens
+	}
+
+	return count, nil
+}
@@ -0,0 +1,166 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestBuildTokenTransfersFromBlock(t *testing.T) {
+	block := Block{
+		Txs: []Tx{
+			{
+				TxHash: "0x1", Channel: "channel1", Status: "Success", BlockHeight: 10, Timestamp: time.Unix(1000, 0),
+				Result: TxResult{EventLogs: []EventLog{
+					{
+						ScoreAddress: "cx54d95fee187faaea03cee908f50623c8381179d0", LogIndex: 0,
+						Signature: tokenTransferSignature, Indexed1: "hx01", Indexed2: "hx02", Indexed3: "0xde0b6b3a7640000",
+					},
+					{
+						ScoreAddress: "cx54d95fee187faaea03cee908f50623c8381179d0", LogIndex: 1,
+						Signature: "Approval(Address,Address,int)", Indexed1: "hx01", Indexed2: "hx02", Indexed3: "0x1",
+					},
+				}},
+			},
+			{
+				TxHash: "0x2", Channel: "channel1", Status: "Failure", BlockHeight: 10,
+				Result: TxResult{EventLogs: []EventLog{
+					{Signature: tokenTransferSignature, Indexed1: "hx01", Indexed2: "hx02", Indexed3: "0x1"},
+				}},
+			},
+		},
+	}
+
+	transfers := buildTokenTransfersFromBlock(&block)
+	assert.Equal(t, len(transfers), 1)
+	assert.Equal(t, transfers[0].TokenAddress, "cx54d95fee187faaea03cee908f50623c8381179d0")
+	assert.Equal(t, transfers[0].TxHash, "0x1")
+	assert.Equal(t, transfers[0].From, "hx01")
+	assert.Equal(t, transfers[0].To, "hx02")
+	assert.Equal(t, transfers[0].Amount, "1000000000000000000")
+}
+
+func TestCrawlTokenTransfers(t *testing.T) {
+	dbpath := "test_token.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_token"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every Tx transfers 1 token from hx...02 to hx...03.
+	tokenAddress := "cx0000000000000000000000000000000000000001"
+	var tokens []TokenSummary
+	count, err := QueryTokensInChannel(channelName, 10, 0, &tokens)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, tokens[0].Address, tokenAddress)
+	assert.Equal(t, tokens[0].TransferCount, int64(10))
+	assert.Equal(t, tokens[0].HolderCount, int64(1))
+
+	var balances []TokenBalance
+	count, err = QueryTokenHoldersInChannel(channelName, tokenAddress, 10, 0, &balances)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, balances[0].Holder, "hx0000000000000000000000000000000000000003")
+	assert.Equal(t, balances[0].Balance, "10")
+
+	// The balances are reverted with blocks.
+	assert.Equal(t, DeleteBlocksFromHeight(channelName, 6), nil)
+	var transfers []TokenTransfer
+	count, _ = QueryTokenTransfersInChannel(channelName, tokenAddress, 10, 0, &transfers)
+	assert.Equal(t, count, int64(5))
+	assert.Equal(t, transfers[0].BlockHeight, int64(5))
+	_, _ = QueryTokenHoldersInChannel(channelName, tokenAddress, 10, 0, &balances)
+	assert.Equal(t, balances[0].Balance, "5")
+
+	// The token is deleted with every transfer.
+	assert.Equal(t, DeleteBlocksFromHeight(channelName, 1), nil)
+	count, _ = QueryTokensInChannel(channelName, 10, 0, &tokens)
+	assert.Equal(t, count, int64(0))
+}
+
+// Test the holders are ordered by the balance in number, not in string.
+func TestQueryTokenHoldersInChannel(t *testing.T) {
+	dbpath := "test_token_holder.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	channelName := "channel_token_holder"
+	tokenAddress := "cx54d95fee187faaea03cee908f50623c8381179d0"
+	transfers := []TokenTransfer{
+		{Channel: channelName, TokenAddress: tokenAddress, TxHash: "0x1", BlockHeight: 1,
+			From: "hx00", To: "hx01", Amount: "9"},
+		{Channel: channelName, TokenAddress: tokenAddress, TxHash: "0x2", BlockHeight: 2,
+			From: "hx00", To: "hx02", Amount: "100000000000000000000"},
+		{Channel: channelName, TokenAddress: tokenAddress, TxHash: "0x3", BlockHeight: 3,
+			From: "hx00", To: "hx03", Amount: "10"},
+		{Channel: channelName, TokenAddress: tokenAddress, TxHash: "0x4", BlockHeight: 4,
+			From: "hx03", To: "hx01", Amount: "10"},
+		// The balances over 2^53 are not same in REAL.
+		{Channel: channelName, TokenAddress: tokenAddress, TxHash: "0x5", BlockHeight: 5,
+			From: "hx00", To: "hx04", Amount: "9007199254740992"},
+		{Channel: channelName, TokenAddress: tokenAddress, TxHash: "0x6", BlockHeight: 6,
+			From: "hx00", To: "hx05", Amount: "9007199254740993"},
+	}
+	assert.Equal(t, registerTokenTransfers(Database(), transfers), nil)
+
+	var balances []TokenBalance
+	count, err := QueryTokenHoldersInChannel(channelName, tokenAddress, 10, 0, &balances)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(4))
+	assert.Equal(t, balances[0].Holder, "hx02")
+	assert.Equal(t, balances[0].Balance, "100000000000000000000")
+	assert.Equal(t, balances[1].Holder, "hx05")
+	assert.Equal(t, balances[2].Holder, "hx04")
+	assert.Equal(t, balances[3].Holder, "hx01")
+	assert.Equal(t, balances[3].Balance, "19")
+}
+
+// Test the first height of token is the lowest transfer remaining after the blocks are deleted.
+func TestFirstHeightOfTokenAfterDelete(t *testing.T) {
+	dbpath := "test_token_first_height.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+
+	channelName := "channel_token_first"
+	tokenAddress := "cx54d95fee187faaea03cee908f50623c8381179d0"
+
+	// The higher transfer is crawled before the lower one.
+	assert.Equal(t, registerTokenTransfers(Database(), []TokenTransfer{{Channel: channelName,
+		TokenAddress: tokenAddress, TxHash: "0x7", BlockHeight: 7, From: "hx00", To: "hx01", Amount: "1"}}), nil)
+	assert.Equal(t, registerTokenTransfers(Database(), []TokenTransfer{{Channel: channelName,
+		TokenAddress: tokenAddress, TxHash: "0x3", BlockHeight: 3, From: "hx00", To: "hx01", Amount: "1"}}), nil)
+
+	var token Token
+	assert.Equal(t, Database().Where("channel = ? AND address = ?", channelName, tokenAddress).First(&token).Error, nil)
+	assert.Equal(t, token.FirstHeight, int64(3))
+	assert.Equal(t, token.FirstTxHash, "0x3")
+
+	// The range of the lowest transfer is deleted like a reorg.
+	tokenBalanceLock.Lock()
+	assert.Equal(t, deleteTokenTransfersInRange(Database(), channelName, 1, 5), nil)
+	tokenBalanceLock.Unlock()
+
+	assert.Equal(t, Database().Where("channel = ? AND address = ?", channelName, tokenAddress).First(&token).Error, nil)
+	assert.Equal(t, token.FirstHeight, int64(7))
+	assert.Equal(t, token.FirstTxHash, "0x7")
+
+	var balances []TokenBalance
+	_, _ = QueryTokenHoldersInChannel(channelName, tokenAddress, 10, 0, &balances)
+	assert.Equal(t, balances[0].Balance, "1")
+}
@@ -0,0 +1,182 @@
+package polarbear
+
+import (
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	maxReconcileTxCount  = 100 // Max Txs to query again in a channel at once.
+	reconcileBackoffBase = 10 * time.Second
+	reconcileBackoffMax  = time.Hour
+)
+
+// PendingTx is the trial to query the result of pending Tx again. The pending Tx without it is tried at once.
+type PendingTx struct {
+	gorm.Model
+	TxID         uint   `gorm:"unique_index"`
+	Channel      string `gorm:"type:VARCHAR(64);not null;index"`
+	BlockHeight  int64  `gorm:"type:BIGINT;not null;index"`
+	CountOfTrial int
+	NextTrialAt  time.Time `gorm:"index"`
+	LastError    string    `gorm:"type:VARCHAR(1024)"`
+}
+
+// reconcileBackoff returns the duration to wait for the next trial, doubled in every trial.
+func reconcileBackoff(countOfTrial int) time.Duration {
+	backoff := reconcileBackoffBase
+	for i := 1; i < countOfTrial && backoff < reconcileBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > reconcileBackoffMax {
+		backoff = reconcileBackoffMax
+	}
+
+	return backoff
+}
+
+// CountPendingTxs returns the count of Txs whose result is not fetched yet in channel.
+func CountPendingTxs(channelName string) (int64, error) {
+	var count int64
+	if err := Database().Model(&Tx{}).Where("channel = ? AND status = ?", channelName, TxStatusPending).Count(
+		&count).Error; err != nil {
+		logger.Errorf("%s", err)
+		return -1, isaacerror.SysErrFailToQueryPendingTxs
+	}
+
+	return count, nil
+}
+
+// queryDuePendingTxs queries the pending Txs to query the result again at the time in channel, in the order of ID.
+func queryDuePendingTxs(channelName string, now time.Time, out *[]Tx) error {
+	txTable := Database().NewScope(&Tx{}).TableName()
+	pendingTxTable := Database().NewScope(&PendingTx{}).TableName()
+
+	return Database().Model(&Tx{}).Select(txTable+".*").Joins(
+		"LEFT JOIN "+pendingTxTable+" ON "+pendingTxTable+".tx_id = "+txTable+".id").Where(
+		txTable+".channel = ? AND "+txTable+".status = ? AND ("+pendingTxTable+".id IS NULL OR "+
+			pendingTxTable+".next_trial_at <= ?)", channelName, TxStatusPending, now).Order(
+		txTable + ".id asc").Limit(maxReconcileTxCount).Find(out).Error
+}
+
+// reconcilePendingTxs queries the result of pending Txs due in channel again, and updates the status and result of
+// them with the contracts and token transfers in them. Returns the count of Txs still pending.
+func reconcilePendingTxs(nodes *nodePool, channelName string) (int64, error) {
+	if isCrawlSuspended(channelName) {
+		return CountPendingTxs(channelName)
+	}
+
+	unlock := lockChannelCrawl(channelName)
+	defer unlock()
+
+	// Check again, crawling may be paused or a crawl job may begin while waiting for the lock.
+	if isCrawlSuspended(channelName) {
+		return CountPendingTxs(channelName)
+	}
+
+	var txs []Tx
+	if err := queryDuePendingTxs(channelName, time.Now(), &txs); err != nil {
+		logger.Errorf("%s", err)
+		return -1, isaacerror.SysErrFailToQueryPendingTxs
+	}
+
+	if len(txs) != 0 {
+		logger.Infof("Query the result of %d pending Txs again in %s.", len(txs), channelName)
+		txHashes := make([]string, 0, len(txs))
+		for _, tx := range txs {
+			txHashes = append(txHashes, tx.TxHash)
+		}
+		txResults := nodes.getTxResults("", txHashes)
+
+		for i := range txs {
+			result, ok := txResults[txs[i].TxHash]
+			if !ok {
+				if err := postponePendingTx(&txs[i], isaacerror.SysErrFailToGetTxResult); err != nil {
+					return -1, err
+				}
+				continue
+			}
+			if err := resolvePendingTx(&txs[i], result); err != nil {
+				return -1, err
+			}
+		}
+	}
+
+	return CountPendingTxs(channelName)
+}
+
+// resolvePendingTx updates the status and result of pending Tx, and registers the contracts and token transfers in it.
+func resolvePendingTx(tx *Tx, result map[string]interface{}) error {
+	buildTxResultFromJSON(result, tx)
+	tx.Result.TxID = tx.ID
+
+	block := &Block{Txs: []Tx{*tx}}
+	transfers := buildTokenTransfersFromBlock(block)
+	if len(transfers) != 0 {
+		tokenBalanceLock.Lock()
+		defer tokenBalanceLock.Unlock()
+	}
+
+	db := Database().Begin()
+	updated := db.Model(&Tx{}).Where("id = ? AND status = ?", tx.ID, TxStatusPending).Update("status", tx.Status)
+	if updated.Error != nil {
+		db.Rollback()
+		return updated.Error
+	}
+
+	// The Tx is deleted or resolved by others.
+	if updated.RowsAffected == 0 {
+		db.Rollback()
+		return nil
+	}
+
+	if err := db.Create(&tx.Result).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := registerContractsInBlock(db, block); err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := registerTokenTransfers(db, transfers); err != nil {
+		db.Rollback()
+		return err
+	}
+	if err := db.Unscoped().Where("tx_id = ?", tx.ID).Delete(&PendingTx{}).Error; err != nil {
+		db.Rollback()
+		return err
+	}
+
+	logger.Infof("Tx %s in %s is resolved as %s.", tx.TxHash, tx.Channel, tx.Status)
+	return db.Commit().Error
+}
+
+// postponePendingTx records the failed trial of pending Tx, and postpones the next trial with the backoff.
+func postponePendingTx(tx *Tx, err error) error {
+	var pendingTx PendingTx
+	if err := Database().Where(&PendingTx{TxID: tx.ID}).FirstOrInit(&pendingTx).Error; err != nil {
+		return err
+	}
+
+	pendingTx.Channel = tx.Channel
+	pendingTx.BlockHeight = tx.BlockHeight
+	pendingTx.CountOfTrial++
+	pendingTx.NextTrialAt = time.Now().Add(reconcileBackoff(pendingTx.CountOfTrial))
+	pendingTx.LastError = err.Error()
+
+	return Database().Save(&pendingTx).Error
+}
+
+func cronJobForTxReconcile(channelNames []string) {
+	for _, channelName := range channelNames {
+		countOfPending, err := reconcilePendingTxs(getNodePool(channelName), channelName)
+		if err != nil {
+			logger.Errorf("Fail to reconcile the pending Txs in %s. %s", channelName, err)
+		} else if countOfPending > 0 {
+			logger.Infof("%d Txs are still pending in %s.", countOfPending, channelName)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestReconcilePendingTxs(t *testing.T) {
+	dbpath := "test_reconcile_pending_txs.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(5)
+	defer node.close()
+
+	channelName := "channel_reconcile"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	// The blocks are stored with the Txs whose result is not fetched.
+	node.setFailTxResults(true)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(5))
+	countOfPending, err := CountPendingTxs(channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfPending, int64(5))
+
+	var status CrawlerStatus
+	assert.Equal(t, QueryCrawlerStatus(channelName, &status), nil)
+	assert.Equal(t, status.PendingTxs, int64(5))
+
+	// The failed trial is postponed with the backoff.
+	countOfPending, err = reconcilePendingTxs(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfPending, int64(5))
+
+	var pendingTxs []PendingTx
+	Database().Where("channel = ?", channelName).Find(&pendingTxs)
+	assert.Equal(t, len(pendingTxs), 5)
+	assert.Equal(t, pendingTxs[0].CountOfTrial, 1)
+	assert.Equal(t, pendingTxs[0].NextTrialAt.After(time.Now()), true)
+
+	// Nothing is queried before the next trial.
+	countOfRequests := node.countOfRequests()
+	countOfPending, _ = reconcilePendingTxs(getNodePool(channelName), channelName)
+	assert.Equal(t, countOfPending, int64(5))
+	assert.Equal(t, node.countOfRequests(), countOfRequests)
+
+	// Resolved in the next trial.
+	node.setFailTxResults(false)
+	Database().Model(&PendingTx{}).Where("channel = ?", channelName).Update("next_trial_at", time.Now().Add(-time.Second))
+	countOfPending, err = reconcilePendingTxs(getNodePool(channelName), channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, countOfPending, int64(0))
+
+	var txs []Tx
+	Database().Preload("Result").Where("channel = ?", channelName).Find(&txs)
+	assert.Equal(t, len(txs), 5)
+	for _, tx := range txs {
+		assert.Equal(t, tx.Status, "Success")
+		assert.Equal(t, tx.Result.TxID, tx.ID)
+	}
+
+	var countOfPendingTxs int
+	Database().Model(&PendingTx{}).Where("channel = ?", channelName).Count(&countOfPendingTxs)
+	assert.Equal(t, countOfPendingTxs, 0)
+
+	// The token transfers in the resolved Txs are summed.
+	var balances []TokenBalance
+	_, _ = QueryTokenHoldersInChannel(channelName, "cx0000000000000000000000000000000000000001", 10, 0, &balances)
+	assert.Equal(t, len(balances), 1)
+	assert.Equal(t, balances[0].Balance, "5")
+}
+
+func TestReconcileBackoff(t *testing.T) {
+	assert.Equal(t, reconcileBackoff(1), 10*time.Second)
+	assert.Equal(t, reconcileBackoff(2), 20*time.Second)
+	assert.Equal(t, reconcileBackoff(3), 40*time.Second)
+	assert.Equal(t, reconcileBackoff(100), time.Hour)
+}
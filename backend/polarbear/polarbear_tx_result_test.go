@@ -0,0 +1,87 @@
+package polarbear
+
+import (
+	"encoding/json"
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestBuildTxResultFromJSON(t *testing.T) {
+	var result map[string]interface{}
+	_ = json.Unmarshal([]byte(`{
+		"txHash": "0xb903239f8543d04b5dc1ba6579132b143087c68db1b2168786408fcbce568238",
+		"status": "0x0",
+		"stepUsed": "0x2e248",
+		"stepPrice": "0x2540be400",
+		"scoreAddress": "cx54d95fee187faaea03cee908f50623c8381179d0",
+		"logsBloom": "0x00000000000000000000000000000000",
+		"failure": {
+			"code": "0x7d64",
+			"message": "Out of balance"
+		}
+	}`), &result)
+
+	tx := Tx{TxHash: "0xb903239f8543d04b5dc1ba6579132b143087c68db1b2168786408fcbce568238", Channel: "channel1", BlockHeight: 10}
+	buildTxResultFromJSON(result, &tx)
+
+	assert.Equal(t, tx.Status, "Failure")
+	assert.Equal(t, tx.Result.TxHash, tx.TxHash)
+	assert.Equal(t, tx.Result.Channel, "channel1")
+	assert.Equal(t, tx.Result.BlockHeight, int64(10))
+	assert.Equal(t, tx.Result.StepUsed, int64(0x2e248))
+	assert.Equal(t, tx.Result.StepPrice, int64(10000000000))
+	assert.Equal(t, tx.Result.Fee, "1890000000000000")
+	assert.Equal(t, tx.Result.ScoreAddress, "cx54d95fee187faaea03cee908f50623c8381179d0")
+	assert.Equal(t, tx.Result.FailureCode, int64(0x7d64))
+	assert.Equal(t, tx.Result.FailureMessage, "Out of balance")
+	assert.Equal(t, tx.Result.LogsBloom, "0x00000000000000000000000000000000")
+
+	// Fee bigger than int64.
+	result["stepUsed"] = "0x7fffffffffffffff"
+	buildTxResultFromJSON(result, &tx)
+	assert.Equal(t, tx.Result.Fee, "92233720368547758070000000000")
+}
+
+func TestCrawlTxResult(t *testing.T) {
+	dbpath := "test_tx_result.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(5)
+	defer node.close()
+
+	channelName := "channel_tx_result"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	var txs []Tx
+	count, err := QueryTxsInChannelBySearch(channelName, 10, 0, TxSearch{BlockHeight: -1}, &txs)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count, int64(5))
+	for _, tx := range txs {
+		assert.Equal(t, tx.Status, "Success")
+		assert.Equal(t, tx.Result.TxID, tx.ID)
+		assert.Equal(t, tx.Result.StepUsed, int64(0x1234))
+		assert.Equal(t, tx.Result.Fee, "46600000000000")
+	}
+
+	var tx Tx
+	assert.Equal(t, QueryTxInChannelByHash(channelName, txs[0].TxHash, &tx), nil)
+	assert.Equal(t, tx.Result.StepPrice, int64(10000000000))
+
+	// The results are deleted with blocks.
+	assert.Equal(t, DeleteBlocksFromHeight(channelName, 3), nil)
+	var countOfResult int64
+	Database().Model(&TxResult{}).Where("channel = ?", channelName).Count(&countOfResult)
+	assert.Equal(t, countOfResult, int64(2))
+}
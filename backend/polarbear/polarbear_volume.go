@@ -0,0 +1,181 @@
+package polarbear
+
+import (
+	"database/sql"
+	"math/big"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"sort"
+	"time"
+)
+
+// Interval of time bucket in volume statistics.
+const (
+	VolumeIntervalHour  = "hour"
+	VolumeIntervalDay   = "day"
+	VolumeIntervalMonth = "month"
+)
+
+// VolumeStats is the ICX volume and fees of Txs. The amounts are in loop.
+// Volume is the value of successful Txs. Fee is the fee of every Tx, because the failed Tx also pays the fee.
+// If the address is searched, SentVolume and ReceivedVolume are the volume sent and received by the address,
+// and Fee is the fee paid by the address.
+type VolumeStats struct {
+	Volume         *big.Int
+	SentVolume     *big.Int
+	ReceivedVolume *big.Int
+	Fee            *big.Int
+	TxCount        int64
+}
+
+// VolumeBucket is the volume statistics in the time bucket which begins at Timestamp.
+type VolumeBucket struct {
+	Timestamp time.Time
+	VolumeStats
+}
+
+// VolumeSearch is the condition of volume statistics.
+type VolumeSearch struct {
+	Address  string
+	From     time.Time
+	To       time.Time
+	Interval string // No time bucket if it is "".
+}
+
+// NewVolumeStats returns the empty volume statistics.
+func NewVolumeStats() VolumeStats {
+	return VolumeStats{
+		Volume:         new(big.Int),
+		SentVolume:     new(big.Int),
+		ReceivedVolume: new(big.Int),
+		Fee:            new(big.Int),
+	}
+}
+
+// add adds the amounts of a Tx to the statistics.
+func (s *VolumeStats) add(address string, from string, to string, status string, value *big.Int, fee *big.Int) {
+	s.TxCount++
+	if status == TxStatusSuccess {
+		s.Volume.Add(s.Volume, value)
+		if address != "" && from == address {
+			s.SentVolume.Add(s.SentVolume, value)
+		}
+		if address != "" && to == address {
+			s.ReceivedVolume.Add(s.ReceivedVolume, value)
+		}
+	}
+	if address == "" || from == address {
+		s.Fee.Add(s.Fee, fee)
+	}
+}
+
+// truncateToInterval returns the beginning of the time bucket in UTC.
+func truncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case VolumeIntervalHour:
+		return t.Truncate(time.Hour)
+	case VolumeIntervalDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case VolumeIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return t
+}
+
+// QueryVolumeStatsInChannel queries the ICX volume and fees in channel by search condition.
+// The amounts are summed in big integer to be exact, so the Txs are read one by one.
+// The time buckets are ordered by time, and the bucket without Tx is omitted.
+func QueryVolumeStatsInChannel(
+	channelName string,
+	search VolumeSearch,
+	total *VolumeStats,
+	buckets *[]VolumeBucket) error {
+
+	// Check arguments.
+	if channelName == "" {
+		logger.Errorf("Arguments is wrong. channelName:%s", channelName)
+		return isaacerror.SysErrFailToQueryVolumeStats
+	}
+	switch search.Interval {
+	case "", VolumeIntervalHour, VolumeIntervalDay, VolumeIntervalMonth:
+	default:
+		return isaacerror.SysErrInvalidParameter
+	}
+
+	txTable := Database().NewScope(&Tx{}).TableName()
+	txResultTable := Database().NewScope(&TxResult{}).TableName()
+	from := txTable + "." + Database().Dialect().Quote("from")
+	to := txTable + "." + Database().Dialect().Quote("to")
+
+	table := Database().Table(txTable).Select(
+		txTable+".timestamp, "+txTable+".status, "+from+", "+to+", "+txTable+".value, "+txResultTable+".fee").Joins(
+		"LEFT JOIN "+txResultTable+" ON "+txResultTable+".tx_id = "+txTable+".id AND "+
+			txResultTable+".deleted_at IS NULL").Where(
+		txTable+".channel = ? AND "+txTable+".deleted_at IS NULL", channelName)
+
+	if search.Address != "" {
+		table = table.Where("("+from+" = ? OR "+to+" = ?)", search.Address, search.Address)
+	}
+	if !search.From.IsZero() {
+		table = table.Where(txTable+".timestamp >= ?", search.From)
+	}
+	if !search.To.IsZero() {
+		table = table.Where(txTable+".timestamp <= ?", search.To)
+	}
+
+	rows, err := table.Rows()
+	if err != nil {
+		logger.Errorf("%s", err)
+		return isaacerror.SysErrFailToQueryVolumeStats
+	}
+	defer rows.Close()
+
+	*total = NewVolumeStats()
+	bucketMap := make(map[time.Time]*VolumeBucket)
+	for rows.Next() {
+		var timestamp time.Time
+		var status, txFrom, txTo string
+		var value, fee sql.NullString
+		if err := rows.Scan(&timestamp, &status, &txFrom, &txTo, &value, &fee); err != nil {
+			logger.Errorf("%s", err)
+			return isaacerror.SysErrFailToQueryVolumeStats
+		}
+
+		valueAmount, _ := new(big.Int).SetString(value.String, 10)
+		if valueAmount == nil {
+			valueAmount = new(big.Int)
+		}
+		feeAmount, _ := new(big.Int).SetString(fee.String, 10)
+		if feeAmount == nil {
+			feeAmount = new(big.Int)
+		}
+
+		total.add(search.Address, txFrom, txTo, status, valueAmount, feeAmount)
+
+		if search.Interval == "" {
+			continue
+		}
+		bucketTime := truncateToInterval(timestamp, search.Interval)
+		bucket, ok := bucketMap[bucketTime]
+		if !ok {
+			bucket = &VolumeBucket{Timestamp: bucketTime, VolumeStats: NewVolumeStats()}
+			bucketMap[bucketTime] = bucket
+		}
+		bucket.add(search.Address, txFrom, txTo, status, valueAmount, feeAmount)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Errorf("%s", err)
+		return isaacerror.SysErrFailToQueryVolumeStats
+	}
+
+	*buckets = make([]VolumeBucket, 0, len(bucketMap))
+	for _, bucket := range bucketMap {
+		*buckets = append(*buckets, *bucket)
+	}
+	sort.Slice(*buckets, func(i, j int) bool {
+		return (*buckets)[i].Timestamp.Before((*buckets)[j].Timestamp)
+	})
+
+	return nil
+}
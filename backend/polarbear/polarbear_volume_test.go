@@ -0,0 +1,76 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"os"
+	"testing"
+	"time"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestParseHexBigInt(t *testing.T) {
+	assert.Equal(t, parseHexBigInt("0xde0b6b3a7640000").String(), "1000000000000000000")
+	assert.Equal(t, parseHexBigInt("0x8ac7230489e80000").String(), "10000000000000000000")
+	assert.Equal(t, parseHexBigInt(float64(16)).String(), "16")
+	assert.Equal(t, parseHexBigInt(nil).String(), "0")
+	assert.Equal(t, parseHexBigInt("0xzz").String(), "0")
+}
+
+func TestQueryVolumeStatsInChannel(t *testing.T) {
+	dbpath := "test_volume.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_volume"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every Tx transfers 1 ICX. The sum is bigger than int64.
+	var total VolumeStats
+	var buckets []VolumeBucket
+	assert.Equal(t, QueryVolumeStatsInChannel(channelName, VolumeSearch{}, &total, &buckets), nil)
+	assert.Equal(t, total.TxCount, int64(10))
+	assert.Equal(t, total.Volume.String(), "10000000000000000000")
+	assert.Equal(t, total.Fee.String(), "466000000000000")
+	assert.Equal(t, len(buckets), 0)
+
+	// Per address and per time bucket.
+	var tx Tx
+	Database().Where("channel = ? AND block_height = ?", channelName, 3).First(&tx)
+	search := VolumeSearch{Address: tx.From, Interval: VolumeIntervalDay}
+	assert.Equal(t, QueryVolumeStatsInChannel(channelName, search, &total, &buckets), nil)
+	assert.Equal(t, total.TxCount, int64(1))
+	assert.Equal(t, total.SentVolume.String(), "1000000000000000000")
+	assert.Equal(t, total.ReceivedVolume.String(), "0")
+	assert.Equal(t, total.Fee.String(), "46600000000000")
+	assert.Equal(t, len(buckets), 1)
+	assert.Equal(t, buckets[0].Timestamp, truncateToInterval(tx.Timestamp, VolumeIntervalDay))
+
+	// The receiver doesn't pay the fee.
+	search = VolumeSearch{Address: tx.To}
+	assert.Equal(t, QueryVolumeStatsInChannel(channelName, search, &total, &buckets), nil)
+	assert.Equal(t, total.ReceivedVolume.String(), "1000000000000000000")
+	assert.Equal(t, total.Fee.String(), "0")
+
+	// Invalid interval.
+	search = VolumeSearch{Interval: "week"}
+	assert.NotEqual(t, QueryVolumeStatsInChannel(channelName, search, &total, &buckets), nil)
+}
+
+func TestTruncateToInterval(t *testing.T) {
+	timestamp := time.Date(2019, 5, 17, 13, 45, 10, 0, time.UTC)
+	assert.Equal(t, truncateToInterval(timestamp, VolumeIntervalHour), time.Date(2019, 5, 17, 13, 0, 0, 0, time.UTC))
+	assert.Equal(t, truncateToInterval(timestamp, VolumeIntervalDay), time.Date(2019, 5, 17, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, truncateToInterval(timestamp, VolumeIntervalMonth), time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,305 @@
+package polarbear
+
+import (
+	"motherbear/backend/configuration"
+	"motherbear/backend/constants"
+	"motherbear/backend/isaacerror"
+	"motherbear/backend/logger"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// nodeRateLimiter limits the requests to a node, spacing them by the interval.
+type nodeRateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+var nodeRateLimiters = make(map[string]*nodeRateLimiter)
+var nodeRateLimitersLock sync.Mutex
+
+// wait blocks until the next request to the node is allowed.
+func (l *nodeRateLimiter) wait() {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(slot.Sub(now))
+}
+
+// getRequestPerSecond returns the limit of requests per second to a node.
+func getRequestPerSecond() int {
+	requestPerSecond := configuration.Conf().Blockchain.RequestPerSecond
+	if requestPerSecond <= 0 {
+		requestPerSecond = constants.DefaultRequestPerSecondToNode
+	}
+	return requestPerSecond
+}
+
+// waitForRequestSlot blocks until the request to the node is allowed by the limit of requests per second.
+func waitForRequestSlot(nodeIP string) {
+	interval := time.Second / time.Duration(getRequestPerSecond())
+
+	nodeRateLimitersLock.Lock()
+	limiter, ok := nodeRateLimiters[nodeIP]
+	if !ok {
+		limiter = &nodeRateLimiter{}
+		nodeRateLimiters[nodeIP] = limiter
+	}
+	nodeRateLimitersLock.Unlock()
+
+	limiter.mu.Lock()
+	limiter.interval = interval
+	limiter.mu.Unlock()
+
+	limiter.wait()
+}
+
+// getCrawlConcurrency returns the count of workers to crawl blocks in channel.
+func getCrawlConcurrency(channelName string) int {
+	for _, c := range configuration.Conf().Channel {
+		if c.Name == channelName && c.Concurrency > 0 {
+			return c.Concurrency
+		}
+	}
+
+	return 2*runtime.NumCPU() + 1
+}
+
+// The maximum count of blocks to request in a batch request.
+const maxCountOfBlockInBatch = 10
+
+// crawlWorkerPool crawls the blocks of heights from the queue with the workers of channel. It lives while crawling,
+// and polling, block stream, backfill and crawl jobs of the channel send the heights into the same queue.
+// Each worker requests to the node selected by the node pool, so the requests are spread over the nodes.
+type crawlWorkerPool struct {
+	channelName string
+	queue       chan crawlTask
+	wg          sync.WaitGroup
+
+	mu      sync.Mutex
+	size    int           // Count of workers configured.
+	running int           // Count of workers running.
+	resized chan struct{} // Closed when the size is changed.
+
+	stopLock sync.RWMutex
+	stopped  chan struct{}
+	isStop   bool
+}
+
+// crawlTask is the heights to crawl in a batch request, and the batch of crawl it belongs to.
+type crawlTask struct {
+	nodes   *nodePool
+	heights []int64
+	batch   *crawlBatch
+}
+
+// crawlBatch collects the failed heights of the tasks sent into the pool by a crawl.
+type crawlBatch struct {
+	wg           sync.WaitGroup
+	failuresLock sync.Mutex
+	failures     map[int64]error
+}
+
+var crawlWorkerPools = make(map[string]*crawlWorkerPool)
+var crawlWorkerPoolsLock sync.Mutex
+
+// True after the worker pools are stopped, until they are started again. No pool is created while it is true.
+var crawlWorkerPoolsStopped bool
+
+func newCrawlWorkerPool(concurrency int, channelName string) *crawlWorkerPool {
+	pool := &crawlWorkerPool{
+		channelName: channelName,
+		queue:       make(chan crawlTask),
+		resized:     make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	pool.resize(concurrency)
+
+	return pool
+}
+
+// startCrawlWorkerPools starts the worker pool of each channel. The pools stopped before can be created again.
+func startCrawlWorkerPools(channelNames []string) {
+	crawlWorkerPoolsLock.Lock()
+	crawlWorkerPoolsStopped = false
+	crawlWorkerPoolsLock.Unlock()
+
+	for _, channelName := range channelNames {
+		_, _ = getCrawlWorkerPool(channelName)
+	}
+}
+
+// stopCrawlWorkerPools stops the worker pools of every channel after the tasks in progress.
+// The crawls after it fail until the pools are started again.
+func stopCrawlWorkerPools() {
+	crawlWorkerPoolsLock.Lock()
+	pools := crawlWorkerPools
+	crawlWorkerPools = make(map[string]*crawlWorkerPool)
+	crawlWorkerPoolsStopped = true
+	crawlWorkerPoolsLock.Unlock()
+
+	for _, pool := range pools {
+		pool.stop()
+	}
+}
+
+// getCrawlWorkerPool returns the worker pool of channel with the workers of the concurrency configured now.
+// The pool of channel added after the crawler began is started at the first crawl.
+// Returns SysErrCrawlerStopped after the pools are stopped.
+func getCrawlWorkerPool(channelName string) (*crawlWorkerPool, error) {
+	concurrency := getCrawlConcurrency(channelName)
+
+	crawlWorkerPoolsLock.Lock()
+	defer crawlWorkerPoolsLock.Unlock()
+
+	if crawlWorkerPoolsStopped {
+		return nil, isaacerror.SysErrCrawlerStopped
+	}
+
+	pool, ok := crawlWorkerPools[channelName]
+	if !ok {
+		pool = newCrawlWorkerPool(concurrency, channelName)
+		crawlWorkerPools[channelName] = pool
+	} else {
+		pool.resize(concurrency)
+	}
+
+	return pool, nil
+}
+
+// resize changes the count of workers. The workers over the count exit after their task.
+func (p *crawlWorkerPool) resize(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.size == concurrency {
+		return
+	}
+	p.size = concurrency
+	for p.running < p.size {
+		p.running++
+		p.wg.Add(1)
+		go p.work()
+	}
+	close(p.resized)
+	p.resized = make(chan struct{})
+}
+
+// getSize returns the count of workers configured.
+func (p *crawlWorkerPool) getSize() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.size
+}
+
+// exitIfOver returns true and leaves the pool if more workers are running than the size.
+// Otherwise returns the channel closed at the next resize.
+func (p *crawlWorkerPool) exitIfOver() (bool, chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running > p.size {
+		p.running--
+		return true, nil
+	}
+	return false, p.resized
+}
+
+func (p *crawlWorkerPool) work() {
+	defer p.wg.Done()
+
+	for {
+		exit, resized := p.exitIfOver()
+		if exit {
+			return
+		}
+
+		select {
+		case task := <-p.queue:
+			p.run(task)
+		case <-resized:
+		case <-p.stopped:
+			return
+		}
+	}
+}
+
+func (p *crawlWorkerPool) run(task crawlTask) {
+	defer task.batch.wg.Done()
+
+	failures := batchCrawlAndStoreBlock(task.nodes, p.channelName, task.heights)
+	for height, err := range failures {
+		logger.Errorf("Fail to crawl %d block in %s. %s", height, p.channelName, err)
+	}
+	task.batch.fail(failures)
+}
+
+// push sends the heights of batch into the queue. It blocks while every worker is busy.
+// The heights fail at once with SysErrCrawlerStopped if the pool is stopped.
+func (p *crawlWorkerPool) push(nodes *nodePool, batch *crawlBatch, heights []int64) error {
+	p.stopLock.RLock()
+	defer p.stopLock.RUnlock()
+
+	if p.isStop {
+		batch.fail(failHeights(heights, isaacerror.SysErrCrawlerStopped))
+		return isaacerror.SysErrCrawlerStopped
+	}
+
+	batch.wg.Add(1)
+	p.queue <- crawlTask{nodes: nodes, heights: heights, batch: batch}
+	return nil
+}
+
+// stop stops the workers after their task. The heights pushed later fail.
+func (p *crawlWorkerPool) stop() {
+	p.stopLock.Lock()
+	defer p.stopLock.Unlock()
+
+	if !p.isStop {
+		p.isStop = true
+		close(p.stopped)
+	}
+	p.wg.Wait()
+}
+
+// failHeights returns the error by height for the heights failed with the error.
+func failHeights(heights []int64, err error) map[int64]error {
+	failures := make(map[int64]error)
+	for _, h := range heights {
+		failures[h] = err
+	}
+	return failures
+}
+
+func newCrawlBatch() *crawlBatch {
+	return &crawlBatch{failures: make(map[int64]error)}
+}
+
+func (b *crawlBatch) fail(failures map[int64]error) {
+	b.failuresLock.Lock()
+	defer b.failuresLock.Unlock()
+
+	for height, err := range failures {
+		b.failures[height] = err
+	}
+}
+
+// wait returns the failed heights after every task of batch is done.
+func (b *crawlBatch) wait() map[int64]error {
+	b.wg.Wait()
+
+	return b.failures
+}
@@ -0,0 +1,150 @@
+package polarbear
+
+import (
+	"motherbear/backend/constants"
+	"motherbear/backend/db"
+	"motherbear/backend/isaacerror"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	conf "motherbear/backend/configuration"
+
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestWaitForRequestSlot(t *testing.T) {
+	conf.Conf().Blockchain.RequestPerSecond = 20
+	defer func() { conf.Conf().Blockchain.RequestPerSecond = 0 }()
+
+	// 21 requests to a node take 1 sec at least, even if they are requested at the same time.
+	begin := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 21; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			waitForRequestSlot("http://rate-limited-node")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, time.Since(begin) >= time.Second, true)
+
+	// The limit is for each node.
+	begin = time.Now()
+	waitForRequestSlot("http://other-node")
+	assert.Equal(t, time.Since(begin) < 50*time.Millisecond, true)
+}
+
+func TestCrawlWithWorkerPool(t *testing.T) {
+	dbpath := "test_worker_pool.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node1 := newFakeNode(0)
+	defer node1.close()
+	node2 := newFakeNode(0)
+	defer node2.close()
+
+	// Both nodes serve the same chain.
+	node1.appendBlocks(50)
+	node2.mu.Lock()
+	node2.blocks = node1.blocks
+	node2.mu.Unlock()
+
+	channelName := "channel1"
+	confPath := initFakeNodeConf(t, channelName, node1, node2)
+	defer os.Remove(confPath)
+
+	conf.Conf().Channel[0].Concurrency = 4
+	assert.Equal(t, getCrawlConcurrency(channelName), 4)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(50))
+
+	var count int64
+	Database().Model(&Block{}).Where("channel = ?", channelName).Count(&count)
+	assert.Equal(t, count, int64(50))
+
+	// The requests are spread over the nodes.
+	assert.Equal(t, node1.countOfRequests() > 20, true)
+	assert.Equal(t, node2.countOfRequests() > 20, true)
+
+	// A few blocks are crawled with workers less than the concurrency.
+	node1.appendBlocks(52)
+	node2.mu.Lock()
+	node2.blocks = node1.blocks
+	node2.mu.Unlock()
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(52))
+}
+
+// Test the worker pool of channel lives over the crawls, and is resized and stopped.
+func TestCrawlWorkerPoolOfChannel(t *testing.T) {
+	dbpath := "test_worker_pool_channel.db"
+	Setup(dbpath)
+	defer Teardown(dbpath)
+	db.InitDB(constants.DBTypeSqlite3, ":memory:")
+	defer db.CloseDBInstance()
+
+	node := newFakeNode(10)
+	defer node.close()
+
+	channelName := "channel_pool"
+	confPath := initFakeNodeConf(t, channelName, node)
+	defer os.Remove(confPath)
+	conf.Conf().Channel[0].Concurrency = 3
+
+	startCrawlWorkerPools([]string{channelName})
+	pool, err := getCrawlWorkerPool(channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pool.getSize(), 3)
+
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(10))
+
+	// The same pool crawls the next blocks with the workers of the concurrency changed.
+	conf.Conf().Channel[0].Concurrency = 1
+	node.appendBlocks(15)
+	if err := crawlBlockchain(channelName); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(15))
+	samePool, _ := getCrawlWorkerPool(channelName)
+	assert.Equal(t, samePool == pool, true)
+	assert.Equal(t, pool.getSize(), 1)
+
+	// The heights sent after the pool is stopped fail.
+	stopCrawlWorkerPools()
+	batch := newCrawlBatch()
+	assert.Equal(t, pool.push(getNodePool(channelName), batch, []int64{16}), isaacerror.SysErrCrawlerStopped)
+	failures := batch.wait()
+	assert.Equal(t, failures[16], isaacerror.SysErrCrawlerStopped)
+
+	// No pool is created after the pools are stopped, so nothing crawls.
+	_, err = getCrawlWorkerPool(channelName)
+	assert.Equal(t, err, isaacerror.SysErrCrawlerStopped)
+	node.appendBlocks(17)
+	failures = crawlHeightsInPool(getNodePool(channelName), channelName, []int64{16, 17})
+	assert.Equal(t, failures, map[int64]error{16: isaacerror.SysErrCrawlerStopped, 17: isaacerror.SysErrCrawlerStopped})
+	assert.Equal(t, GetCurrentBlockHeightInDB(channelName), int64(15))
+
+	// A new pool is started when the crawler begins again.
+	startCrawlWorkerPools([]string{channelName})
+	newPool, err := getCrawlWorkerPool(channelName)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, newPool != pool, true)
+	stopCrawlWorkerPools()
+
+	// The other tests crawl with the pools created on demand.
+	startCrawlWorkerPools(nil)
+}
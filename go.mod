@@ -27,6 +27,7 @@ require (
 	github.com/swaggo/swag v1.5.0
 	github.com/ybbus/jsonrpc v2.1.2+incompatible
 	golang.org/x/crypto v0.0.0-20190506204251-e1dfcc566284
+	golang.org/x/net v0.0.0-20190404232315-eb5bcb51f2a3
 	golang.org/x/sys v0.0.0-20190507160741-ecd444e8653b // indirect
 	golang.org/x/text v0.3.2
 	golang.org/x/tools v0.0.0-20190508025753-952990169864
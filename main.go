@@ -2,17 +2,29 @@ package main
 
 import (
 	"log"
+	"motherbear/backend/cli"
 	"motherbear/backend/constants"
 	"motherbear/backend/db"
+	"motherbear/backend/handlers/addresses"
 	"motherbear/backend/handlers/alerting"
 	"motherbear/backend/handlers/auth"
 	"motherbear/backend/handlers/blocks"
 	"motherbear/backend/handlers/channels"
+	"motherbear/backend/handlers/contracts"
+	"motherbear/backend/handlers/crawler"
+	"motherbear/backend/handlers/events"
+	"motherbear/backend/handlers/export"
+	"motherbear/backend/handlers/importer"
 	"motherbear/backend/handlers/nodes"
 	"motherbear/backend/handlers/nodetype"
+	"motherbear/backend/handlers/quarantine"
 	"motherbear/backend/handlers/resources"
+	"motherbear/backend/handlers/retention"
+	"motherbear/backend/handlers/search"
 	"motherbear/backend/handlers/settings"
+	"motherbear/backend/handlers/stats"
 	"motherbear/backend/handlers/symptom"
+	"motherbear/backend/handlers/tokens"
 	"motherbear/backend/handlers/txs"
 	"motherbear/backend/handlers/users"
 	"motherbear/backend/polarbear"
@@ -57,29 +69,41 @@ func init() {
 		os.MkdirAll(constants.DBFolderName, os.ModePerm)
 	}
 
+	// The migrate command applies the migrations by itself, or shows them in dry run.
+	migrateByCommand := len(os.Args) > 1 && os.Args[1] == cli.MigrateCommandName
+
+	// Check the DB types before opening them.
+	for _, dbConfig := range []DBConfig{Conf().ETC.DB[0], Conf().Blockchain.DB[0]} {
+		if err := dbConfig.Validate(); err != nil {
+			panic(err)
+		}
+	}
+
 	// Init ISSAC database
-	if Conf().ETC.DB[0].DBType == constants.DBTypeMysql {
-		// mysql param is (dbType, id, pass, database)
-		db.InitDB(constants.DBTypeMysql, Conf().ETC.DB[0].Id, Conf().ETC.DB[0].Pass, Conf().ETC.DB[0].Database)
-	} else if Conf().ETC.DB[0].DBType == constants.DBTypeSqlite3 {
-		// sqlite param is (dbType, path)
-		db.InitDB(constants.DBTypeSqlite3, Conf().ETC.DB[0].DBPath)
+	isaacDataSource, err := Conf().ETC.DB[0].DataSourceName()
+	if err != nil {
+		panic(err)
+	}
+	if migrateByCommand {
+		db.OpenDB(Conf().ETC.DB[0].DBType, isaacDataSource)
+	} else {
+		db.InitDB(Conf().ETC.DB[0].DBType, isaacDataSource)
 	}
 
 	// Init Block Crawling database
-	if Conf().Blockchain.DB[0].DBType == constants.DBTypeMysql {
-		// mysql param is (dbType, id, pass, database)
-		if err := polarbear.Init(constants.DBTypeMysql, Conf().Blockchain.DB[0].Id,
-			Conf().Blockchain.DB[0].Pass, Conf().Blockchain.DB[0].Database); os.IsExist(err) {
-			panic(err)
-		}
-	} else if Conf().Blockchain.DB[0].DBType == constants.DBTypeSqlite3 {
-		// sqlite param is (dbType, path)
-		if err := polarbear.Init(constants.DBTypeSqlite3, Conf().Blockchain.DB[0].DBPath); os.IsExist(err) {
-			panic(err)
-		}
+	crawlingDataSource, err := Conf().Blockchain.DB[0].DataSourceName()
+	if err != nil {
+		panic(err)
+	}
+	if migrateByCommand {
+		polarbear.OpenDB(Conf().Blockchain.DB[0].DBType, crawlingDataSource)
+	} else if err := polarbear.Init(Conf().Blockchain.DB[0].DBType, crawlingDataSource); err != nil {
+		panic(err)
 	}
+}
 
+// beginToCrawl runs crawling only for the server, not for the commands.
+func beginToCrawl() {
 	// Run crawling data from prometheus.
 	_, err := prom_crawler.BeginToCrawl()
 	if err != nil {
@@ -103,9 +127,16 @@ func init() {
 // @BasePath /api/v1
 func main() {
 
+	// Run the command instead of the server, like "isaac export txs -channel loopchain_default".
+	if len(os.Args) > 1 {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
 	// Use 4 CPU cores as default.
 	runtime.GOMAXPROCS(4)
 
+	beginToCrawl()
+
 	// Set the router as the default one shipped with Gin.
 	router := gin.Default()
 	router.Use(CORSMiddleware())
@@ -140,6 +171,11 @@ func main() {
 		}
 	})
 
+	// Serve the metrics of crawler for prometheus, which scrapes them without the token.
+	if Conf().Blockchain.CrawlerMetrics {
+		router.GET(constants.CrawlerMetricsURL, crawler.GetMetricsHandler)
+	}
+
 	// Move swagger document under /api/v1.
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	apiV1 := router.Group(constants.APIVersionURL)
@@ -193,6 +229,52 @@ func main() {
 		apiV1.GET(constants.TxGETListAPIURL, txs.GetHandlerList)
 		apiV1.GET(constants.TxGETAPIURL, txs.GetHandler)
 
+		// /api/v1/events
+		apiV1.GET(constants.EventGETListAPIURL, events.GetHandlerList)
+
+		// /api/v1/addresses
+		apiV1.GET(constants.AddressGETAPIURL, addresses.GetHandler)
+
+		// /api/v1/contracts
+		apiV1.GET(constants.ContractGETListAPIURL, contracts.GetHandlerList)
+		apiV1.GET(constants.ContractGETAPIURL, contracts.GetHandler)
+
+		// /api/v1/stats
+		apiV1.GET(constants.StatsVolumeGETAPIURL, stats.GetHandlerVolume)
+
+		// /api/v1/tokens
+		apiV1.GET(constants.TokenGETListAPIURL, tokens.GetHandlerList)
+		apiV1.GET(constants.TokenHoldersGETAPIURL, tokens.GetHandlerHolders)
+		apiV1.GET(constants.TokenTransfersGETAPIURL, tokens.GetHandlerTransfers)
+
+		// /api/v1/quarantine
+		apiV1.GET(constants.QuarantineGETListAPIURL, quarantine.GetHandlerList)
+		apiV1.GET(constants.QuarantineGETAPIURL, quarantine.GetHandler)
+		apiV1.POST(constants.QuarantineRetryPOSTAPIURL, quarantine.PostHandlerRetry)
+
+		// /api/v1/retention
+		apiV1.GET(constants.RetentionGETAPIURL, retention.GetHandler)
+
+		// /api/v1/search
+		apiV1.GET(constants.SearchGETAPIURL, search.GetHandler)
+
+		// /api/v1/crawler
+		apiV1.GET(constants.CrawlerStatusGETAPIURL, crawler.GetStatusHandler)
+		apiV1.POST(constants.CrawlerPausePOSTAPIURL, crawler.PostHandlerPause)
+		apiV1.POST(constants.CrawlerResumePOSTAPIURL, crawler.PostHandlerResume)
+		apiV1.GET(constants.CrawlerJobsGETListAPIURL, crawler.GetHandlerJobList)
+		apiV1.POST(constants.CrawlerJobsPOSTAPIURL, crawler.PostHandlerJob)
+		apiV1.GET(constants.CrawlerJobGETAPIURL, crawler.GetHandlerJob)
+		apiV1.POST(constants.CrawlerJobCancelPOSTAPIURL, crawler.PostHandlerCancelJob)
+
+		// /api/v1/export
+		apiV1.GET(constants.ExportBlocksGETAPIURL, export.GetHandlerBlocks)
+		apiV1.GET(constants.ExportTxsGETAPIURL, export.GetHandlerTxs)
+		apiV1.GET(constants.ExportSymptomsGETAPIURL, export.GetHandlerSymptoms)
+
+		// /api/v1/import
+		apiV1.POST(constants.ImportPOSTAPIURL, importer.PostHandler)
+
 		// /api/v1/resources
 		apiV1.GET(constants.ResourcesGETAPIURL, resources.GetHandler)
 